@@ -0,0 +1 @@
+ALTER TABLE users DROP COLUMN last_seen;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS user_event_seq (
+    "user_id" TEXT not null,
+    "last_seq" INTEGER not null default 0,
+    primary key ("user_id")
+);
+
+CREATE TABLE IF NOT EXISTS user_events (
+    "user_id" TEXT not null,
+    "seq" INTEGER not null,
+    "payload" TEXT not null,
+    "created_at" datetime not null default CURRENT_TIMESTAMP,
+    primary key ("user_id", "seq")
+);
@@ -0,0 +1,2 @@
+ALTER TABLE notifications ADD COLUMN created_at DATETIME DEFAULT NULL;
+UPDATE notifications SET created_at = CURRENT_TIMESTAMP WHERE created_at IS NULL;
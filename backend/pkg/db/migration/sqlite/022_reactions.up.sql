@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS reactions (
+    "target_id" TEXT not null,
+    "target_type" TEXT not null,
+    "user_id" TEXT not null,
+    "type" TEXT not null,
+    "created_at" datetime not null default CURRENT_TIMESTAMP,
+    primary key ("target_id", "user_id")
+);
@@ -0,0 +1,2 @@
+ALTER TABLE posts ADD COLUMN edited_at DATETIME DEFAULT NULL;
+ALTER TABLE comments ADD COLUMN edited_at DATETIME DEFAULT NULL;
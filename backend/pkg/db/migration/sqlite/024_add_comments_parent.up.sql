@@ -0,0 +1 @@
+ALTER TABLE comments ADD COLUMN parent_id TEXT DEFAULT NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE event_users DROP COLUMN occurrence;
+ALTER TABLE event DROP COLUMN exdates;
+ALTER TABLE event DROP COLUMN rrule;
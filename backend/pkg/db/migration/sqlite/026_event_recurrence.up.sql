@@ -0,0 +1,6 @@
+-- RRULE style recurrence, exdates holds skipped occurrences separated by comma
+ALTER TABLE event ADD COLUMN rrule TEXT DEFAULT NULL;
+ALTER TABLE event ADD COLUMN exdates TEXT DEFAULT NULL;
+
+-- start of occurrence the response belongs to, empty for single events
+ALTER TABLE event_users ADD COLUMN occurrence TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+DROP TABLE scheduled_job_recipients;
+DROP INDEX scheduled_jobs_due;
+DROP TABLE scheduled_jobs;
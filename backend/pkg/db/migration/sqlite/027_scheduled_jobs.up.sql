@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS scheduled_jobs (
+    "job_id" TEXT not null,
+    "type" TEXT not null,
+    "job_key" TEXT not null,
+    "payload" TEXT not null default '',
+    "run_at" INTEGER not null, -- unix seconds
+    "attempts" INTEGER not null default 0,
+    "last_error" TEXT not null default '',
+    "done_at" INTEGER default NULL,
+    "created_at" datetime not null default CURRENT_TIMESTAMP,
+    primary key ("job_id"),
+    unique ("type", "job_key")
+);
+
+CREATE INDEX IF NOT EXISTS scheduled_jobs_due ON scheduled_jobs (done_at, run_at);
+
+-- users job already delivered to, so rerun after restart skips them
+CREATE TABLE IF NOT EXISTS scheduled_job_recipients (
+    "job_id" TEXT not null,
+    "user_id" TEXT not null,
+    primary key ("job_id", "user_id")
+);
@@ -0,0 +1,2 @@
+ALTER TABLE event_users DROP COLUMN waitlisted_at;
+ALTER TABLE event DROP COLUMN capacity;
@@ -0,0 +1,5 @@
+-- most users going to event (or each occurrence), NULL if unlimited
+ALTER TABLE event ADD COLUMN capacity INTEGER DEFAULT NULL;
+
+-- set while response is 'waitlisted', orders the waitlist
+ALTER TABLE event_users ADD COLUMN waitlisted_at datetime DEFAULT NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE event DROP COLUMN cancelled_at;
+ALTER TABLE event DROP COLUMN edited_at;
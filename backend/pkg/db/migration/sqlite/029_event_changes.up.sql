@@ -0,0 +1,3 @@
+ALTER TABLE event ADD COLUMN edited_at datetime DEFAULT NULL;
+-- cancelled events stay listed, they only can't be answered
+ALTER TABLE event ADD COLUMN cancelled_at datetime DEFAULT NULL;
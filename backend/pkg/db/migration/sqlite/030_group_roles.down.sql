@@ -0,0 +1 @@
+ALTER TABLE group_users DROP COLUMN role;
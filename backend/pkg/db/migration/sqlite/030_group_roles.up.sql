@@ -0,0 +1,11 @@
+-- role of user in group: owner, moderator or member
+-- groups.administrator keeps pointing to the owner
+ALTER TABLE group_users ADD COLUMN role TEXT NOT NULL DEFAULT 'member';
+
+-- owners of older groups were not always saved as members
+INSERT INTO group_users (group_id, user_id)
+SELECT group_id, administrator FROM groups
+WHERE administrator NOT IN (SELECT user_id FROM group_users WHERE group_users.group_id = groups.group_id);
+
+UPDATE group_users SET role = 'owner'
+WHERE user_id = (SELECT administrator FROM groups WHERE groups.group_id = group_users.group_id);
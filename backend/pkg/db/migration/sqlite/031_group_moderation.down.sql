@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS group_moderation_log_group;
+DROP TABLE IF EXISTS group_moderation_log;
+ALTER TABLE group_users DROP COLUMN muted_until;
+DROP TABLE IF EXISTS group_bans;
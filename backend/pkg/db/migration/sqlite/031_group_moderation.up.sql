@@ -0,0 +1,26 @@
+-- banned users can't request to join, join public group or be invited
+CREATE TABLE IF NOT EXISTS group_bans (
+    "group_id" TEXT not null,
+    "user_id" TEXT not null,
+    "banned_by" TEXT not null,
+    "created_at" datetime not null default CURRENT_TIMESTAMP,
+    primary key ("group_id", "user_id")
+);
+
+-- RFC3339 time until member can't post, chat or create events
+ALTER TABLE group_users ADD COLUMN muted_until TEXT DEFAULT NULL;
+
+-- audit trail of kicks, bans and mutes
+CREATE TABLE IF NOT EXISTS group_moderation_log (
+    "action_id" TEXT not null,
+    "group_id" TEXT not null,
+    "actor_id" TEXT not null,
+    "target_id" TEXT not null,
+    "action" TEXT not null,
+    "reason" TEXT not null default '',
+    "expires_at" TEXT default NULL, -- end of mute
+    "created_at" datetime not null default CURRENT_TIMESTAMP,
+    primary key ("action_id")
+);
+
+CREATE INDEX IF NOT EXISTS group_moderation_log_group ON group_moderation_log (group_id, created_at);
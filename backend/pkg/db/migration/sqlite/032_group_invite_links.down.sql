@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS group_invite_link_uses;
+DROP TABLE IF EXISTS group_invite_links;
@@ -0,0 +1,20 @@
+-- shareable links that let anyone with token join group
+CREATE TABLE IF NOT EXISTS group_invite_links (
+    "link_id" TEXT not null,
+    "group_id" TEXT not null,
+    "token" TEXT not null unique,
+    "created_by" TEXT not null,
+    "expires_at" TEXT default NULL, -- RFC3339, NULL never expires
+    "max_uses" INTEGER default NULL, -- NULL is unlimited
+    "revoked_at" datetime default NULL,
+    "created_at" datetime not null default CURRENT_TIMESTAMP,
+    primary key ("link_id")
+);
+
+-- users that joined through link
+CREATE TABLE IF NOT EXISTS group_invite_link_uses (
+    "link_id" TEXT not null,
+    "user_id" TEXT not null,
+    "created_at" datetime not null default CURRENT_TIMESTAMP,
+    primary key ("link_id", "user_id")
+);
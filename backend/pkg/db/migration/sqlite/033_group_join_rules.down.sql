@@ -0,0 +1,3 @@
+ALTER TABLE groups DROP COLUMN auto_approve;
+DROP TABLE IF EXISTS group_join_answers;
+DROP TABLE IF EXISTS group_questions;
@@ -0,0 +1,20 @@
+-- questions users answer when requesting to join private group
+CREATE TABLE IF NOT EXISTS group_questions (
+    "group_id" TEXT not null,
+    "position" INTEGER not null,
+    "question" TEXT not null,
+    primary key ("group_id", "position")
+);
+
+-- answers of pending request, question text is copied so edits don't change what was asked
+CREATE TABLE IF NOT EXISTS group_join_answers (
+    "group_id" TEXT not null,
+    "user_id" TEXT not null,
+    "position" INTEGER not null,
+    "question" TEXT not null,
+    "answer" TEXT not null,
+    primary key ("group_id", "user_id", "position")
+);
+
+-- rule that accepts requests without moderator: '', followed_by_owner or followed_by_moderator
+ALTER TABLE groups ADD COLUMN auto_approve TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+ALTER TABLE posts DROP COLUMN announced_at;
+ALTER TABLE posts DROP COLUMN pinned_at;
@@ -0,0 +1,3 @@
+-- pinned group posts lead group feed, announcements notified all members
+ALTER TABLE posts ADD COLUMN pinned_at datetime DEFAULT NULL;
+ALTER TABLE posts ADD COLUMN announced_at datetime DEFAULT NULL;
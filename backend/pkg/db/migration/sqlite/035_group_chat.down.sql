@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS group_chat_mutes;
+ALTER TABLE group_messages DROP COLUMN mentioned;
+ALTER TABLE messages DROP COLUMN reply_to;
@@ -0,0 +1,13 @@
+-- message quoted by reply, NULL for plain messages
+ALTER TABLE messages ADD COLUMN reply_to TEXT DEFAULT NULL;
+
+-- receiver was @mentioned, counts as unread even in muted chat
+ALTER TABLE group_messages ADD COLUMN mentioned INT DEFAULT 0;
+
+-- members who muted group chat, they get no live pushes or unread counts except mentions
+CREATE TABLE IF NOT EXISTS group_chat_mutes (
+    "group_id" TEXT not null,
+    "user_id" TEXT not null,
+    "created_at" datetime not null default CURRENT_TIMESTAMP,
+    primary key ("group_id", "user_id")
+);
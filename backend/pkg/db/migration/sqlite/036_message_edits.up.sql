@@ -0,0 +1,11 @@
+-- edited marker and tombstone of message deleted for everyone, content is cleared
+ALTER TABLE messages ADD COLUMN edited_at datetime DEFAULT NULL;
+ALTER TABLE messages ADD COLUMN deleted_at datetime DEFAULT NULL;
+
+-- messages user deleted only for themselves, hidden from their chat history
+CREATE TABLE IF NOT EXISTS message_deletions (
+    "message_id" TEXT not null,
+    "user_id" TEXT not null,
+    "created_at" datetime not null default CURRENT_TIMESTAMP,
+    primary key ("message_id", "user_id")
+);
@@ -0,0 +1,4 @@
+ALTER TABLE group_messages DROP COLUMN read_at;
+ALTER TABLE group_messages DROP COLUMN delivered_at;
+ALTER TABLE messages DROP COLUMN read_at;
+ALTER TABLE messages DROP COLUMN delivered_at;
@@ -0,0 +1,12 @@
+-- per recipient delivery and read time, delivered when recipient had open connection
+ALTER TABLE messages ADD COLUMN delivered_at datetime DEFAULT NULL;
+ALTER TABLE messages ADD COLUMN read_at datetime DEFAULT NULL;
+ALTER TABLE group_messages ADD COLUMN delivered_at datetime DEFAULT NULL;
+ALTER TABLE group_messages ADD COLUMN read_at datetime DEFAULT NULL;
+
+-- messages read before receipts existed get time they were sent
+UPDATE messages SET delivered_at = created_at, read_at = created_at WHERE is_read = 1;
+UPDATE group_messages SET
+    delivered_at = (SELECT created_at FROM messages WHERE message_id = group_messages.message_id),
+    read_at = (SELECT created_at FROM messages WHERE message_id = group_messages.message_id)
+WHERE is_read = 1;
@@ -0,0 +1,23 @@
+package db
+
+import "strings"
+
+// max ids bound in one IN (...) query, keeps below sqlite variable limit
+const batchSize = 500
+
+// returns "?,?,?" with n placeholders
+func placeholders(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strings.Repeat("?,", n-1) + "?"
+}
+
+// converts ids to query arguments
+func stringArgs(ids []string) []interface{} {
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return args
+}
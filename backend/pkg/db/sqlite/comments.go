@@ -11,25 +11,78 @@ type CommentRepository struct {
 
 func (repo *CommentRepository) Get(postID string) ([]models.Comment, error) {
 	var comments []models.Comment
-	rows, err := repo.DB.Query("SELECT comment_id, created_by, content, image, created_at FROM comments WHERE post_id = ? ORDER BY created_at DESC;", postID)
+	rows, err := repo.DB.Query("SELECT comment_id, COALESCE(parent_id, ''), created_by, content, image, created_at, edited_at FROM comments WHERE post_id = ? ORDER BY created_at DESC;", postID)
 	if err != nil {
 		return comments, err
 	}
 	for rows.Next() {
 		var comment models.Comment
-		rows.Scan(&comment.ID, &comment.AuthorID, &comment.Content, &comment.ImagePath, &comment.CreatedAt)
+		rows.Scan(&comment.ID, &comment.ParentID, &comment.AuthorID, &comment.Content, &comment.ImagePath, &comment.CreatedAt, &comment.EditedAt)
 		comments = append(comments, comment)
 	}
 	return comments, nil
 }
 
+// comments of several posts grouped by post id, loaded in one query per batchSize posts
+func (repo *CommentRepository) GetForPosts(postIDs []string) (map[string][]models.Comment, error) {
+	comments := make(map[string][]models.Comment, len(postIDs))
+	for start := 0; start < len(postIDs); start += batchSize {
+		chunk := postIDs[start:min(start+batchSize, len(postIDs))]
+		rows, err := repo.DB.Query("SELECT comment_id, post_id, COALESCE(parent_id, ''), created_by, content, image, created_at, edited_at FROM comments WHERE post_id IN ("+placeholders(len(chunk))+") ORDER BY created_at DESC;", stringArgs(chunk)...)
+		if err != nil {
+			return comments, err
+		}
+		for rows.Next() {
+			var comment models.Comment
+			rows.Scan(&comment.ID, &comment.PostID, &comment.ParentID, &comment.AuthorID, &comment.Content, &comment.ImagePath, &comment.CreatedAt, &comment.EditedAt)
+			comments[comment.PostID] = append(comments[comment.PostID], comment)
+		}
+		rows.Close()
+	}
+	return comments, nil
+}
+
+func (repo *CommentRepository) GetData(commentID string) (models.Comment, error) {
+	var comment models.Comment
+	err := repo.DB.QueryRow("SELECT comment_id, post_id, COALESCE(parent_id, ''), created_by, content, image, created_at, edited_at FROM comments WHERE comment_id = ?", commentID).
+		Scan(&comment.ID, &comment.PostID, &comment.ParentID, &comment.AuthorID, &comment.Content, &comment.ImagePath, &comment.CreatedAt, &comment.EditedAt)
+	return comment, err
+}
+
 func (repo *CommentRepository) New(comment models.Comment) error {
-	stmt, err := repo.DB.Prepare("INSERT INTO comments (comment_id, post_id, created_by, content,image) values (?,?,?,?,?)")
+	stmt, err := repo.DB.Prepare("INSERT INTO comments (comment_id, post_id, parent_id, created_by, content,image) values (?,?,(NULLIF(?,'')),?,?,?)")
 	if err != nil {
 		return err
 	}
-	if _, err := stmt.Exec(comment.ID, comment.PostID, comment.AuthorID, comment.Content, comment.ImagePath); err != nil {
+	if _, err := stmt.Exec(comment.ID, comment.PostID, comment.ParentID, comment.AuthorID, comment.Content, comment.ImagePath); err != nil {
 		return err
 	}
 	return nil
 }
+
+func (repo *CommentRepository) Update(comment models.Comment) error {
+	_, err := repo.DB.Exec("UPDATE comments SET content = ?, image = ?, edited_at = CURRENT_TIMESTAMP WHERE comment_id = ?", comment.Content, comment.ImagePath, comment.ID)
+	return err
+}
+
+func (repo *CommentRepository) Delete(commentID string) error {
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	// comment and all replies below it
+	const thread = `WITH RECURSIVE thread(id) AS (
+			SELECT ? UNION SELECT comment_id FROM comments JOIN thread ON comments.parent_id = thread.id
+		)`
+	if _, err := tx.Exec(thread+" DELETE FROM notifications WHERE type IN ('REACTION', 'COMMENT_REPLY') AND content IN (SELECT id FROM thread)", commentID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(thread+" DELETE FROM reactions WHERE target_id IN (SELECT id FROM thread)", commentID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(thread+" DELETE FROM comments WHERE comment_id IN (SELECT id FROM thread)", commentID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,90 @@
+package db
+
+import (
+	"database/sql"
+	"social-network/pkg/models"
+	"time"
+)
+
+type DeliveryRepository struct {
+	DB *sql.DB
+}
+
+// reserve next sequence number for each user and save event with it
+// nothing is saved if any insert fails
+func (repo *DeliveryRepository) Append(userIDs []string, payload []byte) (map[string]int64, error) {
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	reserve, err := tx.Prepare(`
+		INSERT INTO user_event_seq (user_id, last_seq) VALUES (?, 1)
+		ON CONFLICT(user_id) DO UPDATE SET last_seq = last_seq + 1
+		RETURNING last_seq`)
+	if err != nil {
+		return nil, err
+	}
+	defer reserve.Close()
+	save, err := tx.Prepare("INSERT INTO user_events (user_id, seq, payload) VALUES (?,?,?)")
+	if err != nil {
+		return nil, err
+	}
+	defer save.Close()
+
+	seqs := make(map[string]int64, len(userIDs))
+	for _, userID := range userIDs {
+		if _, ok := seqs[userID]; ok {
+			continue
+		}
+		var seq int64
+		if err := reserve.QueryRow(userID).Scan(&seq); err != nil {
+			return nil, err
+		}
+		if _, err := save.Exec(userID, seq, string(payload)); err != nil {
+			return nil, err
+		}
+		seqs[userID] = seq
+	}
+	return seqs, tx.Commit()
+}
+
+func (repo *DeliveryRepository) GetSince(userID string, seq int64, limit int) ([]models.QueuedEvent, error) {
+	var events []models.QueuedEvent
+	rows, err := repo.DB.Query(`
+		SELECT seq, payload, created_at FROM user_events
+		WHERE user_id = ? AND seq > ?
+		ORDER BY seq ASC
+		LIMIT ?`, userID, seq, limit)
+	if err != nil {
+		return events, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		event := models.QueuedEvent{UserID: userID}
+		var payload string
+		if err := rows.Scan(&event.Seq, &payload, &event.CreatedAt); err != nil {
+			return events, err
+		}
+		event.Payload = []byte(payload)
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+func (repo *DeliveryRepository) GetBounds(userID string) (int64, int64, error) {
+	var oldest, last int64
+	err := repo.DB.QueryRow(`
+		SELECT
+			IFNULL((SELECT MIN(seq) FROM user_events WHERE user_id = ?), 0),
+			IFNULL((SELECT last_seq FROM user_event_seq WHERE user_id = ?), 0)`, userID, userID).Scan(&oldest, &last)
+	return oldest, last, err
+}
+
+// created_at is UTC text of CURRENT_TIMESTAMP, before is compared in the same form
+func (repo *DeliveryRepository) Prune(before time.Time) error {
+	_, err := repo.DB.Exec("DELETE FROM user_events WHERE created_at < ?", before.UTC().Format("2006-01-02 15:04:05"))
+	return err
+}
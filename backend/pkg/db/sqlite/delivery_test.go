@@ -0,0 +1,35 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPruneEvents(t *testing.T) {
+	repo := &DeliveryRepository{DB: newTestDB(t)}
+	for i := 0; i < 2; i++ {
+		if _, err := repo.Append([]string{"alice", "bob"}, []byte("{}")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().UTC().Add(-8 * 24 * time.Hour).Format("2006-01-02 15:04:05")
+	if _, err := repo.DB.Exec("UPDATE user_events SET created_at = ? WHERE seq = 1", old); err != nil {
+		t.Fatal(err)
+	}
+
+	// time zone of provided time doesn't matter
+	east := time.FixedZone("UTC+14", 14*60*60)
+	if err := repo.Prune(time.Now().In(east).Add(-7 * 24 * time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	for _, user := range []string{"alice", "bob"} {
+		oldest, last, err := repo.GetBounds(user)
+		if err != nil || oldest != 2 || last != 2 {
+			t.Errorf("%s: oldest %d, last %d, err %v, want 2, 2", user, oldest, last, err)
+		}
+		events, err := repo.GetSince(user, 0, 10)
+		if err != nil || len(events) != 1 || time.Since(events[0].CreatedAt) > time.Minute {
+			t.Errorf("%s: events %+v, err %v", user, events, err)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package db
 
 import (
 	"database/sql"
+	"sort"
+	"strings"
 	"time"
 	"social-network/pkg/models"
 )
@@ -23,7 +25,13 @@ func (repo *EventRepository) GetAll(groupID string) ([]models.Event, error) {
 			e.created_at,
 			u.first_name,
 			u.last_name,
-			u.email
+			u.email,
+			e.edited_at,
+			e.cancelled_at,
+			e.capacity,
+			e.rrule,
+			e.exdates,
+			''
 		FROM event e 
 		JOIN users u ON e.created_by = u.user_id 
 		WHERE e.group_id = ? 
@@ -31,12 +39,91 @@ func (repo *EventRepository) GetAll(groupID string) ([]models.Event, error) {
 	if err != nil {
 		return events, err
 	}
+	return scanEvents(rows)
+}
+
+// dates are compared after reading, as they are saved with different offsets
+func (repo *EventRepository) GetUpcoming(t time.Time) ([]models.Event, error) {
+	rows, err := repo.DB.Query(`
+		SELECT 
+			e.event_id, 
+			e.group_id, 
+			e.created_by, 
+			e.title, 
+			e.content, 
+			e.date,
+			e.created_at,
+			u.first_name,
+			u.last_name,
+			u.email,
+			e.edited_at,
+			e.cancelled_at,
+			e.capacity,
+			e.rrule,
+			e.exdates,
+			''
+		FROM event e 
+		JOIN users u ON e.created_by = u.user_id 
+		WHERE e.cancelled_at IS NULL`)
+	if err != nil {
+		return []models.Event{}, err
+	}
+	events, err := scanEvents(rows)
+	upcoming := []models.Event{}
+	for _, event := range events {
+		if event.Recurrence != nil || event.DateTime.After(t) {
+			upcoming = append(upcoming, event)
+		}
+	}
+	return upcoming, err
+}
+
+// events user answered going or maybe, only from groups user still belongs to
+// answered occurrences of recurring events are returned as separate events
+func (repo *EventRepository) GetUserCalendar(userID string) ([]models.Event, error) {
+	rows, err := repo.DB.Query(`
+		SELECT 
+			e.event_id, 
+			e.group_id, 
+			e.created_by, 
+			e.title, 
+			e.content, 
+			e.date,
+			e.created_at,
+			u.first_name,
+			u.last_name,
+			u.email,
+			e.edited_at,
+			e.cancelled_at,
+			e.capacity,
+			e.rrule,
+			e.exdates,
+			eu.occurrence
+		FROM event e 
+		JOIN users u ON e.created_by = u.user_id 
+		JOIN event_users eu ON eu.event_id = e.event_id AND eu.user_id = ?
+		WHERE eu.response IN ('going', 'maybe')
+		  AND ((SELECT administrator FROM groups WHERE group_id = e.group_id) = ?
+			OR (SELECT COUNT(*) FROM group_users WHERE group_id = e.group_id AND user_id = ?) > 0)
+		ORDER BY e.date DESC`, userID, userID, userID)
+	if err != nil {
+		return []models.Event{}, err
+	}
+	return scanEvents(rows)
+}
+
+// reads event rows selected with author names (see GetAll)
+func scanEvents(rows *sql.Rows) ([]models.Event, error) {
+	var events = []models.Event{}
 	defer rows.Close()
 	
 	for rows.Next() {
 		var event models.Event
 		var dateStr string
 		var createdAtStr string
+		var capacity sql.NullInt64
+		var rrule, exdates sql.NullString
+		var occurrence string
 		err := rows.Scan(
 			&event.ID, 
 			&event.GroupID, 
@@ -48,6 +135,12 @@ func (repo *EventRepository) GetAll(groupID string) ([]models.Event, error) {
 			&event.Author.FirstName,
 			&event.Author.LastName,
 			&event.Author.Email,
+			&event.EditedAt,
+			&event.CancelledAt,
+			&capacity,
+			&rrule,
+			&exdates,
+			&occurrence,
 		)
 		if err != nil {
 			continue
@@ -66,6 +159,13 @@ func (repo *EventRepository) GetAll(groupID string) ([]models.Event, error) {
 		
 		// Set author ID
 		event.Author.ID = event.AuthorID
+		event.Capacity = int(capacity.Int64)
+		event.Recurrence = eventRecurrence(rrule, exdates)
+		if occurrence != "" {
+			if start, err := time.Parse(time.RFC3339, occurrence); err == nil {
+				event = atOccurrence(event, start)
+			}
+		}
 		
 		events = append(events, event)
 	}
@@ -84,7 +184,12 @@ func (repo *EventRepository) GetData(eventId string) (models.Event, error) {
 			e.created_at,
 			u.first_name,
 			u.last_name,
-			u.email
+			u.email,
+			e.edited_at,
+			e.cancelled_at,
+			e.capacity,
+			e.rrule,
+			e.exdates
 		FROM event e 
 		JOIN users u ON e.created_by = u.user_id 
 		WHERE e.event_id = ?`, eventId)
@@ -92,6 +197,8 @@ func (repo *EventRepository) GetData(eventId string) (models.Event, error) {
 	var event models.Event
 	var dateStr string
 	var createdAtStr string
+	var capacity sql.NullInt64
+	var rrule, exdates sql.NullString
 	
 	err := row.Scan(
 		&event.Title, 
@@ -104,6 +211,11 @@ func (repo *EventRepository) GetData(eventId string) (models.Event, error) {
 		&event.Author.FirstName,
 		&event.Author.LastName,
 		&event.Author.Email,
+		&event.EditedAt,
+		&event.CancelledAt,
+		&capacity,
+		&rrule,
+		&exdates,
 	)
 	if err != nil {
 		return event, err
@@ -122,110 +234,239 @@ func (repo *EventRepository) GetData(eventId string) (models.Event, error) {
 	
 	// Set author ID
 	event.Author.ID = event.AuthorID
+	event.Capacity = int(capacity.Int64)
+	event.Recurrence = eventRecurrence(rrule, exdates)
 	
 	return event, nil
 }
 
-func (repo *EventRepository) Save(event models.Event) error {
-	stmt, err := repo.DB.Prepare("INSERT INTO event (event_id, group_id, created_by, content, title, date) values (?,?,?,?,?,?)")
-	if err != nil {
-		return err
+// reads saved repetition rule, nil for single events
+func eventRecurrence(rrule, exdates sql.NullString) *models.Recurrence {
+	if !rrule.Valid || rrule.String == "" {
+		return nil
 	}
-	// Use DateTime (time.Time) instead of Date (string) for proper time storage
-	if _, err := stmt.Exec(event.ID, event.GroupID, event.AuthorID, event.Content, event.Title, event.DateTime); err != nil {
-		return err
+	var exceptions []string
+	if exdates.String != "" {
+		exceptions = strings.Split(exdates.String, ",")
 	}
-	return nil
+	rule, err := models.ParseRRule(rrule.String, exceptions)
+	if err != nil {
+		return nil
+	}
+	return rule
 }
 
-func (repo *EventRepository) AddParticipant(eventID, userID string) error {
-	stmt, err := repo.DB.Prepare("INSERT INTO event_users (event_id, user_id) values (?,?)")
+// copy of recurring event moved to start of one occurrence
+func atOccurrence(event models.Event, start time.Time) models.Event {
+	event.Occurrence = models.OccurrenceKey(start)
+	event.DateTime = start
+	event.Date = start.Format(time.RFC3339)
+	return event
+}
+
+func (repo *EventRepository) Save(event models.Event) error {
+	stmt, err := repo.DB.Prepare("INSERT INTO event (event_id, group_id, created_by, content, title, date, capacity, rrule, exdates) values (?,?,?,?,?,?,?,?,?)")
 	if err != nil {
 		return err
 	}
-	if _, err := stmt.Exec(eventID, userID); err != nil {
+	capacity := sql.NullInt64{Int64: int64(event.Capacity), Valid: event.Capacity > 0}
+	var rrule, exdates sql.NullString
+	if event.Recurrence != nil {
+		rrule = sql.NullString{String: event.Recurrence.RRule(), Valid: true}
+		exdates = sql.NullString{String: strings.Join(event.Recurrence.Exceptions, ","), Valid: len(event.Recurrence.Exceptions) > 0}
+	}
+	// Use DateTime (time.Time) instead of Date (string) for proper time storage
+	if _, err := stmt.Exec(event.ID, event.GroupID, event.AuthorID, event.Content, event.Title, event.DateTime, capacity, rrule, exdates); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (repo *EventRepository) RemoveParticipant(eventID, userID string) error {
-	stmt, err := repo.DB.Prepare("DELETE FROM event_users WHERE user_id = ? AND event_id = ?")
+func (repo *EventRepository) UpdateResponse(eventID, occurrence, userID, response string) (models.RSVPResult, error) {
+	result := models.RSVPResult{Response: response}
+	tx, err := repo.DB.Begin()
 	if err != nil {
-		return err
+		return result, err
 	}
-	_, err = stmt.Exec(userID, eventID)
-	if err != nil {
-		return err
-	}
-	return nil
-}
+	defer tx.Rollback()
 
-func (repo *EventRepository) UpdateResponse(eventID, userID, response string) error {
+	var capacity sql.NullInt64
+	if err = tx.QueryRow("SELECT capacity FROM event WHERE event_id = ?", eventID).Scan(&capacity); err != nil {
+		return result, err
+	}
 	// First check if user already has a response
-	var count int
-	row := repo.DB.QueryRow("SELECT COUNT(*) FROM event_users WHERE event_id = ? AND user_id = ?", eventID, userID)
-	if err := row.Scan(&count); err != nil {
-		return err
+	var current string
+	err = tx.QueryRow("SELECT response FROM event_users WHERE event_id = ? AND occurrence = ? AND user_id = ?", eventID, occurrence, userID).Scan(&current)
+	if err != nil && err != sql.ErrNoRows {
+		return result, err
 	}
-	
-	if count > 0 {
-		// Update existing response
-		stmt, err := repo.DB.Prepare("UPDATE event_users SET response = ? WHERE event_id = ? AND user_id = ?")
+	exists := err == nil
+
+	if response == "going" && capacity.Int64 > 0 && current != "going" {
+		going, err := countGoing(tx, eventID, occurrence)
 		if err != nil {
-			return err
+			return result, err
 		}
-		_, err = stmt.Exec(response, eventID, userID)
-		return err
+		// waitlisted user keeps place in the queue
+		if current == "waitlisted" || going >= int(capacity.Int64) {
+			result.Response = "waitlisted"
+		}
+	}
+
+	if result.Response == current {
+		return result, nil
+	} else if exists {
+		// Update existing response, waitlist time is set only when joining the waitlist
+		_, err = tx.Exec(`
+			UPDATE event_users SET response = ?,
+				waitlisted_at = CASE WHEN ? = 'waitlisted' THEN ? ELSE NULL END
+			WHERE event_id = ? AND occurrence = ? AND user_id = ?`,
+			result.Response, result.Response, time.Now(), eventID, occurrence, userID)
 	} else {
 		// Insert new response
-		stmt, err := repo.DB.Prepare("INSERT INTO event_users (event_id, user_id, response) VALUES (?, ?, ?)")
+		var waitlistedAt *time.Time
+		if result.Response == "waitlisted" {
+			now := time.Now()
+			waitlistedAt = &now
+		}
+		_, err = tx.Exec("INSERT INTO event_users (event_id, occurrence, user_id, response, waitlisted_at) VALUES (?, ?, ?, ?, ?)",
+			eventID, occurrence, userID, result.Response, waitlistedAt)
+	}
+	if err != nil {
+		return result, err
+	}
+
+	// spot left by going user goes to oldest waitlisted users
+	if current == "going" && capacity.Int64 > 0 {
+		if result.Promoted, err = promoteWaitlisted(tx, eventID, occurrence, int(capacity.Int64)); err != nil {
+			return result, err
+		}
+	}
+	return result, tx.Commit()
+}
+
+func countGoing(tx *sql.Tx, eventID, occurrence string) (int, error) {
+	var going int
+	err := tx.QueryRow("SELECT COUNT(*) FROM event_users WHERE event_id = ? AND occurrence = ? AND response = 'going'", eventID, occurrence).Scan(&going)
+	return going, err
+}
+
+// moves oldest waitlisted users to going while there are free spots, returns their ids
+func promoteWaitlisted(tx *sql.Tx, eventID, occurrence string, capacity int) ([]string, error) {
+	var promoted []string
+	going, err := countGoing(tx, eventID, occurrence)
+	if err != nil {
+		return promoted, err
+	}
+	for ; going < capacity; going++ {
+		var userID string
+		err := tx.QueryRow(`
+			SELECT user_id FROM event_users
+			WHERE event_id = ? AND occurrence = ? AND response = 'waitlisted'
+			ORDER BY waitlisted_at, rowid
+			LIMIT 1`, eventID, occurrence).Scan(&userID)
+		if err == sql.ErrNoRows {
+			break
+		} else if err != nil {
+			return promoted, err
+		}
+		_, err = tx.Exec("UPDATE event_users SET response = 'going', waitlisted_at = NULL WHERE event_id = ? AND occurrence = ? AND user_id = ?", eventID, occurrence, userID)
 		if err != nil {
-			return err
+			return promoted, err
 		}
-		_, err = stmt.Exec(eventID, userID, response)
-		return err
+		promoted = append(promoted, userID)
 	}
+	return promoted, nil
 }
 
-func (repo *EventRepository) GetEventWithResponses(eventID, currentUserID string) (*models.EventWithResponses, error) {
+func (repo *EventRepository) GetEventWithResponses(eventID, occurrence, currentUserID string) (*models.EventWithResponses, error) {
 	// Get event details
 	event, err := repo.GetData(eventID)
 	if err != nil {
 		return nil, err
 	}
+	if occurrence != "" {
+		start, err := time.Parse(time.RFC3339, occurrence)
+		if err != nil {
+			return nil, err
+		}
+		event = atOccurrence(event, start)
+	}
 	
-	// Get author info
-	authorRow := repo.DB.QueryRow("SELECT first_name, last_name FROM users WHERE user_id = ?", event.AuthorID)
-	var firstName, lastName string
-	if err := authorRow.Scan(&firstName, &lastName); err == nil {
-		event.Author.FirstName = firstName
-		event.Author.LastName = lastName
+	responses, err := repo.getResponses(eventID)
+	if err != nil {
+		responses = map[string][]models.EventResponse{} // Return event even if no responses
+	}
+	eventWithResponses := withResponses(event, responses[event.Occurrence], currentUserID)
+	return &eventWithResponses, nil
+}
+
+func (repo *EventRepository) GetGroupEventsWithResponses(groupID, currentUserID string, from, to time.Time) ([]models.EventWithResponses, error) {
+	events, err := repo.GetAll(groupID)
+	if err != nil {
+		return nil, err
 	}
 	
-	eventWithResponses := &models.EventWithResponses{
-		Event: event,
-		Responses: []models.EventResponse{},
+	var eventsWithResponses []models.EventWithResponses
+	
+	for _, event := range events {
+		responses, err := repo.getResponses(event.ID)
+		if err != nil {
+			continue // Skip this event if there's an error
+		}
+		if event.Recurrence == nil {
+			if (!from.IsZero() && event.DateTime.Before(from)) || (!to.IsZero() && !event.DateTime.Before(to)) {
+				continue
+			}
+			eventsWithResponses = append(eventsWithResponses, withResponses(event, responses[""], currentUserID))
+			continue
+		}
+		// every occurrence in window is listed on its own with its own responses
+		for _, start := range event.Recurrence.Occurrences(event.DateTime, from, to) {
+			occurrence := atOccurrence(event, start)
+			eventsWithResponses = append(eventsWithResponses, withResponses(occurrence, responses[occurrence.Occurrence], currentUserID))
+		}
 	}
+	// latest first, same as GetAll
+	sort.SliceStable(eventsWithResponses, func(i, j int) bool {
+		return eventsWithResponses[i].DateTime.After(eventsWithResponses[j].DateTime)
+	})
 	
-	// Get all responses
+	return eventsWithResponses, nil
+}
+
+// all responses to event grouped by occurrence ('' for single events)
+func (repo *EventRepository) getResponses(eventID string) (map[string][]models.EventResponse, error) {
+	var responses = map[string][]models.EventResponse{}
 	rows, err := repo.DB.Query(`
-		SELECT eu.user_id, eu.response, u.first_name, u.last_name 
+		SELECT eu.occurrence, eu.user_id, eu.response, u.first_name, u.last_name 
 		FROM event_users eu 
 		JOIN users u ON eu.user_id = u.user_id 
-		WHERE eu.event_id = ?`, eventID)
+		WHERE eu.event_id = ?
+		ORDER BY eu.waitlisted_at, eu.rowid`, eventID)
 	if err != nil {
-		return eventWithResponses, nil // Return event even if no responses
+		return responses, err
 	}
 	defer rows.Close()
 	
 	for rows.Next() {
 		var response models.EventResponse
-		var firstName, lastName string
-		rows.Scan(&response.UserID, &response.Response, &firstName, &lastName)
+		var occurrence, firstName, lastName string
+		rows.Scan(&occurrence, &response.UserID, &response.Response, &firstName, &lastName)
 		response.EventID = eventID
 		response.UserName = firstName + " " + lastName
-		
+		responses[occurrence] = append(responses[occurrence], response)
+	}
+	return responses, nil
+}
+
+// wraps event with its responses and counts them
+func withResponses(event models.Event, responses []models.EventResponse, currentUserID string) models.EventWithResponses {
+	eventWithResponses := models.EventWithResponses{
+		Event: event,
+		Responses: []models.EventResponse{},
+	}
+	for _, response := range responses {
 		eventWithResponses.Responses = append(eventWithResponses.Responses, response)
 		
 		// Set current user's response
@@ -241,36 +482,109 @@ func (repo *EventRepository) GetEventWithResponses(eventID, currentUserID string
 			eventWithResponses.NotGoingCount++
 		case "maybe":
 			eventWithResponses.MaybeCount++
+		case "waitlisted":
+			// responses are in waitlist order
+			eventWithResponses.WaitlistCount++
+			if response.UserID == currentUserID {
+				eventWithResponses.WaitlistPosition = eventWithResponses.WaitlistCount
+			}
 		}
 	}
-	
-	return eventWithResponses, nil
+	return eventWithResponses
 }
 
-func (repo *EventRepository) GetGroupEventsWithResponses(groupID, currentUserID string) ([]models.EventWithResponses, error) {
-	events, err := repo.GetAll(groupID)
+func (repo *EventRepository) GetAttendees(eventID, occurrence string) ([]string, error) {
+	var userIDs []string
+	rows, err := repo.DB.Query("SELECT user_id FROM event_users WHERE event_id = ? AND occurrence = ? AND response IN ('going', 'maybe')", eventID, occurrence)
 	if err != nil {
-		return nil, err
+		return userIDs, err
 	}
-	
-	var eventsWithResponses []models.EventWithResponses
-	
-	for _, event := range events {
-		eventWithResp, err := repo.GetEventWithResponses(event.ID, currentUserID)
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return userIDs, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, rows.Err()
+}
+
+func (repo *EventRepository) Update(event models.Event) error {
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var oldDate time.Time
+	if err = tx.QueryRow("SELECT date FROM event WHERE event_id = ?", event.ID).Scan(&oldDate); err != nil {
+		return err
+	}
+	var rrule, exdates sql.NullString
+	if event.Recurrence != nil {
+		rrule = sql.NullString{String: event.Recurrence.RRule(), Valid: true}
+		exdates = sql.NullString{String: strings.Join(event.Recurrence.Exceptions, ","), Valid: len(event.Recurrence.Exceptions) > 0}
+	}
+	_, err = tx.Exec("UPDATE event SET title = ?, content = ?, date = ?, rrule = ?, exdates = ?, edited_at = CURRENT_TIMESTAMP WHERE event_id = ?",
+		event.Title, event.Content, event.DateTime, rrule, exdates, event.ID)
+	if err != nil {
+		return err
+	}
+
+	// responses to occurrences follow moved series
+	if shift := event.DateTime.Sub(oldDate); event.Recurrence != nil && shift != 0 {
+		rows, err := tx.Query("SELECT DISTINCT occurrence FROM event_users WHERE event_id = ? AND occurrence != ''", event.ID)
 		if err != nil {
-			continue // Skip this event if there's an error
+			return err
+		}
+		var occurrences []string
+		for rows.Next() {
+			var occurrence string
+			if err := rows.Scan(&occurrence); err != nil {
+				rows.Close()
+				return err
+			}
+			occurrences = append(occurrences, occurrence)
+		}
+		rows.Close()
+		// latest first when moving later (earliest first otherwise), so moved keys never meet unmoved ones
+		sort.Slice(occurrences, func(i, j int) bool { return (occurrences[i] > occurrences[j]) == (shift > 0) })
+		for _, occurrence := range occurrences {
+			start, err := time.Parse(time.RFC3339, occurrence)
+			if err != nil {
+				continue
+			}
+			_, err = tx.Exec("UPDATE event_users SET occurrence = ? WHERE event_id = ? AND occurrence = ?",
+				models.OccurrenceKey(start.Add(shift)), event.ID, occurrence)
+			if err != nil {
+				return err
+			}
 		}
-		eventsWithResponses = append(eventsWithResponses, *eventWithResp)
 	}
-	
-	return eventsWithResponses, nil
+	return tx.Commit()
+}
+
+func (repo *EventRepository) Cancel(eventID string) error {
+	_, err := repo.DB.Exec("UPDATE event SET cancelled_at = CURRENT_TIMESTAMP WHERE event_id = ? AND cancelled_at IS NULL", eventID)
+	return err
 }
 
-func (repo *EventRepository) IsParticipating(eventID, userID string) (bool, error) {
-	row := repo.DB.QueryRow("SELECT COUNT() FROM event_users WHERE event_id = ? AND user_id = ?", eventID, userID)
-	var participate int
-	if err := row.Scan(&participate); err != nil {
-		return false, err
+func (repo *EventRepository) GetRespondents(eventID string) ([]string, error) {
+	var userIDs []string
+	rows, err := repo.DB.Query("SELECT DISTINCT user_id FROM event_users WHERE event_id = ?", eventID)
+	if err != nil {
+		return userIDs, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return userIDs, err
+		}
+		userIDs = append(userIDs, userID)
 	}
-	return participate > 0, nil
+	return userIDs, rows.Err()
 }
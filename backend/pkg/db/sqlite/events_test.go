@@ -0,0 +1,56 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"social-network/pkg/models"
+)
+
+func TestEventWaitlist(t *testing.T) {
+	repo := &EventRepository{DB: newTestDB(t)}
+	event := models.Event{ID: "event", GroupID: "group", AuthorID: "alice", Title: "event", DateTime: time.Now().Add(time.Hour), Capacity: 2}
+	if err := repo.Save(event); err != nil {
+		t.Fatal(err)
+	}
+	steps := []struct {
+		user, response string
+		want           string
+		promoted       []string
+	}{
+		{"a", "going", "going", nil},
+		{"b", "going", "going", nil},
+		{"c", "going", "waitlisted", nil},
+		{"d", "going", "waitlisted", nil},
+		{"c", "going", "waitlisted", nil}, // asking again keeps place
+		{"e", "maybe", "maybe", nil},
+		{"a", "not_going", "not_going", []string{"c"}},
+		{"d", "maybe", "maybe", nil}, // leaves waitlist
+		{"f", "going", "waitlisted", nil},
+		{"b", "maybe", "maybe", []string{"f"}},
+		{"c", "not_going", "not_going", nil}, // nobody waiting
+		{"a", "going", "going", nil},
+	}
+	for i, step := range steps {
+		result, err := repo.UpdateResponse("event", "", step.user, step.response)
+		if err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+		if result.Response != step.want || len(result.Promoted) != len(step.promoted) {
+			t.Fatalf("step %d: %s %s gave %+v, want %s promoting %v", i, step.user, step.response, result, step.want, step.promoted)
+		}
+		for j := range step.promoted {
+			if result.Promoted[j] != step.promoted[j] {
+				t.Fatalf("step %d: promoted %v, want %v", i, result.Promoted, step.promoted)
+			}
+		}
+	}
+	attendees, err := repo.GetAttendees("event", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// f and a going, b, d and e maybe
+	if len(attendees) != 5 {
+		t.Errorf("attendees = %v", attendees)
+	}
+}
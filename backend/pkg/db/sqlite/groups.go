@@ -3,6 +3,7 @@ package db
 import (
 	"database/sql"
 	"social-network/pkg/models"
+	"time"
 )
 
 type GroupRepository struct {
@@ -95,7 +96,7 @@ func (repo *GroupRepository) New(group models.Group) error {
 	}
 
 	// Add the admin as a member to the group_users table
-	memberStmt, err := repo.DB.Prepare("INSERT INTO group_users (group_id, user_id) values (?,?)")
+	memberStmt, err := repo.DB.Prepare("INSERT INTO group_users (group_id, user_id, role) values (?,?,'owner')")
 	if err != nil {
 		return err
 	}
@@ -106,6 +107,90 @@ func (repo *GroupRepository) New(group models.Group) error {
 	return nil
 }
 
+func (repo *GroupRepository) Update(group models.Group) error {
+	_, err := repo.DB.Exec("UPDATE groups SET name = ?, description = ?, privacy = ?, image = ? WHERE group_id = ?",
+		group.Name, group.Description, group.Privacy, group.Image, group.ID)
+	return err
+}
+
+// posts of group, used in Delete
+const groupPosts = "(SELECT post_id FROM posts WHERE group_id = ?1)"
+
+func (repo *GroupRepository) Delete(groupId string) ([]string, error) {
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	images, err := deleteGroup(tx, groupId)
+	if err != nil {
+		return images, err
+	}
+	return images, tx.Commit()
+}
+
+// removes group with everything in it inside tx, returns images to delete from disk
+func deleteGroup(tx *sql.Tx, groupId string) ([]string, error) {
+	var images []string
+	rows, err := tx.Query(`
+		SELECT image FROM groups WHERE group_id = ?1 AND image IS NOT NULL
+		UNION ALL SELECT image FROM posts WHERE group_id = ?1 AND image IS NOT NULL
+		UNION ALL SELECT image FROM comments WHERE post_id IN `+groupPosts+` AND image IS NOT NULL`, groupId)
+	if err != nil {
+		return images, err
+	}
+	for rows.Next() {
+		var image string
+		if err := rows.Scan(&image); err != nil {
+			rows.Close()
+			return images, err
+		}
+		if image != "" {
+			images = append(images, image)
+		}
+	}
+	rows.Close()
+
+	statements := []string{
+		// posts with comments and reactions on them
+		"DELETE FROM notifications WHERE type IN ('REACTION', 'COMMENT_REPLY', 'GROUP_ANNOUNCEMENT') AND content IN " + groupPosts,
+		"DELETE FROM notifications WHERE type IN ('REACTION', 'COMMENT_REPLY') AND content IN (SELECT comment_id FROM comments WHERE post_id IN " + groupPosts + ")",
+		"DELETE FROM reactions WHERE target_id IN (SELECT comment_id FROM comments WHERE post_id IN " + groupPosts + ")",
+		"DELETE FROM comments WHERE post_id IN " + groupPosts,
+		"DELETE FROM reactions WHERE target_id IN " + groupPosts,
+		"DELETE FROM almost_private WHERE post_id IN " + groupPosts,
+		"DELETE FROM private_post_access WHERE post_id IN " + groupPosts,
+		"DELETE FROM posts WHERE group_id = ?1",
+		// events, notification content is event id or event id with changed fields
+		`DELETE FROM notifications WHERE type LIKE 'EVENT%' AND EXISTS (SELECT 1 FROM event WHERE group_id = ?1
+			AND (notifications.content = event.event_id OR notifications.content LIKE event.event_id || ':%'))`,
+		"DELETE FROM event_users WHERE event_id IN (SELECT event_id FROM event WHERE group_id = ?1)",
+		"DELETE FROM event WHERE group_id = ?1",
+		// chat, mention notification content is message id
+		"DELETE FROM notifications WHERE type = 'MENTION' AND content IN (SELECT message_id FROM messages WHERE type = 'GROUP' AND receiver_id = ?1)",
+		"DELETE FROM group_messages WHERE message_id IN (SELECT message_id FROM messages WHERE type = 'GROUP' AND receiver_id = ?1)",
+		"DELETE FROM message_deletions WHERE message_id IN (SELECT message_id FROM messages WHERE type = 'GROUP' AND receiver_id = ?1)",
+		"DELETE FROM messages WHERE type = 'GROUP' AND receiver_id = ?1",
+		"DELETE FROM group_chat_mutes WHERE group_id = ?1",
+		// requests, invites and membership
+		"DELETE FROM notifications WHERE user_id = ?1 OR (type = 'GROUP_INVITE' AND content = ?1)",
+		"DELETE FROM group_users WHERE group_id = ?1",
+		"DELETE FROM group_bans WHERE group_id = ?1",
+		"DELETE FROM group_moderation_log WHERE group_id = ?1",
+		"DELETE FROM group_questions WHERE group_id = ?1",
+		"DELETE FROM group_join_answers WHERE group_id = ?1",
+		"DELETE FROM group_invite_link_uses WHERE link_id IN (SELECT link_id FROM group_invite_links WHERE group_id = ?1)",
+		"DELETE FROM group_invite_links WHERE group_id = ?1",
+		"DELETE FROM groups WHERE group_id = ?1",
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement, groupId); err != nil {
+			return images, err
+		}
+	}
+	return images, nil
+}
+
 func (repo *GroupRepository) GetData(groupId string) (models.Group, error) {
 	row := repo.DB.QueryRow(`
 		SELECT 
@@ -139,11 +224,13 @@ func (repo *GroupRepository) GetMembers(groupId string) ([]models.User, error) {
 			users.last_name, 
 			users.nickname, 
 			users.image,
-			CASE WHEN users.user_id = (SELECT administrator FROM groups WHERE group_id = ?) THEN 1 ELSE 0 END as is_admin
+			CASE WHEN users.user_id = (SELECT administrator FROM groups WHERE group_id = ?) THEN 1 ELSE 0 END as is_admin,
+			COALESCE((SELECT role FROM group_users WHERE group_id = ? AND user_id = users.user_id), 'owner'),
+			COALESCE((SELECT muted_until FROM group_users WHERE group_id = ? AND user_id = users.user_id), '')
 		FROM users 
 		WHERE (users.user_id = (SELECT administrator FROM groups WHERE group_id = ?)) 
 		   OR (users.user_id IN (SELECT user_id FROM group_users WHERE group_id = ?))
-	`, groupId, groupId, groupId)
+	`, groupId, groupId, groupId, groupId, groupId)
 	if err != nil {
 		return members, err
 	}
@@ -151,10 +238,14 @@ func (repo *GroupRepository) GetMembers(groupId string) ([]models.User, error) {
 		var member models.User
 		var isAdmin int
 		var nickname sql.NullString
-		err := rows.Scan(&member.ID, &member.FirstName, &member.LastName, &nickname, &member.ImagePath, &isAdmin)
+		var mutedUntil string
+		err := rows.Scan(&member.ID, &member.FirstName, &member.LastName, &nickname, &member.ImagePath, &isAdmin, &member.GroupRole, &mutedUntil)
 		if err != nil {
 			continue
 		}
+		if until, err := time.Parse(time.RFC3339, mutedUntil); err == nil && until.After(time.Now()) {
+			member.MutedUntil = mutedUntil
+		}
 		// Handle nullable nickname
 		if nickname.Valid {
 			member.Nickname = nickname.String
@@ -224,3 +315,280 @@ func (repo *GroupRepository) RemoveMember(userId, groupId string) error {
 	}
 	return nil
 }
+
+func (repo *GroupRepository) GetRole(groupId, userId string) (string, error) {
+	var role string
+	err := repo.DB.QueryRow("SELECT role FROM group_users WHERE group_id = ? AND user_id = ?", groupId, userId).Scan(&role)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return role, err
+}
+
+func (repo *GroupRepository) IsModerator(groupId, userId string) (bool, error) {
+	role, err := repo.GetRole(groupId, userId)
+	return role == models.RoleOwner || role == models.RoleModerator, err
+}
+
+func (repo *GroupRepository) GetModerators(groupId string) ([]string, error) {
+	var userIds []string
+	rows, err := repo.DB.Query("SELECT user_id FROM group_users WHERE group_id = ? AND role IN ('owner', 'moderator')", groupId)
+	if err != nil {
+		return userIds, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var userId string
+		if err := rows.Scan(&userId); err != nil {
+			return userIds, err
+		}
+		userIds = append(userIds, userId)
+	}
+	return userIds, rows.Err()
+}
+
+func (repo *GroupRepository) SetRole(groupId, userId, role string) error {
+	_, err := repo.DB.Exec("UPDATE group_users SET role = ? WHERE group_id = ? AND user_id = ? AND role != 'owner'", role, groupId, userId)
+	return err
+}
+
+// owner is changed in both groups and group_users in one transaction
+func (repo *GroupRepository) TransferOwnership(groupId, newOwnerId string) error {
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err = transferOwnership(tx, groupId, newOwnerId); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func transferOwnership(tx *sql.Tx, groupId, newOwnerId string) error {
+	statements := []string{
+		"UPDATE group_users SET role = 'moderator' WHERE group_id = ? AND role = 'owner' AND user_id != ?",
+		"UPDATE group_users SET role = 'owner' WHERE group_id = ? AND user_id = ?",
+		"UPDATE groups SET administrator = ? WHERE group_id = ?",
+	}
+	args := [][]interface{}{{groupId, newOwnerId}, {groupId, newOwnerId}, {newOwnerId, groupId}}
+	for i, statement := range statements {
+		if _, err := tx.Exec(statement, args[i]...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// oldest moderator, otherwise oldest member of group
+const groupSuccessor = `
+		SELECT user_id FROM group_users
+		WHERE group_id = ? AND role != 'owner'
+		ORDER BY role = 'moderator' DESC, rowid ASC
+		LIMIT 1`
+
+func (repo *GroupRepository) GetSuccessor(groupId string) (string, error) {
+	var userId string
+	err := repo.DB.QueryRow(groupSuccessor, groupId).Scan(&userId)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return userId, err
+}
+
+func (repo *GroupRepository) GetOwnedGroups(userId string) ([]string, error) {
+	var groupIds []string
+	rows, err := repo.DB.Query("SELECT group_id FROM groups WHERE administrator = ?", userId)
+	if err != nil {
+		return groupIds, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var groupId string
+		if err := rows.Scan(&groupId); err != nil {
+			return groupIds, err
+		}
+		groupIds = append(groupIds, groupId)
+	}
+	return groupIds, rows.Err()
+}
+
+// banned user loses membership, pending request to join and invites to group
+func (repo *GroupRepository) Ban(groupId, userId, bannedBy string) error {
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	statements := []string{
+		"INSERT INTO group_bans (group_id, user_id, banned_by) VALUES (?1, ?2, ?3) ON CONFLICT DO NOTHING",
+		"DELETE FROM group_users WHERE group_id = ?1 AND user_id = ?2 AND role != 'owner'",
+		`DELETE FROM notifications WHERE (type = 'GROUP_REQUEST' AND user_id = ?1 AND content = ?2)
+			OR (type = 'GROUP_INVITE' AND user_id = ?2 AND content = ?1)`,
+		"DELETE FROM group_join_answers WHERE group_id = ?1 AND user_id = ?2",
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement, groupId, userId, bannedBy); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (repo *GroupRepository) Unban(groupId, userId string) error {
+	_, err := repo.DB.Exec("DELETE FROM group_bans WHERE group_id = ? AND user_id = ?", groupId, userId)
+	return err
+}
+
+func (repo *GroupRepository) IsBanned(groupId, userId string) (bool, error) {
+	var count int
+	err := repo.DB.QueryRow("SELECT COUNT(*) FROM group_bans WHERE group_id = ? AND user_id = ?", groupId, userId).Scan(&count)
+	return count > 0, err
+}
+
+func (repo *GroupRepository) Mute(groupId, userId string, until time.Time) error {
+	_, err := repo.DB.Exec("UPDATE group_users SET muted_until = ? WHERE group_id = ? AND user_id = ?", until.UTC().Format(time.RFC3339), groupId, userId)
+	return err
+}
+
+func (repo *GroupRepository) Unmute(groupId, userId string) error {
+	_, err := repo.DB.Exec("UPDATE group_users SET muted_until = NULL WHERE group_id = ? AND user_id = ?", groupId, userId)
+	return err
+}
+
+func (repo *GroupRepository) GetMutedUntil(groupId, userId string) (time.Time, error) {
+	var mutedUntil sql.NullString
+	err := repo.DB.QueryRow("SELECT muted_until FROM group_users WHERE group_id = ? AND user_id = ?", groupId, userId).Scan(&mutedUntil)
+	if err == sql.ErrNoRows || !mutedUntil.Valid {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	until, err := time.Parse(time.RFC3339, mutedUntil.String)
+	if err != nil || !until.After(time.Now()) {
+		return time.Time{}, err
+	}
+	return until, nil
+}
+
+func (repo *GroupRepository) SaveModerationAction(action models.ModerationAction) error {
+	var expiresAt interface{}
+	if action.ExpiresAt != "" {
+		expiresAt = action.ExpiresAt
+	}
+	_, err := repo.DB.Exec(`INSERT INTO group_moderation_log (action_id, group_id, actor_id, target_id, action, reason, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`, action.ID, action.GroupID, action.ActorID, action.TargetID, action.Action, action.Reason, expiresAt)
+	return err
+}
+
+func (repo *GroupRepository) GetModerationLog(groupId string, page models.Page) ([]models.ModerationAction, *models.Cursor, error) {
+	var actions []models.ModerationAction
+	var keys []models.Cursor
+	rows, err := repo.DB.Query(`
+		SELECT action_id, actor_id, target_id, action, reason, COALESCE(expires_at, ''), CAST(created_at AS TEXT)
+		FROM group_moderation_log
+		WHERE group_id = ?
+		AND (? = '' OR created_at < ? OR (created_at = ? AND action_id < ?))
+		ORDER BY created_at DESC, action_id DESC
+		LIMIT ?`, append([]interface{}{groupId}, pageArgs(page)...)...)
+	if err != nil {
+		return actions, nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		action := models.ModerationAction{GroupID: groupId}
+		if err := rows.Scan(&action.ID, &action.ActorID, &action.TargetID, &action.Action, &action.Reason, &action.ExpiresAt, &action.CreatedAt); err != nil {
+			return actions, nil, err
+		}
+		actions = append(actions, action)
+		keys = append(keys, models.Cursor{CreatedAt: action.CreatedAt, ID: action.ID})
+	}
+	next := nextCursor(page, keys)
+	if next != nil {
+		actions = actions[:page.Limit]
+	}
+	return actions, next, rows.Err()
+}
+
+func (repo *GroupRepository) GetJoinRules(groupId string) (models.JoinRules, error) {
+	rules := models.JoinRules{Questions: []string{}}
+	if err := repo.DB.QueryRow("SELECT auto_approve FROM groups WHERE group_id = ?", groupId).Scan(&rules.AutoApprove); err != nil {
+		return rules, err
+	}
+	rows, err := repo.DB.Query("SELECT question FROM group_questions WHERE group_id = ? ORDER BY position", groupId)
+	if err != nil {
+		return rules, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var question string
+		if err := rows.Scan(&question); err != nil {
+			return rules, err
+		}
+		rules.Questions = append(rules.Questions, question)
+	}
+	return rules, rows.Err()
+}
+
+// questions are replaced as a whole
+func (repo *GroupRepository) SetJoinRules(groupId string, rules models.JoinRules) error {
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err = tx.Exec("UPDATE groups SET auto_approve = ? WHERE group_id = ?", rules.AutoApprove, groupId); err != nil {
+		return err
+	}
+	if _, err = tx.Exec("DELETE FROM group_questions WHERE group_id = ?", groupId); err != nil {
+		return err
+	}
+	for i, question := range rules.Questions {
+		if _, err = tx.Exec("INSERT INTO group_questions (group_id, position, question) VALUES (?, ?, ?)", groupId, i, question); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (repo *GroupRepository) SaveJoinAnswers(groupId, userId string, answers []models.JoinAnswer) error {
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err = tx.Exec("DELETE FROM group_join_answers WHERE group_id = ? AND user_id = ?", groupId, userId); err != nil {
+		return err
+	}
+	for i, answer := range answers {
+		if _, err = tx.Exec("INSERT INTO group_join_answers (group_id, user_id, position, question, answer) VALUES (?, ?, ?, ?, ?)",
+			groupId, userId, i, answer.Question, answer.Answer); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (repo *GroupRepository) GetJoinAnswers(groupId string) (map[string][]models.JoinAnswer, error) {
+	answers := make(map[string][]models.JoinAnswer)
+	rows, err := repo.DB.Query("SELECT user_id, question, answer FROM group_join_answers WHERE group_id = ? ORDER BY user_id, position", groupId)
+	if err != nil {
+		return answers, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var userId string
+		var answer models.JoinAnswer
+		if err := rows.Scan(&userId, &answer.Question, &answer.Answer); err != nil {
+			return answers, err
+		}
+		answers[userId] = append(answers[userId], answer)
+	}
+	return answers, rows.Err()
+}
+
+func (repo *GroupRepository) DeleteJoinAnswers(groupId, userId string) error {
+	_, err := repo.DB.Exec("DELETE FROM group_join_answers WHERE group_id = ? AND user_id = ?", groupId, userId)
+	return err
+}
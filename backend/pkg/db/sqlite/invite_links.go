@@ -0,0 +1,94 @@
+package db
+
+import (
+	"database/sql"
+	"social-network/pkg/models"
+	"time"
+)
+
+type InviteLinkRepository struct {
+	DB *sql.DB
+}
+
+// columns read by scanInviteLink
+const inviteLinkColumns = `link_id, group_id, token, created_by, COALESCE(expires_at, ''), COALESCE(max_uses, 0),
+	revoked_at IS NOT NULL, CAST(created_at AS TEXT),
+	(SELECT COUNT(*) FROM group_invite_link_uses WHERE group_invite_link_uses.link_id = group_invite_links.link_id)`
+
+func (repo *InviteLinkRepository) Save(link models.InviteLink) error {
+	var expiresAt, maxUses interface{}
+	if link.ExpiresAt != nil {
+		expiresAt = link.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+	if link.MaxUses > 0 {
+		maxUses = link.MaxUses
+	}
+	_, err := repo.DB.Exec(`INSERT INTO group_invite_links (link_id, group_id, token, created_by, expires_at, max_uses)
+		VALUES (?, ?, ?, ?, ?, ?)`, link.ID, link.GroupID, link.Token, link.CreatedBy, expiresAt, maxUses)
+	return err
+}
+
+func (repo *InviteLinkRepository) GetByToken(token string) (models.InviteLink, error) {
+	row := repo.DB.QueryRow("SELECT "+inviteLinkColumns+" FROM group_invite_links WHERE token = ?", token)
+	return scanInviteLink(row)
+}
+
+func (repo *InviteLinkRepository) GetAll(groupId string) ([]models.InviteLink, error) {
+	var links []models.InviteLink
+	rows, err := repo.DB.Query("SELECT "+inviteLinkColumns+` FROM group_invite_links
+		WHERE group_id = ? AND revoked_at IS NULL
+		ORDER BY created_at DESC, link_id DESC`, groupId)
+	if err != nil {
+		return links, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		link, err := scanInviteLink(rows)
+		if err != nil {
+			return links, err
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
+
+func (repo *InviteLinkRepository) Revoke(groupId, linkId string) (bool, error) {
+	result, err := repo.DB.Exec("UPDATE group_invite_links SET revoked_at = CURRENT_TIMESTAMP WHERE group_id = ? AND link_id = ? AND revoked_at IS NULL", groupId, linkId)
+	if err != nil {
+		return false, err
+	}
+	revoked, err := result.RowsAffected()
+	return revoked > 0, err
+}
+
+// limit is checked in the same statement, so two users can't take the last use
+func (repo *InviteLinkRepository) Use(linkId, userId string) (bool, error) {
+	result, err := repo.DB.Exec(`
+		INSERT INTO group_invite_link_uses (link_id, user_id)
+		SELECT link_id, ?2 FROM group_invite_links
+		WHERE link_id = ?1 AND revoked_at IS NULL
+		AND (max_uses IS NULL OR (SELECT COUNT(*) FROM group_invite_link_uses WHERE link_id = ?1) < max_uses)
+		ON CONFLICT DO NOTHING`, linkId, userId)
+	if err != nil {
+		return false, err
+	}
+	used, err := result.RowsAffected()
+	return used > 0, err
+}
+
+// works for both sql.Row and sql.Rows
+func scanInviteLink(row interface{ Scan(...interface{}) error }) (models.InviteLink, error) {
+	var link models.InviteLink
+	var expiresAt string
+	if err := row.Scan(&link.ID, &link.GroupID, &link.Token, &link.CreatedBy, &expiresAt, &link.MaxUses, &link.Revoked, &link.CreatedAt, &link.Uses); err != nil {
+		return link, err
+	}
+	if expiresAt != "" {
+		at, err := time.Parse(time.RFC3339, expiresAt)
+		if err != nil {
+			return link, err
+		}
+		link.ExpiresAt = &at
+	}
+	return link, nil
+}
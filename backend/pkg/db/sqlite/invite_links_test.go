@@ -0,0 +1,75 @@
+package db
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"social-network/pkg/models"
+)
+
+func TestInviteLinkUseLimit(t *testing.T) {
+	repo := &InviteLinkRepository{DB: newTestDB(t)}
+	for _, link := range []models.InviteLink{
+		{ID: "limited", GroupID: "group", Token: "t1", CreatedBy: "alice", MaxUses: 2},
+		{ID: "revoked", GroupID: "group", Token: "t2", CreatedBy: "alice"},
+	} {
+		if err := repo.Save(link); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if revoked, err := repo.Revoke("group", "revoked"); err != nil || !revoked {
+		t.Fatalf("revoke = %v, %v", revoked, err)
+	}
+	tests := []struct {
+		link, user string
+		want       bool
+	}{
+		{"limited", "a", true},
+		{"limited", "a", false}, // same user again
+		{"limited", "b", true},
+		{"limited", "c", false}, // used up
+		{"revoked", "a", false},
+		{"missing", "a", false},
+	}
+	for _, test := range tests {
+		if got, err := repo.Use(test.link, test.user); err != nil || got != test.want {
+			t.Errorf("Use(%s, %s) = %v, %v, want %v", test.link, test.user, got, err, test.want)
+		}
+	}
+	link, err := repo.GetByToken("t1")
+	if err != nil || link.Uses != 2 {
+		t.Fatalf("uses = %d, %v", link.Uses, err)
+	}
+}
+
+// users joining at the same time can't go over the limit
+func TestInviteLinkConcurrentUse(t *testing.T) {
+	repo := &InviteLinkRepository{DB: newTestDB(t)}
+	if err := repo.Save(models.InviteLink{ID: "link", GroupID: "group", Token: "t", CreatedBy: "alice", MaxUses: 3}); err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	used := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(user string) {
+			defer wg.Done()
+			ok, err := repo.Use("link", user)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if ok {
+				mu.Lock()
+				used++
+				mu.Unlock()
+			}
+		}(fmt.Sprintf("user%d", i))
+	}
+	wg.Wait()
+	if used != 3 {
+		t.Errorf("%d users joined, want 3", used)
+	}
+}
@@ -0,0 +1,80 @@
+package db
+
+import (
+	"database/sql"
+	"social-network/pkg/models"
+	"time"
+)
+
+type JobRepository struct {
+	DB *sql.DB
+}
+
+func (repo *JobRepository) Schedule(job models.Job) error {
+	_, err := repo.DB.Exec(`
+		INSERT INTO scheduled_jobs (job_id, type, job_key, payload, run_at)
+		VALUES (?,?,?,?,?)
+		ON CONFLICT(type, job_key) DO NOTHING`,
+		job.ID, job.Type, job.Key, job.Payload, job.RunAt.Unix())
+	return err
+}
+
+func (repo *JobRepository) GetDue(now time.Time, limit int) ([]models.Job, error) {
+	var jobs []models.Job
+	rows, err := repo.DB.Query(`
+		SELECT job_id, type, job_key, payload, run_at, attempts, last_error
+		FROM scheduled_jobs
+		WHERE done_at IS NULL AND run_at <= ?
+		ORDER BY run_at ASC
+		LIMIT ?`, now.Unix(), limit)
+	if err != nil {
+		return jobs, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var job models.Job
+		var runAt int64
+		if err := rows.Scan(&job.ID, &job.Type, &job.Key, &job.Payload, &runAt, &job.Attempts, &job.LastError); err != nil {
+			return jobs, err
+		}
+		job.RunAt = time.Unix(runAt, 0)
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+func (repo *JobRepository) Complete(jobID string, at time.Time) error {
+	_, err := repo.DB.Exec("UPDATE scheduled_jobs SET done_at = ? WHERE job_id = ?", at.Unix(), jobID)
+	return err
+}
+
+func (repo *JobRepository) Retry(jobID string, runAt time.Time, reason string) error {
+	_, err := repo.DB.Exec("UPDATE scheduled_jobs SET attempts = attempts + 1, run_at = ?, last_error = ? WHERE job_id = ?", runAt.Unix(), reason, jobID)
+	return err
+}
+
+func (repo *JobRepository) CancelPending(jobType, keyPrefix string) error {
+	_, err := repo.DB.Exec("DELETE FROM scheduled_jobs WHERE type = ? AND substr(job_key, 1, length(?)) = ? AND done_at IS NULL", jobType, keyPrefix, keyPrefix)
+	return err
+}
+
+// job only keeps the mark of recipient, notification is saved as NotifRepository saves it
+func (repo *JobRepository) NotifyOnce(jobID string, notif models.Notification) (bool, error) {
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec("INSERT OR IGNORE INTO scheduled_job_recipients (job_id, user_id) VALUES (?,?)", jobID, notif.TargetID)
+	if err != nil {
+		return false, err
+	}
+	if inserted, err := res.RowsAffected(); err != nil || inserted == 0 {
+		return false, err
+	}
+	if err := saveNotification(tx, notif); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
@@ -3,6 +3,8 @@ package db
 import (
 	"database/sql"
 	"social-network/pkg/models"
+	"strconv"
+	"time"
 )
 
 type MsgRepository struct {
@@ -10,70 +12,248 @@ type MsgRepository struct {
 }
 
 func (repo *MsgRepository) Save(msg models.ChatMessage) error {
-	stmt, err := repo.DB.Prepare("INSERT INTO messages (message_id, sender_id, receiver_id, type, content) values (?,?,?,?,?)")
+	stmt, err := repo.DB.Prepare("INSERT INTO messages (message_id, sender_id, receiver_id, type, content, reply_to) values (?,?,?,?,?,NULLIF(?,''))")
 	if err != nil {
 		return err
 	}
-	if _, err := stmt.Exec(msg.ID, msg.SenderId, msg.ReceiverId, msg.Type, msg.Content); err != nil {
+	if _, err := stmt.Exec(msg.ID, msg.SenderId, msg.ReceiverId, msg.Type, msg.Content, msg.ReplyToID); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (repo *MsgRepository) SaveGroupMsg(msg models.ChatMessage) error {
-	stmt, err := repo.DB.Prepare("INSERT INTO group_messages (message_id, receiver_id) values (?,?)")
+func (repo *MsgRepository) SaveGroupMsg(msg models.ChatMessage, mentioned bool) error {
+	stmt, err := repo.DB.Prepare("INSERT INTO group_messages (message_id, receiver_id, mentioned) values (?,?,?)")
 	if err != nil {
 		return err
 	}
-	if _, err := stmt.Exec(msg.ID, msg.ReceiverId); err != nil {
+	if _, err := stmt.Exec(msg.ID, msg.ReceiverId, mentioned); err != nil {
 		return err
 	}
 	return nil
 }
 
+func (repo *MsgRepository) GetData(messageId string) (models.ChatMessage, error) {
+	var msg models.ChatMessage
+	row := repo.DB.QueryRow("SELECT message_id, sender_id, receiver_id, type, content, IFNULL(reply_to, ''), edited_at IS NOT NULL, deleted_at IS NOT NULL FROM messages WHERE message_id = ?", messageId)
+	err := row.Scan(&msg.ID, &msg.SenderId, &msg.ReceiverId, &msg.Type, &msg.Content, &msg.ReplyToID, &msg.Edited, &msg.Deleted)
+	return msg, err
+}
+
+func (repo *MsgRepository) Edit(messageId, content string, window time.Duration) (bool, error) {
+	result, err := repo.DB.Exec(`
+		UPDATE messages SET content = ?, edited_at = CURRENT_TIMESTAMP
+		WHERE message_id = ? AND deleted_at IS NULL AND created_at >= datetime('now', ?)`,
+		content, messageId, "-"+strconv.Itoa(int(window.Seconds()))+" seconds")
+	if err != nil {
+		return false, err
+	}
+	edited, err := result.RowsAffected()
+	return edited > 0, err
+}
+
+// tombstone counts as read, so it doesn't bump unread counters
+func (repo *MsgRepository) DeleteForEveryone(messageId string) error {
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	statements := []string{
+		"UPDATE messages SET content = '', deleted_at = CURRENT_TIMESTAMP, is_read = 1 WHERE message_id = ?",
+		"UPDATE group_messages SET is_read = 1 WHERE message_id = ?",
+		"DELETE FROM notifications WHERE type = 'MENTION' AND content = ?",
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement, messageId); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// hidden message counts as read for user
+func (repo *MsgRepository) DeleteForUser(messageId, userId string) error {
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	statements := []string{
+		"INSERT OR IGNORE INTO message_deletions (message_id, user_id) VALUES (?1, ?2)",
+		"UPDATE messages SET is_read = 1 WHERE message_id = ?1 AND receiver_id = ?2",
+		"UPDATE group_messages SET is_read = 1 WHERE message_id = ?1 AND receiver_id = ?2",
+		"DELETE FROM notifications WHERE type = 'MENTION' AND content = ?1 AND user_id = ?2",
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement, messageId, userId); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// columns of chat history with sender and content of quoted message and receipts, read by scanMessagePage
+const messageColumns = `message_id, sender_id, receiver_id, type, content, IFNULL(reply_to, ''),
+	edited_at IS NOT NULL, deleted_at IS NOT NULL,
+	(SELECT sender_id FROM messages AS quoted WHERE quoted.message_id = messages.reply_to),
+	(SELECT content FROM messages AS quoted WHERE quoted.message_id = messages.reply_to),
+	(SELECT deleted_at IS NOT NULL FROM messages AS quoted WHERE quoted.message_id = messages.reply_to),
+	delivered_at, read_at, (SELECT COUNT(*) FROM group_messages WHERE message_id = messages.message_id AND read_at IS NOT NULL),
+	CAST(created_at AS TEXT)`
+
 // needs RECEIVER and SENDER as input
-func (repo *MsgRepository) GetAll(msgIn models.ChatMessage) ([]models.ChatMessage, error) {
-	var messages []models.ChatMessage
-	rows, err := repo.DB.Query("SELECT message_id,sender_id, receiver_id, type, content FROM messages WHERE (receiver_id = ? AND sender_id = ? )OR (receiver_id = ? AND sender_id = ? ) ORDER BY created_at ASC;", msgIn.ReceiverId, msgIn.SenderId, msgIn.SenderId, msgIn.ReceiverId)
+// page is taken from newest messages, returned in chronological order
+func (repo *MsgRepository) GetAll(msgIn models.ChatMessage, page models.Page) ([]models.ChatMessage, *models.Cursor, error) {
+	rows, err := repo.DB.Query(`
+		SELECT `+messageColumns+` FROM messages
+		WHERE ((receiver_id = ? AND sender_id = ? )OR (receiver_id = ? AND sender_id = ? ))
+		  AND message_id NOT IN (SELECT message_id FROM message_deletions WHERE user_id = ?)
+		  AND (? = '' OR created_at < ? OR (created_at = ? AND message_id < ?))
+		ORDER BY created_at DESC, message_id DESC
+		LIMIT ?;`, append([]interface{}{msgIn.ReceiverId, msgIn.SenderId, msgIn.SenderId, msgIn.ReceiverId, msgIn.SenderId}, pageArgs(page)...)...)
 	if err != nil {
-		return messages, err
+		return nil, nil, err
+	}
+	return scanMessagePage(rows, page)
+}
+
+// page is taken from newest messages, returned in chronological order
+func (repo *MsgRepository) GetAllGroup(userId, groupId string, page models.Page) ([]models.ChatMessage, *models.Cursor, error) {
+	rows, err := repo.DB.Query(`
+		SELECT `+messageColumns+` FROM messages
+		WHERE ((sender_id = ? AND receiver_id = ? ) OR (receiver_id = ? AND ((SELECT COUNT() FROM groups WHERE group_id = ? AND administrator = ?) = 1 OR (SELECT COUNT() FROM group_users WHERE group_id =? AND user_id =?) = 1) ))
+		  AND message_id NOT IN (SELECT message_id FROM message_deletions WHERE user_id = ?)
+		  AND (? = '' OR created_at < ? OR (created_at = ? AND message_id < ?))
+		ORDER BY created_at DESC, message_id DESC
+		LIMIT ?;`, append([]interface{}{userId, groupId, groupId, groupId, userId, groupId, userId, userId}, pageArgs(page)...)...)
+	if err != nil {
+		return nil, nil, err
 	}
+	return scanMessagePage(rows, page)
+}
+
+// reads newest first rows, trims them to page and flips to oldest first
+func scanMessagePage(rows *sql.Rows, page models.Page) ([]models.ChatMessage, *models.Cursor, error) {
+	defer rows.Close()
+	var messages []models.ChatMessage
+	var keys []models.Cursor
 	for rows.Next() {
 		var msg models.ChatMessage
-		rows.Scan(&msg.ID, &msg.SenderId, &msg.ReceiverId, &msg.Type, &msg.Content)
+		var key models.Cursor
+		var quotedSender, quotedContent sql.NullString
+		var quotedDeleted sql.NullBool
+		rows.Scan(&msg.ID, &msg.SenderId, &msg.ReceiverId, &msg.Type, &msg.Content, &msg.ReplyToID, &msg.Edited, &msg.Deleted, &quotedSender, &quotedContent, &quotedDeleted, &msg.DeliveredAt, &msg.ReadAt, &msg.ReadCount, &key.CreatedAt)
+		key.ID = msg.ID
+		if quotedSender.Valid {
+			msg.ReplyTo = &models.ChatMessage{ID: msg.ReplyToID, SenderId: quotedSender.String, ReceiverId: msg.ReceiverId, Type: msg.Type, Content: quotedContent.String, Deleted: quotedDeleted.Bool}
+		}
 		messages = append(messages, msg)
+		keys = append(keys, key)
 	}
-	return messages, nil
+	next := nextCursor(page, keys)
+	if next != nil {
+		messages = messages[:page.Limit]
+	}
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+	return messages, next, nil
+}
+
+func (repo *MsgRepository) MarkAsRead(msg models.ChatMessage) (bool, error) {
+	result, err := repo.DB.Exec("UPDATE messages SET is_read = ?, read_at = CURRENT_TIMESTAMP, delivered_at = IFNULL(delivered_at, CURRENT_TIMESTAMP) WHERE message_id=? AND receiver_id =? AND read_at IS NULL", 1, msg.ID, msg.ReceiverId)
+	if err != nil {
+		return false, err
+	}
+	read, err := result.RowsAffected()
+	return read > 0, err
 }
 
-func (repo *MsgRepository) GetAllGroup(userId, groupId string) ([]models.ChatMessage, error) {
+func (repo *MsgRepository) MarkAsReadGroup(msg models.ChatMessage) (bool, error) {
+	result, err := repo.DB.Exec("UPDATE group_messages SET is_read = ?, read_at = CURRENT_TIMESTAMP, delivered_at = IFNULL(delivered_at, CURRENT_TIMESTAMP) WHERE message_id = ? AND  receiver_id = ? AND read_at IS NULL", 1, msg.ID, msg.ReceiverId)
+	if err != nil {
+		return false, err
+	}
+	read, err := result.RowsAffected()
+	return read > 0, err
+}
+
+func (repo *MsgRepository) MarkDelivered(msg models.ChatMessage) (bool, error) {
+	query := "UPDATE messages SET delivered_at = CURRENT_TIMESTAMP WHERE message_id = ? AND receiver_id = ? AND delivered_at IS NULL"
+	if msg.Type == "GROUP" {
+		query = "UPDATE group_messages SET delivered_at = CURRENT_TIMESTAMP WHERE message_id = ? AND receiver_id = ? AND delivered_at IS NULL"
+	}
+	result, err := repo.DB.Exec(query, msg.ID, msg.ReceiverId)
+	if err != nil {
+		return false, err
+	}
+	delivered, err := result.RowsAffected()
+	return delivered > 0, err
+}
+
+func (repo *MsgRepository) MarkAllDelivered(userId string) ([]models.ChatMessage, error) {
 	var messages []models.ChatMessage
-	rows, err := repo.DB.Query("SELECT message_id,sender_id, receiver_id, type, content FROM messages WHERE (sender_id = ? AND receiver_id = ? ) OR (receiver_id = ? AND ((SELECT COUNT() FROM groups WHERE group_id = ? AND administrator = ?) = 1 OR (SELECT COUNT() FROM group_users WHERE group_id =? AND user_id =?) = 1) ) ORDER BY created_at ASC;", userId, groupId, groupId, groupId, userId, groupId, userId)
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return messages, err
+	}
+	defer tx.Rollback()
+	rows, err := tx.Query(`
+		SELECT message_id, sender_id, receiver_id, type FROM messages
+		WHERE type = 'PERSON' AND receiver_id = ?1 AND delivered_at IS NULL
+		UNION ALL SELECT message_id, sender_id, receiver_id, type FROM messages
+		WHERE message_id IN (SELECT message_id FROM group_messages WHERE receiver_id = ?1 AND delivered_at IS NULL)`, userId)
 	if err != nil {
 		return messages, err
 	}
 	for rows.Next() {
 		var msg models.ChatMessage
-		rows.Scan(&msg.ID, &msg.SenderId, &msg.ReceiverId, &msg.Type, &msg.Content)
+		if err := rows.Scan(&msg.ID, &msg.SenderId, &msg.ReceiverId, &msg.Type); err != nil {
+			rows.Close()
+			return messages, err
+		}
 		messages = append(messages, msg)
 	}
-	return messages, nil
+	rows.Close()
+	statements := []string{
+		"UPDATE messages SET delivered_at = CURRENT_TIMESTAMP WHERE type = 'PERSON' AND receiver_id = ?1 AND delivered_at IS NULL",
+		"UPDATE group_messages SET delivered_at = CURRENT_TIMESTAMP WHERE receiver_id = ?1 AND delivered_at IS NULL",
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement, userId); err != nil {
+			return nil, err
+		}
+	}
+	return messages, tx.Commit()
 }
 
-func (repo *MsgRepository) MarkAsRead(msg models.ChatMessage) error {
-	_, err := repo.DB.Exec("UPDATE messages SET is_read = ? WHERE message_id=? AND receiver_id =?", 1, msg.ID, msg.ReceiverId)
-	if err != nil {
-		return err
-	}
-	return nil
+// receipts of private message come from message itself, of group message from its copy for each member
+const receiptQuery = `
+	SELECT message_id, receiver_id, delivered_at, read_at FROM messages WHERE type = 'PERSON' AND message_id = ?1 AND (?2 = '' OR receiver_id = ?2)
+	UNION ALL SELECT message_id, receiver_id, delivered_at, read_at FROM group_messages WHERE message_id = ?1 AND (?2 = '' OR receiver_id = ?2)`
+
+func (repo *MsgRepository) GetReceipt(messageId, userId string) (models.MessageReceipt, error) {
+	var receipt models.MessageReceipt
+	err := repo.DB.QueryRow(receiptQuery, messageId, userId).Scan(&receipt.MessageID, &receipt.UserID, &receipt.DeliveredAt, &receipt.ReadAt)
+	return receipt, err
 }
 
-func (repo *MsgRepository) MarkAsReadGroup(msg models.ChatMessage) error {
-	_, err := repo.DB.Exec("UPDATE group_messages SET is_read = ? WHERE message_id = ? AND  receiver_id = ?", 1, msg.ID, msg.ReceiverId)
+func (repo *MsgRepository) GetReceipts(messageId string) ([]models.MessageReceipt, error) {
+	var receipts []models.MessageReceipt
+	rows, err := repo.DB.Query("SELECT * FROM ("+receiptQuery+") ORDER BY read_at IS NULL, read_at, delivered_at", messageId, "")
 	if err != nil {
-		return err
+		return receipts, err
 	}
-	return nil
+	defer rows.Close()
+	for rows.Next() {
+		var receipt models.MessageReceipt
+		if err := rows.Scan(&receipt.MessageID, &receipt.UserID, &receipt.DeliveredAt, &receipt.ReadAt); err != nil {
+			return receipts, err
+		}
+		receipts = append(receipts, receipt)
+	}
+	return receipts, nil
 }
 
 func (repo *MsgRepository) GetUnread(userId string) ([]models.ChatStats, error) {
@@ -92,13 +272,15 @@ func (repo *MsgRepository) GetUnread(userId string) ([]models.ChatStats, error)
 
 func (repo *MsgRepository) GetUnreadGroup(userId string) ([]models.ChatStats, error) {
 	var messages []models.ChatStats
-	rows, err := repo.DB.Query("SELECT receiver_id, type, COUNT(*) FROM messages WHERE type = 'GROUP'AND ((SELECT administrator FROM groups WHERE group_id = messages.receiver_id) = ? OR (SELECT COUNT(*) FROM group_users WHERE group_id = messages.receiver_id AND user_id = ?) = 1) AND (SELECT is_read FROM group_messages WHERE message_id = messages.message_id AND receiver_id = ?) = 0 GROUP BY receiver_id;", userId, userId, userId)
+	rows, err := repo.DB.Query("SELECT receiver_id, type, COUNT(*) FROM messages WHERE type = 'GROUP'AND ((SELECT administrator FROM groups WHERE group_id = messages.receiver_id) = ? OR (SELECT COUNT(*) FROM group_users WHERE group_id = messages.receiver_id AND user_id = ?) = 1) AND (SELECT is_read FROM group_messages WHERE message_id = messages.message_id AND receiver_id = ?) = 0 AND ((SELECT COUNT(*) FROM group_chat_mutes WHERE group_id = messages.receiver_id AND user_id = ?) = 0 OR (SELECT mentioned FROM group_messages WHERE message_id = messages.message_id AND receiver_id = ?) = 1) GROUP BY receiver_id;", userId, userId, userId, userId, userId)
 	
 	/*
 	SELECT receiver_id, type, COUNT(*) FROM messages WHERE type = 'GROUP' AND					
 		// is user group admin ?																	-- is group member? --
 	((SELECT administrator FROM groups WHERE group_id = messages.receiver_id) = ? OR (SELECT COUNT(*) FROM group_users WHERE group_id = messages.receiver_id AND user_id = ?) = 1) 
-		AND (SELECT is_read FROM group_messages WHERE message_id = messages.message_id AND receiver_id = ?) = 0 GROUP BY receiver_id;
+		AND (SELECT is_read FROM group_messages WHERE message_id = messages.message_id AND receiver_id = ?) = 0
+		-- muted chat counts only messages mentioning user --
+		AND ((SELECT COUNT(*) FROM group_chat_mutes WHERE group_id = messages.receiver_id AND user_id = ?) = 0 OR (SELECT mentioned FROM group_messages WHERE message_id = messages.message_id AND receiver_id = ?) = 1) GROUP BY receiver_id;
 	*/
 	
 	
@@ -147,4 +329,37 @@ func (repo *MsgRepository)HasHistory(senderId, receiverId string) (bool, error){
 		return false, nil
 	}
 	return true, nil
-}
\ No newline at end of file
+}
+
+func (repo *MsgRepository) MuteGroupChat(groupId, userId string) error {
+	_, err := repo.DB.Exec("INSERT OR IGNORE INTO group_chat_mutes (group_id, user_id) VALUES (?, ?)", groupId, userId)
+	return err
+}
+
+func (repo *MsgRepository) UnmuteGroupChat(groupId, userId string) error {
+	_, err := repo.DB.Exec("DELETE FROM group_chat_mutes WHERE group_id = ? AND user_id = ?", groupId, userId)
+	return err
+}
+
+func (repo *MsgRepository) IsGroupChatMuted(groupId, userId string) (bool, error) {
+	var count int
+	err := repo.DB.QueryRow("SELECT COUNT(*) FROM group_chat_mutes WHERE group_id = ? AND user_id = ?", groupId, userId).Scan(&count)
+	return count > 0, err
+}
+
+func (repo *MsgRepository) GetGroupChatMutes(groupId string) (map[string]bool, error) {
+	muted := make(map[string]bool)
+	rows, err := repo.DB.Query("SELECT user_id FROM group_chat_mutes WHERE group_id = ?", groupId)
+	if err != nil {
+		return muted, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var userId string
+		if err := rows.Scan(&userId); err != nil {
+			return muted, err
+		}
+		muted[userId] = true
+	}
+	return muted, nil
+}
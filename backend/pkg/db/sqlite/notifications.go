@@ -11,14 +11,19 @@ type NotifRepository struct {
 }
 
 func (repo *NotifRepository) Save(n models.Notification) error {
-	stmt, err := repo.DB.Prepare(`
-		INSERT INTO notifications (notif_id, user_id, type, content, sender) 
-		VALUES (?, ?, ?, ?, ?)`)
-	if err != nil {
-		return err
-	}
-	defer stmt.Close()
-	_, err = stmt.Exec(n.ID, n.TargetID, n.Type, n.Content, n.Sender)
+	return saveNotification(repo.DB, n)
+}
+
+// database or transaction notification is saved with
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// inserts notification, other repositories use it to save one inside their transaction
+func saveNotification(db execer, n models.Notification) error {
+	_, err := db.Exec(`
+		INSERT INTO notifications (notif_id, user_id, type, content, sender, created_at) 
+		VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)`, n.ID, n.TargetID, n.Type, n.Content, n.Sender)
 	return err
 }
 
@@ -34,6 +39,13 @@ func (repo *NotifRepository) DeleteByType(n models.Notification) error {
 	return err
 }
 
+func (repo *NotifRepository) DeleteSent(n models.Notification) error {
+	_, err := repo.DB.Exec(`
+		DELETE FROM notifications 
+		WHERE user_id = ? AND type = ? AND content = ? AND sender = ?`, n.TargetID, n.Type, n.Content, n.Sender)
+	return err
+}
+
 func (repo *NotifRepository) GetGroupRequests(groupId string) ([]models.Notification, error) {
 	var notifs []models.Notification
 	rows, err := repo.DB.Query(`
@@ -104,25 +116,36 @@ func (repo *NotifRepository) GetGroupId(notificationId string) (string, error) {
 	return groupId, err
 }
 
-func (repo *NotifRepository) GetAll(userId string) ([]models.Notification, error) {
+func (repo *NotifRepository) GetAll(userId string, page models.Page) ([]models.Notification, *models.Cursor, error) {
 	var notifs []models.Notification
+	var keys []models.Cursor
 	rows, err := repo.DB.Query(`
-		SELECT content, notif_id, type, sender, user_id, COALESCE(read, FALSE) 
+		SELECT content, notif_id, type, sender, user_id, COALESCE(read, FALSE), CAST(created_at AS TEXT) 
 		FROM notifications 
-		WHERE user_id = ? 
+		WHERE (user_id = ? 
 		OR (SELECT administrator FROM groups WHERE group_id = notifications.user_id) = ?
-		ORDER BY notif_id DESC`, userId, userId)
+		OR (type = 'GROUP_REQUEST' AND user_id IN (SELECT group_id FROM group_users WHERE user_id = ? AND role = 'moderator')))
+		AND (? = '' OR created_at < ? OR (created_at = ? AND notif_id < ?))
+		ORDER BY created_at DESC, notif_id DESC
+		LIMIT ?`, append([]interface{}{userId, userId, userId}, pageArgs(page)...)...)
 	if err != nil {
-		return notifs, err
+		return notifs, nil, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
 		var n models.Notification
-		rows.Scan(&n.Content, &n.ID, &n.Type, &n.Sender, &n.TargetID, &n.Read)
+		var key models.Cursor
+		rows.Scan(&n.Content, &n.ID, &n.Type, &n.Sender, &n.TargetID, &n.Read, &key.CreatedAt)
+		key.ID = n.ID
 		notifs = append(notifs, n)
+		keys = append(keys, key)
 	}
-	return notifs, nil
+	next := nextCursor(page, keys)
+	if next != nil {
+		notifs = notifs[:page.Limit]
+	}
+	return notifs, next, nil
 }
 
 func (repo *NotifRepository) GetCahtNotifById(notificationId string) (models.Notification, error) {
@@ -150,12 +173,20 @@ func (repo *NotifRepository) GetContentFromChatRequest(senderId, receiverId stri
 	return content, err
 }
 
-func (repo *NotifRepository) MarkAsRead(notificationId string) error {
-	_, err := repo.DB.Exec(`
+func (repo *NotifRepository) MarkAsRead(notificationId, userId string) (bool, error) {
+	// same owners as in GetAll: user, owner of target group, moderators for join requests
+	res, err := repo.DB.Exec(`
 		UPDATE notifications 
 		SET read = TRUE 
-		WHERE notif_id = ?`, notificationId)
-	return err
+		WHERE notif_id = ?1
+		AND (user_id = ?2
+		OR (SELECT administrator FROM groups WHERE group_id = notifications.user_id) = ?2
+		OR (type = 'GROUP_REQUEST' AND user_id IN (SELECT group_id FROM group_users WHERE user_id = ?2 AND role = 'moderator')))`, notificationId, userId)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	return affected > 0, err
 }
 
 func (repo *NotifRepository) MarkAllAsRead(userId string) error {
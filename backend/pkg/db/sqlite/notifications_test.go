@@ -0,0 +1,49 @@
+package db
+
+import (
+	"testing"
+
+	"social-network/pkg/models"
+)
+
+func TestMarkNotificationAsRead(t *testing.T) {
+	db := newTestDB(t)
+	notifs, groups := &NotifRepository{DB: db}, &GroupRepository{DB: db}
+	if err := groups.New(models.Group{ID: "group", Name: "group", AdminID: "owner", Privacy: "private"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, member := range []string{"mod", "member"} {
+		if err := groups.SaveMember(member, "group"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := groups.SetRole("group", "mod", "moderator"); err != nil {
+		t.Fatal(err)
+	}
+	saved := []models.Notification{
+		{ID: "follow", TargetID: "bob", Type: "FOLLOW", Content: "alice", Sender: "alice"},
+		{ID: "request", TargetID: "group", Type: "GROUP_REQUEST", Content: "joiner", Sender: "joiner"},
+	}
+	for _, notif := range saved {
+		if err := notifs.Save(notif); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		notif, user string
+		want        bool
+	}{
+		{"follow", "alice", false},
+		{"follow", "bob", true},
+		{"request", "member", false},
+		{"request", "mod", true},
+		{"request", "owner", true},
+		{"missing", "bob", false},
+	}
+	for _, test := range tests {
+		got, err := notifs.MarkAsRead(test.notif, test.user)
+		if err != nil || got != test.want {
+			t.Errorf("MarkAsRead(%s, %s) = %v, %v, want %v", test.notif, test.user, got, err, test.want)
+		}
+	}
+}
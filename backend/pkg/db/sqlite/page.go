@@ -0,0 +1,21 @@
+package db
+
+import "social-network/pkg/models"
+
+// arguments for keyset condition of lists ordered by created_at DESC, id DESC:
+// cursor id is empty, or created_at < ?, or created_at = ? AND <id> < ?,
+// followed by LIMIT, one extra row is fetched to know if there is next page
+func pageArgs(page models.Page) []interface{} {
+	after := page.After
+	return []interface{}{after.ID, after.CreatedAt, after.CreatedAt, after.ID, page.Limit + 1}
+}
+
+// returns cursor of last row on page or nil when extra row was not found
+// keys hold cursor of every scanned row, caller trims rows to page.Limit
+func nextCursor(page models.Page, keys []models.Cursor) *models.Cursor {
+	if len(keys) <= page.Limit {
+		return nil
+	}
+	next := keys[page.Limit-1]
+	return &next
+}
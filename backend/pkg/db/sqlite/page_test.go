@@ -0,0 +1,95 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"social-network/pkg/models"
+)
+
+func TestMain(m *testing.M) {
+	// migrations are read relative to backend directory, as when server runs
+	if err := os.Chdir("../../.."); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db")+"?_busy_timeout=5000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err = Migrations(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestNextCursor(t *testing.T) {
+	keys := []models.Cursor{{ID: "c"}, {ID: "b"}, {ID: "a"}}
+	tests := []struct {
+		limit int
+		rows  int
+		want  *models.Cursor
+	}{
+		{2, 3, &keys[1]}, // extra row found
+		{3, 3, nil},      // exactly full page
+		{5, 3, nil},      // short page
+		{1, 0, nil},      // empty list
+	}
+	for _, test := range tests {
+		got := nextCursor(models.Page{Limit: test.limit}, keys[:test.rows])
+		if (got == nil) != (test.want == nil) || (got != nil && *got != *test.want) {
+			t.Errorf("limit %d, %d rows: got %v, want %v", test.limit, test.rows, got, test.want)
+		}
+	}
+}
+
+// rows with equal created_at are split by id, every row is returned once
+func TestNotificationPages(t *testing.T) {
+	repo := &NotifRepository{DB: newTestDB(t)}
+	for i := 0; i < 7; i++ {
+		notif := models.Notification{ID: fmt.Sprintf("n%d", i), TargetID: "user", Type: "FOLLOW", Content: "other", Sender: "other"}
+		if err := repo.Save(notif); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// other timestamp, listed after the rest
+	if _, err := repo.DB.Exec("UPDATE notifications SET created_at = '2000-01-01 00:00:00' WHERE notif_id = 'n6'"); err != nil {
+		t.Fatal(err)
+	}
+
+	all, next, err := repo.GetAll("user", models.Page{Limit: 10})
+	if err != nil || next != nil || len(all) != 7 {
+		t.Fatalf("page bigger than list got %d rows, next %v, err %v", len(all), next, err)
+	}
+
+	var seen []string
+	page := models.Page{Limit: 3}
+	for i := 0; ; i++ {
+		notifs, next, err := repo.GetAll("user", page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, n := range notifs {
+			seen = append(seen, n.ID)
+		}
+		if next == nil {
+			break
+		}
+		if i > 3 {
+			t.Fatal("pages don't end")
+		}
+		page.After = *next
+	}
+	want := []string{"n5", "n4", "n3", "n2", "n1", "n0", "n6"}
+	if fmt.Sprint(seen) != fmt.Sprint(want) {
+		t.Fatalf("got %v, want %v", seen, want)
+	}
+}
@@ -16,10 +16,11 @@ type PostRepository struct {
 // Private posts if is a follower
 // almost_private if has access
 // all posts if user is an author
-func (repo *PostRepository) GetAll(userID string) ([]models.Post, error) {
+func (repo *PostRepository) GetAll(userID string, page models.Page) ([]models.Post, *models.Cursor, error) {
 	var posts []models.Post
+	var keys []models.Cursor
 	rows, err := repo.DB.Query(`
-		SELECT post_id, created_by, content, image, visibility, created_at FROM posts
+		SELECT post_id, created_by, content, image, visibility, created_at, edited_at, CAST(created_at AS TEXT) FROM posts
 		WHERE group_id IS NULL
 		  AND (
 			visibility = 'PUBLIC'
@@ -34,23 +35,34 @@ func (repo *PostRepository) GetAll(userID string) ([]models.Post, error) {
 			)
 			OR created_by = ?
 		  )
-		ORDER BY created_at DESC;
-	`, userID, userID, userID, userID, userID)
+		  AND (? = '' OR created_at < ? OR (created_at = ? AND post_id < ?))
+		ORDER BY created_at DESC, post_id DESC
+		LIMIT ?;
+	`, append([]interface{}{userID, userID, userID, userID, userID}, pageArgs(page)...)...)
 	if err != nil {
-		return posts, err
+		return posts, nil, err
 	}
+	defer rows.Close()
 	for rows.Next() {
 		var post models.Post
-		rows.Scan(&post.ID, &post.AuthorID, &post.Content, &post.ImagePath, &post.Visibility, &post.CreatedAt)
+		var key models.Cursor
+		rows.Scan(&post.ID, &post.AuthorID, &post.Content, &post.ImagePath, &post.Visibility, &post.CreatedAt, &post.EditedAt, &key.CreatedAt)
+		key.ID = post.ID
 		posts = append(posts, post)
+		keys = append(keys, key)
 	}
-	return posts, nil
+	next := nextCursor(page, keys)
+	if next != nil {
+		posts = posts[:page.Limit]
+	}
+	return posts, next, nil
 }
 
-func (repo *PostRepository) GetUserPosts(userID, currentUserID string) ([]models.Post, error) {
+func (repo *PostRepository) GetUserPosts(userID, currentUserID string, page models.Page) ([]models.Post, *models.Cursor, error) {
 	var posts []models.Post
+	var keys []models.Cursor
 	rows, err := repo.DB.Query(`
-		SELECT post_id, created_by, content, image, visibility, created_at FROM posts 
+		SELECT post_id, created_by, content, image, visibility, created_at, edited_at, CAST(created_at AS TEXT) FROM posts 
 		WHERE group_id IS NULL 
 		  AND created_by = ?
 		  AND (
@@ -66,31 +78,133 @@ func (repo *PostRepository) GetUserPosts(userID, currentUserID string) ([]models
 			)
 			OR ? = ?
 		  )
-		ORDER BY created_at DESC;
-	`, userID, currentUserID, currentUserID, currentUserID, userID, userID, currentUserID, userID, currentUserID, userID)
+		  AND (? = '' OR created_at < ? OR (created_at = ? AND post_id < ?))
+		ORDER BY created_at DESC, post_id DESC
+		LIMIT ?;
+	`, append([]interface{}{userID, currentUserID, currentUserID, currentUserID, userID, userID, currentUserID, userID, currentUserID, userID}, pageArgs(page)...)...)
 	if err != nil {
-		return posts, err
+		return posts, nil, err
 	}
+	defer rows.Close()
 	for rows.Next() {
 		var post models.Post
-		rows.Scan(&post.ID, &post.AuthorID, &post.Content, &post.ImagePath, &post.Visibility, &post.CreatedAt)
+		var key models.Cursor
+		rows.Scan(&post.ID, &post.AuthorID, &post.Content, &post.ImagePath, &post.Visibility, &post.CreatedAt, &post.EditedAt, &key.CreatedAt)
+		key.ID = post.ID
 		posts = append(posts, post)
+		keys = append(keys, key)
+	}
+	next := nextCursor(page, keys)
+	if next != nil {
+		posts = posts[:page.Limit]
 	}
-	return posts, nil
+	return posts, next, nil
 }
 
-func (repo *PostRepository) GetGroupPosts(groupID string) ([]models.Post, error) {
+func (repo *PostRepository) GetGroupPosts(groupID string, page models.Page) ([]models.Post, *models.Cursor, error) {
 	var posts []models.Post
-	rows, err := repo.DB.Query("SELECT post_id , created_by, content, image, created_at  FROM posts WHERE group_id = ? ORDER BY created_at DESC;", groupID)
+	var keys []models.Cursor
+	// pinned posts only on first page, ahead of the rest
+	if page.After.ID == "" {
+		rows, err := repo.DB.Query(`
+			SELECT `+groupPostColumns+` FROM posts
+			WHERE group_id = ? AND pinned_at IS NOT NULL
+			ORDER BY pinned_at DESC, post_id DESC;`, groupID)
+		if err != nil {
+			return posts, nil, err
+		}
+		for rows.Next() {
+			var post models.Post
+			var createdAt string
+			rows.Scan(&post.ID, &post.AuthorID, &post.Content, &post.ImagePath, &post.CreatedAt, &post.EditedAt, &post.PinnedAt, &post.AnnouncedAt, &createdAt)
+			posts = append(posts, post)
+		}
+		rows.Close()
+	}
+	pinned := len(posts)
+	rows, err := repo.DB.Query(`
+		SELECT `+groupPostColumns+` FROM posts
+		WHERE group_id = ? AND pinned_at IS NULL
+		  AND (? = '' OR created_at < ? OR (created_at = ? AND post_id < ?))
+		ORDER BY created_at DESC, post_id DESC
+		LIMIT ?;`, append([]interface{}{groupID}, pageArgs(page)...)...)
 	if err != nil {
-		return posts, err
+		return posts, nil, err
 	}
+	defer rows.Close()
 	for rows.Next() {
 		var post models.Post
-		rows.Scan(&post.ID, &post.AuthorID, &post.Content, &post.ImagePath, &post.CreatedAt)
+		var key models.Cursor
+		rows.Scan(&post.ID, &post.AuthorID, &post.Content, &post.ImagePath, &post.CreatedAt, &post.EditedAt, &post.PinnedAt, &post.AnnouncedAt, &key.CreatedAt)
+		key.ID = post.ID
 		posts = append(posts, post)
+		keys = append(keys, key)
+	}
+	next := nextCursor(page, keys)
+	if next != nil {
+		posts = posts[:pinned+page.Limit]
+	}
+	return posts, next, nil
+}
+
+// columns of group post list, last one is cursor key
+const groupPostColumns = "post_id, created_by, content, image, created_at, edited_at, pinned_at, announced_at, CAST(created_at AS TEXT)"
+
+func (repo *PostRepository) GetData(postID string) (models.Post, error) {
+	var post models.Post
+	err := repo.DB.QueryRow("SELECT post_id, COALESCE(group_id, ''), created_by, content, image, COALESCE(visibility, ''), created_at, edited_at, pinned_at, announced_at FROM posts WHERE post_id = ?", postID).
+		Scan(&post.ID, &post.GroupID, &post.AuthorID, &post.Content, &post.ImagePath, &post.Visibility, &post.CreatedAt, &post.EditedAt, &post.PinnedAt, &post.AnnouncedAt)
+	return post, err
+}
+
+// limit is checked in the same statement, so two moderators can't pin over it
+func (repo *PostRepository) Pin(postID string, limit int) (bool, error) {
+	result, err := repo.DB.Exec(`
+		UPDATE posts SET pinned_at = CURRENT_TIMESTAMP
+		WHERE post_id = ?1 AND group_id IS NOT NULL AND pinned_at IS NULL
+		AND (SELECT COUNT(*) FROM posts AS pinned WHERE pinned.group_id = posts.group_id AND pinned.pinned_at IS NOT NULL) < ?2`, postID, limit)
+	if err != nil {
+		return false, err
+	}
+	pinned, err := result.RowsAffected()
+	return pinned > 0, err
+}
+
+func (repo *PostRepository) Unpin(postID string) error {
+	_, err := repo.DB.Exec("UPDATE posts SET pinned_at = NULL WHERE post_id = ?", postID)
+	return err
+}
+
+func (repo *PostRepository) Announce(postID string) (bool, error) {
+	result, err := repo.DB.Exec("UPDATE posts SET announced_at = CURRENT_TIMESTAMP WHERE post_id = ? AND group_id IS NOT NULL AND announced_at IS NULL", postID)
+	if err != nil {
+		return false, err
 	}
-	return posts, nil
+	announced, err := result.RowsAffected()
+	return announced > 0, err
+}
+
+// same visibility rules as GetAll, group posts are checked by group access
+func (repo *PostRepository) CanAccess(postID, userID string) (bool, error) {
+	var count int
+	err := repo.DB.QueryRow(`
+		SELECT COUNT(*) FROM posts
+		WHERE post_id = ?
+		  AND group_id IS NULL
+		  AND (
+			visibility = 'PUBLIC'
+			OR (visibility = 'ALMOST_PRIVATE' AND (SELECT COUNT(*) FROM almost_private WHERE almost_private.post_id = posts.post_id AND almost_private.user_id = ?) = 1)
+			OR (visibility = 'PRIVATE' 
+				AND (SELECT COUNT(*) FROM private_post_access WHERE private_post_access.post_id = posts.post_id AND private_post_access.user_id = ?) = 1
+				AND (
+					created_by = ?
+					OR (SELECT COUNT(*) FROM followers WHERE followers.user_id = posts.created_by AND follower_id = ?) = 1
+					OR (SELECT status FROM users WHERE user_id = posts.created_by) = 'public'
+				)
+			)
+			OR created_by = ?
+		  );`, postID, userID, userID, userID, userID, userID).Scan(&count)
+	return count > 0, err
 }
 
 func (repo *PostRepository) New(post models.Post) error {
@@ -104,6 +218,66 @@ func (repo *PostRepository) New(post models.Post) error {
 	return nil
 }
 
+func (repo *PostRepository) Update(post models.Post, access []string) error {
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	_, err = tx.Exec("UPDATE posts SET content = ?, image = ?, visibility = ?, edited_at = CURRENT_TIMESTAMP WHERE post_id = ?", post.Content, post.ImagePath, post.Visibility, post.ID)
+	if err != nil {
+		return err
+	}
+	if access == nil {
+		return tx.Commit()
+	}
+	// access lists are rebuilt from scratch for new visibility
+	for _, statement := range []string{
+		"DELETE FROM almost_private WHERE post_id = ?",
+		"DELETE FROM private_post_access WHERE post_id = ?",
+	} {
+		if _, err := tx.Exec(statement, post.ID); err != nil {
+			return err
+		}
+	}
+	table := "private_post_access"
+	if post.Visibility == "ALMOST_PRIVATE" {
+		table = "almost_private"
+	}
+	for _, userId := range access {
+		if _, err := tx.Exec("INSERT INTO "+table+" (post_id, user_id) values (?,?)", post.ID, userId); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// removes post and everything attached to it in one transaction
+func (repo *PostRepository) Delete(postID string) error {
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	statements := []string{
+		// reaction and reply notification content is reacted post or comment, or the reply
+		"DELETE FROM notifications WHERE type IN ('REACTION', 'COMMENT_REPLY') AND (content = ?1 OR content IN (SELECT comment_id FROM comments WHERE post_id = ?1))",
+		"DELETE FROM reactions WHERE target_id IN (SELECT comment_id FROM comments WHERE post_id = ?)",
+		"DELETE FROM comments WHERE post_id = ?",
+		"DELETE FROM reactions WHERE target_id = ?",
+		"DELETE FROM almost_private WHERE post_id = ?",
+		"DELETE FROM private_post_access WHERE post_id = ?",
+		"DELETE FROM notifications WHERE type = 'GROUP_ANNOUNCEMENT' AND content = ?",
+		"DELETE FROM posts WHERE post_id = ?",
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement, postID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
 func (repo *PostRepository) SaveAccess(postId, userId string) error {
 	stmt, err := repo.DB.Prepare("INSERT INTO almost_private (post_id, user_id) values (?,?)")
 	if err != nil {
@@ -0,0 +1,158 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+	"testing"
+
+	"social-network/pkg/models"
+)
+
+func accessRows(t *testing.T, db *sql.DB, table, postId string) int {
+	t.Helper()
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM "+table+" WHERE post_id = ?", postId).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestUpdatePostAccess(t *testing.T) {
+	db := newTestDB(t)
+	repo := &PostRepository{DB: db}
+	post := models.Post{ID: "post", AuthorID: "alice", Content: "hi", Visibility: "ALMOST_PRIVATE"}
+	if err := repo.New(post); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Update(post, []string{"bob", "carol"}); err != nil {
+		t.Fatal(err)
+	}
+
+	post.Visibility = "PRIVATE"
+	if err := repo.Update(post, []string{"dave"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := accessRows(t, db, "almost_private", "post"); got != 0 {
+		t.Errorf("almost_private rows = %d, want 0", got)
+	}
+	if got := accessRows(t, db, "private_post_access", "post"); got != 1 {
+		t.Errorf("private_post_access rows = %d, want 1", got)
+	}
+
+	// content change keeps access
+	post.Content = "edited"
+	if err := repo.Update(post, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := accessRows(t, db, "private_post_access", "post"); got != 1 {
+		t.Errorf("private_post_access rows = %d after content edit, want 1", got)
+	}
+
+	// failing access change leaves post as it was
+	if _, err := db.Exec("ALTER TABLE almost_private RENAME TO almost_private_gone"); err != nil {
+		t.Fatal(err)
+	}
+	post.Content, post.Visibility = "failed", "ALMOST_PRIVATE"
+	if err := repo.Update(post, []string{"erin"}); err == nil {
+		t.Fatal("update without access table succeeded")
+	}
+	saved, err := repo.GetData("post")
+	if err != nil || saved.Content != "edited" || saved.Visibility != "PRIVATE" {
+		t.Errorf("post after failed update = %q %q, %v", saved.Content, saved.Visibility, err)
+	}
+	if got := accessRows(t, db, "private_post_access", "post"); got != 1 {
+		t.Errorf("private_post_access rows = %d after failed update, want 1", got)
+	}
+}
+
+// group keeps at most limit pinned posts even when pinned at the same time,
+// pinned posts lead only the first page
+func TestPinLimit(t *testing.T) {
+	db := newTestDB(t)
+	repo := &PostRepository{DB: db}
+	posts := []models.Post{
+		{ID: "other", AuthorID: "alice", GroupID: "other", Content: "hi"},
+		{ID: "profile", AuthorID: "alice", Content: "hi", Visibility: "PUBLIC"},
+	}
+	for i := 0; i < 6; i++ {
+		posts = append(posts, models.Post{ID: fmt.Sprint("post", i), AuthorID: "alice", GroupID: "group", Content: "hi"})
+	}
+	for _, post := range posts {
+		if err := repo.New(post); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	const limit = 3
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	pinned := 0
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ok, err := repo.Pin(fmt.Sprint("post", i), limit)
+			if err != nil {
+				t.Error(err)
+			}
+			if ok {
+				mu.Lock()
+				pinned++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	if pinned != limit {
+		t.Fatalf("%d posts pinned, want %d", pinned, limit)
+	}
+
+	tests := []struct {
+		postID string
+		want   bool
+	}{
+		{"other", true},    // limit is per group
+		{"other", false},   // already pinned
+		{"profile", false}, // not group post
+	}
+	for _, test := range tests {
+		if ok, err := repo.Pin(test.postID, limit); err != nil || ok != test.want {
+			t.Errorf("pin %s: got %v, err %v, want %v", test.postID, ok, err, test.want)
+		}
+	}
+
+	page := models.Page{Limit: 2}
+	var seen []models.Post
+	for i := 0; ; i++ {
+		groupPosts, next, err := repo.GetGroupPosts("group", page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			for _, post := range groupPosts[:limit] {
+				if post.PinnedAt == nil {
+					t.Fatalf("first page doesn't start with pinned posts: %+v", groupPosts)
+				}
+			}
+		}
+		seen = append(seen, groupPosts...)
+		if next == nil {
+			break
+		}
+		if i > 6 {
+			t.Fatal("pages don't end")
+		}
+		page.After = *next
+	}
+	ids := map[string]bool{}
+	for _, post := range seen {
+		if ids[post.ID] {
+			t.Errorf("%s listed twice", post.ID)
+		}
+		ids[post.ID] = true
+	}
+	if len(ids) != 6 {
+		t.Errorf("listed %d posts, want 6", len(ids))
+	}
+}
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"database/sql"
+	"social-network/pkg/models"
+)
+
+type ReactionRepository struct {
+	DB *sql.DB
+}
+
+func (repo *ReactionRepository) Get(targetID, userID string) (string, error) {
+	var reaction string
+	err := repo.DB.QueryRow("SELECT type FROM reactions WHERE target_id = ? AND user_id = ?", targetID, userID).Scan(&reaction)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return reaction, err
+}
+
+func (repo *ReactionRepository) Set(reaction models.Reaction) error {
+	_, err := repo.DB.Exec(`
+		INSERT INTO reactions (target_id, target_type, user_id, type) VALUES (?, ?, ?, ?)
+		ON CONFLICT (target_id, user_id) DO UPDATE SET type = excluded.type, created_at = CURRENT_TIMESTAMP`,
+		reaction.TargetID, reaction.TargetType, reaction.UserID, reaction.Type)
+	return err
+}
+
+func (repo *ReactionRepository) Delete(targetID, userID string) error {
+	_, err := repo.DB.Exec("DELETE FROM reactions WHERE target_id = ? AND user_id = ?", targetID, userID)
+	return err
+}
+
+func (repo *ReactionRepository) GetCounts(targetIDs []string) (map[string]map[string]int, error) {
+	counts := make(map[string]map[string]int)
+	for start := 0; start < len(targetIDs); start += batchSize {
+		chunk := targetIDs[start:min(start+batchSize, len(targetIDs))]
+		rows, err := repo.DB.Query("SELECT target_id, type, COUNT(*) FROM reactions WHERE target_id IN ("+placeholders(len(chunk))+") GROUP BY target_id, type", stringArgs(chunk)...)
+		if err != nil {
+			return counts, err
+		}
+		for rows.Next() {
+			var targetID, reaction string
+			var count int
+			rows.Scan(&targetID, &reaction, &count)
+			if counts[targetID] == nil {
+				counts[targetID] = make(map[string]int)
+			}
+			counts[targetID][reaction] = count
+		}
+		rows.Close()
+	}
+	return counts, nil
+}
+
+func (repo *ReactionRepository) GetUserReactions(userID string, targetIDs []string) (map[string]string, error) {
+	reactions := make(map[string]string)
+	for start := 0; start < len(targetIDs); start += batchSize {
+		chunk := targetIDs[start:min(start+batchSize, len(targetIDs))]
+		rows, err := repo.DB.Query("SELECT target_id, type FROM reactions WHERE user_id = ? AND target_id IN ("+placeholders(len(chunk))+")", append([]interface{}{userID}, stringArgs(chunk)...)...)
+		if err != nil {
+			return reactions, err
+		}
+		for rows.Next() {
+			var targetID, reaction string
+			rows.Scan(&targetID, &reaction)
+			reactions[targetID] = reaction
+		}
+		rows.Close()
+	}
+	return reactions, nil
+}
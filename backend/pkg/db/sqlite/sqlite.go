@@ -40,6 +40,11 @@ func InitRepositories(db *sql.DB) *models.Repositories {
 		NotifRepo:   &NotifRepository{DB: db},
 		EventRepo:   &EventRepository{DB: db},
 		MsgRepo:     &MsgRepository{DB: db},
+
+		DeliveryRepo: &DeliveryRepository{DB: db},
+		ReactionRepo: &ReactionRepository{DB: db},
+		JobRepo:      &JobRepository{DB: db},
+		InviteRepo:   &InviteLinkRepository{DB: db},
 	}
 }
 
@@ -3,6 +3,7 @@ package db
 import (
 	"database/sql"
 	"strings"
+	"time"
 
 	"social-network/pkg/models"
 )
@@ -89,6 +90,33 @@ func (repo *UserRepository) GetDataMin(userID string) (models.User, error) {
 	return user, nil
 }
 
+// same as GetDataMin for list of users, loaded in one query per batchSize ids
+// unknown ids are missing from result
+func (repo *UserRepository) GetDataMinBatch(userIDs []string) (map[string]models.User, error) {
+	users := make(map[string]models.User, len(userIDs))
+	for start := 0; start < len(userIDs); start += batchSize {
+		chunk := userIDs[start:min(start+batchSize, len(userIDs))]
+		rows, err := repo.DB.Query("SELECT user_id, first_name, last_name, nickname, image FROM users WHERE user_id IN ("+placeholders(len(chunk))+")", stringArgs(chunk)...)
+		if err != nil {
+			return users, err
+		}
+		for rows.Next() {
+			var user models.User
+			var nickname sql.NullString
+			if err := rows.Scan(&user.ID, &user.FirstName, &user.LastName, &nickname, &user.ImagePath); err != nil {
+				rows.Close()
+				return users, err
+			}
+			if nickname.Valid {
+				user.Nickname = nickname.String
+			}
+			users[user.ID] = user
+		}
+		rows.Close()
+	}
+	return users, nil
+}
+
 // returns true if current user is following
 func (repo *UserRepository) IsFollowing(userID, currentUserID string) (bool, error) {
 	row := repo.DB.QueryRow("SELECT COUNT() FROM followers WHERE user_id = ? AND follower_id = ?;", userID, currentUserID)
@@ -313,3 +341,173 @@ func (repo *UserRepository) SearchUsers(query, currentUserID string) ([]models.U
 
 	return users, nil
 }
+
+// save last time user was online
+func (repo *UserRepository) SetLastSeen(userID string, lastSeen time.Time) error {
+	_, err := repo.DB.Exec("UPDATE users SET last_seen = ? WHERE user_id = ?", lastSeen, userID)
+	return err
+}
+
+// returns last time user was online in RFC3339, empty if never seen
+func (repo *UserRepository) GetLastSeenBatch(userIDs []string, viewerID string) (map[string]string, error) {
+	lastSeen := make(map[string]string, len(userIDs))
+	for start := 0; start < len(userIDs); start += batchSize {
+		chunk := userIDs[start:min(start+batchSize, len(userIDs))]
+		rows, err := repo.DB.Query(`
+			SELECT user_id, last_seen FROM users
+			WHERE user_id IN (`+placeholders(len(chunk))+`)
+			AND (status != 'PRIVATE' OR EXISTS (SELECT 1 FROM followers WHERE followers.user_id = users.user_id AND follower_id = ?))`,
+			append(stringArgs(chunk), viewerID)...)
+		if err != nil {
+			return lastSeen, err
+		}
+		for rows.Next() {
+			var userID string
+			var seen sql.NullTime
+			if err := rows.Scan(&userID, &seen); err != nil {
+				rows.Close()
+				return lastSeen, err
+			}
+			lastSeen[userID] = ""
+			if seen.Valid {
+				lastSeen[userID] = seen.Time.Format(time.RFC3339)
+			}
+		}
+		rows.Close()
+	}
+	return lastSeen, nil
+}
+
+func (repo *UserRepository) GetCalendarToken(userID string) (string, error) {
+	var token string
+	err := repo.DB.QueryRow("SELECT token FROM calendar_tokens WHERE user_id = ?", userID).Scan(&token)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return token, err
+}
+
+func (repo *UserRepository) SetCalendarToken(userID, token string) error {
+	_, err := repo.DB.Exec(`
+		INSERT INTO calendar_tokens (user_id, token) VALUES (?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET token = excluded.token, created_at = CURRENT_TIMESTAMP`, userID, token)
+	return err
+}
+
+func (repo *UserRepository) FindUserByCalendarToken(token string) (string, error) {
+	var userID string
+	err := repo.DB.QueryRow("SELECT user_id FROM calendar_tokens WHERE token = ?", token).Scan(&userID)
+	return userID, err
+}
+
+// comments of user together with all replies to them
+const userThreads = `WITH RECURSIVE thread(id) AS (
+		SELECT comment_id FROM comments WHERE created_by = ?1
+		UNION SELECT c.comment_id FROM comments c JOIN thread ON c.parent_id = thread.id)`
+
+func (repo *UserRepository) Delete(userID string) ([]string, error) {
+	var images []string
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return images, err
+	}
+	defer tx.Rollback()
+
+	// owned groups go to successor, groups without other members are removed
+	var groupIds []string
+	rows, err := tx.Query("SELECT group_id FROM groups WHERE administrator = ?", userID)
+	if err != nil {
+		return images, err
+	}
+	for rows.Next() {
+		var groupId string
+		if err := rows.Scan(&groupId); err != nil {
+			rows.Close()
+			return images, err
+		}
+		groupIds = append(groupIds, groupId)
+	}
+	rows.Close()
+	for _, groupId := range groupIds {
+		var successor string
+		err := tx.QueryRow(groupSuccessor, groupId).Scan(&successor)
+		if err == sql.ErrNoRows {
+			groupImages, err := deleteGroup(tx, groupId)
+			if err != nil {
+				return images, err
+			}
+			images = append(images, groupImages...)
+			continue
+		} else if err != nil {
+			return images, err
+		}
+		if err = transferOwnership(tx, groupId, successor); err != nil {
+			return images, err
+		}
+	}
+
+	rows, err = tx.Query(`
+		SELECT image FROM users WHERE user_id = ?1 AND image IS NOT NULL
+		UNION ALL SELECT image FROM posts WHERE created_by = ?1 AND image IS NOT NULL
+		UNION ALL SELECT image FROM comments WHERE (post_id IN (SELECT post_id FROM posts WHERE created_by = ?1)
+			OR comment_id IN (`+userThreads+` SELECT id FROM thread)) AND image IS NOT NULL`, userID)
+	if err != nil {
+		return images, err
+	}
+	for rows.Next() {
+		var image string
+		if err := rows.Scan(&image); err != nil {
+			rows.Close()
+			return images, err
+		}
+		if image != "" {
+			images = append(images, image)
+		}
+	}
+	rows.Close()
+
+	statements := []string{
+		// posts of user with comments and reactions on them
+		"DELETE FROM reactions WHERE target_id IN (SELECT comment_id FROM comments WHERE post_id IN (SELECT post_id FROM posts WHERE created_by = ?1))",
+		"DELETE FROM comments WHERE post_id IN (SELECT post_id FROM posts WHERE created_by = ?1)",
+		"DELETE FROM reactions WHERE target_id IN (SELECT post_id FROM posts WHERE created_by = ?1)",
+		"DELETE FROM almost_private WHERE post_id IN (SELECT post_id FROM posts WHERE created_by = ?1)",
+		"DELETE FROM private_post_access WHERE post_id IN (SELECT post_id FROM posts WHERE created_by = ?1)",
+		"DELETE FROM posts WHERE created_by = ?1",
+		// comments of user elsewhere, replies go with them
+		userThreads + " DELETE FROM reactions WHERE target_id IN (SELECT id FROM thread)",
+		userThreads + " DELETE FROM comments WHERE comment_id IN (SELECT id FROM thread)",
+		"DELETE FROM reactions WHERE user_id = ?1",
+		// events in groups stay with their answers, group owner takes them over
+		"UPDATE event SET created_by = (SELECT administrator FROM groups WHERE group_id = event.group_id) WHERE created_by = ?1 AND group_id IN (SELECT group_id FROM groups)",
+		"DELETE FROM event_users WHERE event_id IN (SELECT event_id FROM event WHERE created_by = ?1)",
+		"DELETE FROM event WHERE created_by = ?1",
+		"DELETE FROM event_users WHERE user_id = ?1",
+		// chats
+		"DELETE FROM group_messages WHERE receiver_id = ?1 OR message_id IN (SELECT message_id FROM messages WHERE sender_id = ?1)",
+		"DELETE FROM message_deletions WHERE user_id = ?1 OR message_id IN (SELECT message_id FROM messages WHERE sender_id = ?1 OR (type = 'PERSON' AND receiver_id = ?1))",
+		"DELETE FROM messages WHERE sender_id = ?1 OR (type = 'PERSON' AND receiver_id = ?1)",
+		"DELETE FROM group_chat_mutes WHERE user_id = ?1",
+		// relations and everything addressed to user
+		"DELETE FROM group_users WHERE user_id = ?1",
+		"DELETE FROM group_bans WHERE user_id = ?1",
+		"DELETE FROM group_invite_link_uses WHERE user_id = ?1",
+		"DELETE FROM group_join_answers WHERE user_id = ?1",
+		"DELETE FROM followers WHERE user_id = ?1 OR follower_id = ?1",
+		"DELETE FROM almost_private WHERE user_id = ?1",
+		"DELETE FROM private_post_access WHERE user_id = ?1",
+		"DELETE FROM notifications WHERE user_id = ?1 OR sender = ?1",
+		"DELETE FROM user_events WHERE user_id = ?1",
+		"DELETE FROM user_event_seq WHERE user_id = ?1",
+		"DELETE FROM scheduled_job_recipients WHERE user_id = ?1",
+		"DELETE FROM calendar_tokens WHERE user_id = ?1",
+		"DELETE FROM sessions WHERE user_id = ?1",
+		"DELETE FROM users WHERE user_id = ?1",
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement, userID); err != nil {
+			return images, err
+		}
+	}
+	return images, tx.Commit()
+}
@@ -0,0 +1,100 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"social-network/pkg/models"
+)
+
+func TestDeleteUserHandsOverGroups(t *testing.T) {
+	for _, failing := range []bool{false, true} {
+		db := newTestDB(t)
+		users, groups, events := &UserRepository{DB: db}, &GroupRepository{DB: db}, &EventRepository{DB: db}
+		for _, id := range []string{"alice", "bob"} {
+			if err := users.Add(models.User{ID: id, Email: id + "@test.com", FirstName: id, LastName: "Test", Password: "x"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, id := range []string{"shared", "alone"} {
+			if err := groups.New(models.Group{ID: id, Name: id, AdminID: "alice", Privacy: "public"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := groups.SaveMember("bob", "shared"); err != nil {
+			t.Fatal(err)
+		}
+		event := models.Event{ID: "event", GroupID: "shared", AuthorID: "alice", Title: "event", DateTime: time.Now().Add(time.Hour)}
+		if err := events.Save(event); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := events.UpdateResponse("event", "", "bob", "going"); err != nil {
+			t.Fatal(err)
+		}
+		if failing {
+			// one of the last statements fails, nothing may change
+			if _, err := db.Exec("DROP TABLE calendar_tokens"); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		_, err := users.Delete("alice")
+		if failing != (err != nil) {
+			t.Fatalf("failing %v: err = %v", failing, err)
+		}
+		owner, alone := "bob", false
+		if failing {
+			owner, alone = "alice", true
+		}
+		if got, err := groups.GetAdmin("shared"); err != nil || got != owner {
+			t.Errorf("failing %v: owner of shared group = %q, %v", failing, got, err)
+		}
+		if _, err := groups.GetData("alone"); (err == nil) != alone {
+			t.Errorf("failing %v: group without members exists = %v", failing, err == nil)
+		}
+		saved, err := events.GetData("event")
+		if err != nil || saved.AuthorID != owner {
+			t.Errorf("failing %v: event author = %q, %v", failing, saved.AuthorID, err)
+		}
+		if attendees, err := events.GetAttendees("event", ""); err != nil || len(attendees) != 1 {
+			t.Errorf("failing %v: attendees = %v, %v", failing, attendees, err)
+		}
+	}
+}
+
+func TestGetLastSeenBatch(t *testing.T) {
+	repo := &UserRepository{DB: newTestDB(t)}
+	for _, id := range []string{"viewer", "public", "private", "friend", "never"} {
+		if err := repo.Add(models.User{ID: id, Email: id + "@test.com", FirstName: id, LastName: "Test", Password: "x"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, id := range []string{"private", "friend"} {
+		if err := repo.SetStatus(models.User{ID: id, Status: "PRIVATE"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repo.SaveFollower("friend", "viewer"); err != nil {
+		t.Fatal(err)
+	}
+	seen := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	for _, id := range []string{"public", "private", "friend"} {
+		if err := repo.SetLastSeen(id, seen); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := repo.GetLastSeenBatch([]string{"public", "private", "friend", "never", "missing"}, "viewer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"public": seen.Format(time.RFC3339), "friend": seen.Format(time.RFC3339), "never": ""}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for id, lastSeen := range want {
+		if value, ok := got[id]; !ok || value != lastSeen {
+			t.Errorf("%s: got %q, %v, want %q", id, value, ok, lastSeen)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"social-network/pkg/models"
+	"social-network/pkg/utils"
+)
+
+// path prefix of tokenized calendar feeds, /calendar/<token>.ics
+const calendarFeedPath = "/calendar/"
+
+/* ----------------------- single event as .ics file ------------------------ */
+func (handler *Handler) EventCalendar(w http.ResponseWriter, r *http.Request) {
+	w = utils.ConfigHeader(w)
+	userId := r.Context().Value(utils.UserKey).(string)
+	event, err := handler.repos.EventRepo.GetData(r.URL.Query().Get("id"))
+	if err != nil {
+		utils.RespondWithError(w, "Event not found", 200)
+		return
+	}
+	isMember, err := handler.isGroupMember(event.GroupID, userId)
+	if err != nil {
+		utils.RespondWithError(w, "Error checking membership", 200)
+		return
+	}
+	if !isMember {
+		utils.RespondWithError(w, "Not a member of this group", 200)
+		return
+	}
+	utils.RespondWithCalendar(w, "event-"+event.ID+".ics", utils.ICalendar(event.Title, []models.Event{event}))
+}
+
+/* ------------------------- all events of one group ------------------------ */
+func (handler *Handler) GroupCalendar(w http.ResponseWriter, r *http.Request) {
+	w = utils.ConfigHeader(w)
+	userId := r.Context().Value(utils.UserKey).(string)
+	groupId := r.URL.Query().Get("groupId")
+	group, err := handler.repos.GroupRepo.GetData(groupId)
+	if err != nil {
+		utils.RespondWithError(w, "Group not found", 200)
+		return
+	}
+	isMember, err := handler.isGroupMember(groupId, userId)
+	if err != nil {
+		utils.RespondWithError(w, "Error checking membership", 200)
+		return
+	}
+	if !isMember {
+		utils.RespondWithError(w, "Not a member of this group", 200)
+		return
+	}
+	events, err := handler.repos.EventRepo.GetAll(groupId)
+	if err != nil {
+		utils.RespondWithError(w, "Error fetching events", 200)
+		return
+	}
+	utils.RespondWithCalendar(w, "group-"+groupId+".ics", utils.ICalendar(group.Name, events))
+}
+
+/* ------------------------ subscription feed address ----------------------- */
+// GET returns feed path of current user, created on first request
+// POST replaces token, old subscription links stop working
+func (handler *Handler) CalendarToken(w http.ResponseWriter, r *http.Request) {
+	w = utils.ConfigHeader(w)
+	userId := r.Context().Value(utils.UserKey).(string)
+	token, err := handler.repos.UserRepo.GetCalendarToken(userId)
+	if err != nil {
+		utils.RespondWithError(w, "Error on getting data", 200)
+		return
+	}
+	if token == "" || r.Method == "POST" {
+		token = utils.RandomToken()
+		if err := handler.repos.UserRepo.SetCalendarToken(userId, token); err != nil {
+			utils.RespondWithError(w, "Error on saving data", 200)
+			return
+		}
+	}
+	utils.RespondWithSuccess(w, calendarFeedPath+token+".ics", 200)
+}
+
+// subscription feed with events user is going or maybe going to
+// no session needed, token from path identifies user
+func (handler *Handler) CalendarFeed(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, calendarFeedPath), ".ics")
+	if token == "" {
+		http.NotFound(w, r)
+		return
+	}
+	userId, err := handler.repos.UserRepo.FindUserByCalendarToken(token)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	events, err := handler.repos.EventRepo.GetUserCalendar(userId)
+	if err != nil {
+		http.Error(w, "Error fetching events", http.StatusInternalServerError)
+		return
+	}
+	utils.RespondWithCalendar(w, "events.ics", utils.ICalendar("Social network events", events))
+}
+
+// true if user is member or admin of group
+func (handler *Handler) isGroupMember(groupId, userId string) (bool, error) {
+	isAdmin, err := handler.repos.GroupRepo.IsAdmin(groupId, userId)
+	if err != nil || isAdmin {
+		return isAdmin, err
+	}
+	return handler.repos.GroupRepo.IsMember(groupId, userId)
+}
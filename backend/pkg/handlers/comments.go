@@ -1,12 +1,16 @@
 package handlers
 
 import (
+	"encoding/json"
 	"net/http"
 	"social-network/pkg/models"
 	"social-network/pkg/utils"
+	ws "social-network/pkg/wsServer"
+	"sort"
 )
 
-func (handler *Handler) NewComment(w http.ResponseWriter, r *http.Request) {
+// new comment on post or reply to comment (parentid)
+func (handler *Handler) NewComment(wsServer *ws.Server, w http.ResponseWriter, r *http.Request) {
 	w = utils.ConfigHeader(w)
 	if r.Method != "POST" {
 		utils.RespondWithError(w, "Error on form submittion", 200)
@@ -23,9 +27,19 @@ func (handler *Handler) NewComment(w http.ResponseWriter, r *http.Request) {
 	newComment := models.Comment{
 		ID:       utils.UniqueId(),
 		PostID:   r.PostFormValue("postid"),
+		ParentID: r.PostFormValue("parentid"),
 		Content:  r.PostFormValue("body"),
 		AuthorID: userId,
 	}
+	// reply must point to comment of the same post
+	var parent models.Comment
+	if newComment.ParentID != "" {
+		parent, err = handler.repos.CommentRepo.GetData(newComment.ParentID)
+		if err != nil || parent.PostID != newComment.PostID {
+			utils.RespondWithError(w, "Replied comment not found", 200)
+			return
+		}
+	}
 	// save image in filesystem
 	newComment.ImagePath = utils.SaveImage(r)
 	// save comment in database
@@ -34,5 +48,195 @@ func (handler *Handler) NewComment(w http.ResponseWriter, r *http.Request) {
 		utils.RespondWithError(w, "Error on saving data", 200)
 		return
 	}
+	// let author of replied comment know
+	if newComment.ParentID != "" && parent.AuthorID != userId {
+		notification := models.Notification{
+			ID:       utils.UniqueId(),
+			TargetID: parent.AuthorID,
+			Type:     "COMMENT_REPLY",
+			Content:  newComment.ID,
+			Sender:   userId,
+		}
+		if err := handler.repos.NotifRepo.Save(notification); err == nil {
+			wsServer.SendNotification(parent.AuthorID, notification)
+		}
+	}
 	utils.RespondWithSuccess(w, "New comment created", 200)
 }
+
+/* ------------------------------ edit comment ------------------------------ */
+// waits for POST form with id, optional body, optional new image or removeImage=true
+// only author can edit, content changes only when body is sent
+func (handler *Handler) EditComment(w http.ResponseWriter, r *http.Request) {
+	w = utils.ConfigHeader(w)
+	if r.Method != "POST" {
+		utils.RespondWithError(w, "Error on form submittion", 200)
+		return
+	}
+	if err := r.ParseMultipartForm(3145728); err != nil { // 3MB
+		utils.RespondWithError(w, "Error in form validation", 200)
+		return
+	}
+	userId := r.Context().Value(utils.UserKey).(string)
+	comment, err := handler.repos.CommentRepo.GetData(r.PostFormValue("id"))
+	if err != nil {
+		utils.RespondWithError(w, "Comment not found", 200)
+		return
+	}
+	if comment.AuthorID != userId {
+		utils.RespondWithError(w, "Only author can edit the comment", 200)
+		return
+	}
+	if _, ok := r.PostForm["body"]; ok {
+		comment.Content = r.PostFormValue("body")
+	}
+	// replace or remove image
+	oldImage := comment.ImagePath
+	if newImage := utils.SaveImage(r); newImage != "" {
+		comment.ImagePath = newImage
+	} else if r.PostFormValue("removeImage") == "true" {
+		comment.ImagePath = ""
+	}
+	if err := handler.repos.CommentRepo.Update(comment); err != nil {
+		if comment.ImagePath != oldImage {
+			utils.DeleteImage(comment.ImagePath)
+		}
+		utils.RespondWithError(w, "Error on saving data", 200)
+		return
+	}
+	if comment.ImagePath != oldImage {
+		utils.DeleteImage(oldImage)
+	}
+	utils.RespondWithSuccess(w, "Comment updated", 200)
+}
+
+/* ----------------------------- delete comment ----------------------------- */
+// waits for DELETE with commentId in json body
+// author or admin of the group (for comments on group posts) can delete
+func (handler *Handler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	w = utils.ConfigHeader(w)
+	if r.Method != http.MethodDelete {
+		utils.RespondWithError(w, "Method not allowed", 405)
+		return
+	}
+	var request struct {
+		CommentID string `json:"commentId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utils.RespondWithError(w, "Invalid request body", 400)
+		return
+	}
+	userId := r.Context().Value(utils.UserKey).(string)
+	comment, err := handler.repos.CommentRepo.GetData(request.CommentID)
+	if err != nil {
+		utils.RespondWithError(w, "Comment not found", 200)
+		return
+	}
+	if comment.AuthorID != userId {
+		post, err := handler.repos.PostRepo.GetData(comment.PostID)
+		if err != nil {
+			utils.RespondWithError(w, "Error on getting data", 200)
+			return
+		}
+		isAdmin := false
+		if post.GroupID != "" {
+			isAdmin, err = handler.repos.GroupRepo.IsModerator(post.GroupID, userId)
+			if err != nil {
+				utils.RespondWithError(w, "Error on getting data", 200)
+				return
+			}
+		}
+		if !isAdmin {
+			utils.RespondWithError(w, "Only author or group moderator can delete the comment", 200)
+			return
+		}
+	}
+	// replies are removed together with comment, remember their images
+	postComments, err := handler.repos.CommentRepo.Get(comment.PostID)
+	if err != nil {
+		utils.RespondWithError(w, "Error on getting data", 200)
+		return
+	}
+	if err := handler.repos.CommentRepo.Delete(comment.ID); err != nil {
+		utils.RespondWithError(w, "Error on deleting comment", 200)
+		return
+	}
+	utils.DeleteImage(comment.ImagePath)
+	for _, reply := range commentReplies(postComments, comment.ID) {
+		utils.DeleteImage(reply.ImagePath)
+	}
+	utils.RespondWithSuccess(w, "Comment deleted", 200)
+}
+
+/* -------------------------------------------------------------------------- */
+/*                                   helpers                                  */
+/* -------------------------------------------------------------------------- */
+
+// deepest level of nested replies, deeper replies are shown on this level
+const maxCommentDepth = 3
+
+// builds reply tree from flat comment list of one post (newest first)
+// top level stays newest first, replies are chronological
+func buildCommentTree(flat []models.Comment) []models.Comment {
+	exists := make(map[string]bool, len(flat))
+	for _, comment := range flat {
+		exists[comment.ID] = true
+	}
+	children := make(map[string][]models.Comment)
+	var roots []models.Comment
+	for _, comment := range flat {
+		if comment.ParentID == "" || !exists[comment.ParentID] {
+			roots = append(roots, comment)
+			continue
+		}
+		children[comment.ParentID] = append(children[comment.ParentID], comment)
+	}
+
+	var build func(comment models.Comment, depth int) models.Comment
+	build = func(comment models.Comment, depth int) models.Comment {
+		if depth == maxCommentDepth {
+			// last level -> whole subtree as flat chronological list
+			comment.Replies = descendants(children, comment.ID)
+			comment.ReplyCount = len(comment.Replies)
+			return comment
+		}
+		replies := children[comment.ID]
+		comment.Replies = nil
+		comment.ReplyCount = 0
+		for i := len(replies) - 1; i >= 0; i-- {
+			reply := build(replies[i], depth+1)
+			comment.Replies = append(comment.Replies, reply)
+			comment.ReplyCount += 1 + reply.ReplyCount
+		}
+		return comment
+	}
+	for i := range roots {
+		roots[i] = build(roots[i], 1)
+	}
+	return roots
+}
+
+// all replies below comment in chronological order, without nesting
+func descendants(children map[string][]models.Comment, commentID string) []models.Comment {
+	var result []models.Comment
+	replies := children[commentID]
+	for i := len(replies) - 1; i >= 0; i-- {
+		reply := replies[i]
+		reply.Replies = nil
+		result = append(result, reply)
+		result = append(result, descendants(children, reply.ID)...)
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].CreatedAt < result[j].CreatedAt })
+	return result
+}
+
+// replies below comment in flat list of post comments
+func commentReplies(comments []models.Comment, commentID string) []models.Comment {
+	children := make(map[string][]models.Comment)
+	for _, comment := range comments {
+		if comment.ParentID != "" {
+			children[comment.ParentID] = append(children[comment.ParentID], comment)
+		}
+	}
+	return descendants(children, commentID)
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"social-network/pkg/models"
 	"social-network/pkg/utils"
@@ -37,6 +38,20 @@ func (handler *Handler) NewEvent(wsServer *ws.Server, w http.ResponseWriter, r *
 			return
 		}
 	}
+	if event.Capacity < 0 {
+		utils.RespondWithError(w, "Invalid capacity", 200)
+		return
+	}
+	if event.Recurrence != nil {
+		if event.Date == "" {
+			utils.RespondWithError(w, "Recurring event needs a date", 200)
+			return
+		}
+		if err = event.Recurrence.Validate(); err != nil {
+			utils.RespondWithError(w, err.Error(), 200)
+			return
+		}
+	}
 	/* -------------------- check if user is a meber of group ------------------- */
 	var isMember = false
 	isAdmin, err := handler.repos.GroupRepo.IsAdmin(event.GroupID, event.AuthorID)
@@ -55,14 +70,26 @@ func (handler *Handler) NewEvent(wsServer *ws.Server, w http.ResponseWriter, r *
 		utils.RespondWithError(w, "Not a member", 200)
 		return
 	}
+	if err = handler.checkNotMuted(event.GroupID, event.AuthorID); err != nil {
+		utils.RespondWithError(w, err.Error(), 200)
+		return
+	}
 	/* ------------------------- save event in database ------------------------- */
 	if err = handler.repos.EventRepo.Save(event); err != nil {
 		utils.RespondWithError(w, "Internal server error", 200)
 		return
 	}
 	/* ----------------- creator automatically participates ----------------- */
-	// Automatically add creator as "going" to their own event
-	if err = handler.repos.EventRepo.UpdateResponse(event.ID, event.AuthorID, "going"); err != nil {
+	// Automatically add creator as "going" to their own event,
+	// occurrences of recurring events are answered one by one
+	if event.Recurrence == nil {
+		if _, err = handler.repos.EventRepo.UpdateResponse(event.ID, "", event.AuthorID, "going"); err != nil {
+			utils.RespondWithError(w, "Internal server error", 200)
+			return
+		}
+	}
+	/* ------------------------ remind attendees later ------------------------ */
+	if err = handler.scheduleEventReminders(event, time.Time{}); err != nil {
 		utils.RespondWithError(w, "Internal server error", 200)
 		return
 	}
@@ -93,22 +120,18 @@ func (handler *Handler) NewEvent(wsServer *ws.Server, w http.ResponseWriter, r *
 		}
 		
 		// NOTIFY  GROUP MEMBER ABOUT THE NEW EVENT IF ONLINE
-		for client := range wsServer.Clients {
-			if client.ID == members[i].ID && client.ID != event.AuthorID{
-				client.SendNotification(newNotif)
-			}
+		if members[i].ID != event.AuthorID {
+			wsServer.SendNotification(members[i].ID, newNotif)
 		}
 
 	}
 	utils.RespondWithEvents(w, []models.Event{event}, 200)
 }
 
-// requestId: notification.id,
-// eventId: notification.event.id,
-// response: reqResponse,
 // Handles clients reaction to participation in event
-// waits for POST req with eventID as "id" and user status "going" with response YES or NO
-func (handler *Handler) Participate(w http.ResponseWriter, r *http.Request) {
+// waits for POST req with JSON {eventId, occurrence, response} where response is YES or NO,
+// requestId of notification is accepted for older clients, invitation is removed anyway
+func (handler *Handler) Participate(wsServer *ws.Server, w http.ResponseWriter, r *http.Request) {
 	w = utils.ConfigHeader(w)
 	if r.Method != "POST" {
 		utils.RespondWithError(w, "Error on form submittion", 200)
@@ -116,9 +139,10 @@ func (handler *Handler) Participate(w http.ResponseWriter, r *http.Request) {
 	}
 	/* --------------------------- read incoming data --------------------------- */
 	type Response struct {
-		EventID   string `json:"eventId"`
-		RequestID string `json:"requestId"` //notif id
-		Response  string `json:"response"` //YES || NO
+		EventID    string `json:"eventId"`
+		RequestID  string `json:"requestId"` //notif id
+		Occurrence string `json:"occurrence"`
+		Response   string `json:"response"` //YES || NO
 	}
 	var response Response
 	err := json.NewDecoder(r.Body).Decode(&response)
@@ -134,44 +158,23 @@ func (handler *Handler) Participate(w http.ResponseWriter, r *http.Request) {
 		utils.RespondWithError(w, "Provided incomplete data", 200)
 		return
 	}
-
-	/* ------------------- check if response alredy registerd ------------------- */
-	isParticipating, err := handler.repos.EventRepo.IsParticipating(response.EventID, userId)
-	if err != nil {
-		utils.RespondWithError(w, "Internal server error", 200)
-		return
+	answer := rsvp{EventID: response.EventID, Occurrence: response.Occurrence}
+	switch strings.ToUpper(response.Response) {
+	case "YES":
+		answer.Response = "going"
+	case "NO":
+		answer.Response = "not_going"
 	}
 	/* ----------------------------- handle response ---------------------------- */
-	if response.Response == "YES" && !isParticipating {
-		if err = handler.repos.EventRepo.AddParticipant(response.EventID, userId); err != nil {
-			utils.RespondWithError(w, "Internal server error", 200)
-			return
-		}
-	} else if strings.ToUpper(response.Response) == "NO" && isParticipating {
-		if err = handler.repos.EventRepo.RemoveParticipant(response.EventID, userId); err != nil {
-			utils.RespondWithError(w, "Internal server error", 200)
-			return
-		}
-	}
-	/* --------------------------- remove notificaton -------------------------- */
-	if len(response.RequestID) !=0 {//participation activated form notification
-		if err = handler.repos.NotifRepo.Delete(response.RequestID); err != nil {
-			utils.RespondWithError(w, "Internal server error", 200)
-			return
-		}
-	}else{ //participation activated without noification
-		// delete notification if exists
-		notif := models.Notification{Type: "EVENT", TargetID: userId, Content:response.EventID}
-		if err = handler.repos.NotifRepo.DeleteByType(notif); err!=nil{
-			utils.RespondWithError(w, "Internal server error", 200)
-			return
-		}
-
+	updated, err := handler.respondToEvent(wsServer, userId, answer)
+	if err != nil {
+		utils.RespondWithError(w, err.Error(), 200)
+		return
 	}
-	utils.RespondWithSuccess(w, "Data saved successfully", 200)
+	utils.RespondWithEventResponses(w, *updated, 200)
 }
 
-func (handler *Handler) UpdateEventResponse(w http.ResponseWriter, r *http.Request) {
+func (handler *Handler) UpdateEventResponse(wsServer *ws.Server, w http.ResponseWriter, r *http.Request) {
 	w = utils.ConfigHeader(w)
 	if r.Method != "POST" {
 		utils.RespondWithError(w, "Error on form submission", 200)
@@ -179,8 +182,9 @@ func (handler *Handler) UpdateEventResponse(w http.ResponseWriter, r *http.Reque
 	}
 
 	type ResponseRequest struct {
-		EventID  string `json:"eventId"`
-		Response string `json:"response"` // "going", "not_going", "maybe"
+		EventID    string `json:"eventId"`
+		Occurrence string `json:"occurrence"` // start of occurrence, required for recurring events
+		Response   string `json:"response"`   // "going", "not_going", "maybe"
 	}
 
 	var req ResponseRequest
@@ -192,44 +196,14 @@ func (handler *Handler) UpdateEventResponse(w http.ResponseWriter, r *http.Reque
 
 	userID := r.Context().Value(utils.UserKey).(string)
 
-	// Validate response
-	if req.Response != "going" && req.Response != "not_going" && req.Response != "maybe" {
-		utils.RespondWithError(w, "Invalid response type", 200)
-		return
-	}
-
-	// Check if user is member of the group
-	event, err := handler.repos.EventRepo.GetData(req.EventID)
-	if err != nil {
-		utils.RespondWithError(w, "Event not found", 200)
-		return
-	}
-
-	isMember, err := handler.repos.GroupRepo.IsMember(event.GroupID, userID)
-	if err != nil {
-		utils.RespondWithError(w, "Error checking membership", 200)
-		return
-	}
-
-	isAdmin, err := handler.repos.GroupRepo.IsAdmin(event.GroupID, userID)
-	if err != nil {
-		utils.RespondWithError(w, "Error checking admin status", 200)
-		return
-	}
-
-	if !isMember && !isAdmin {
-		utils.RespondWithError(w, "Not a member of this group", 200)
-		return
-	}
-
-	// Update response
-	err = handler.repos.EventRepo.UpdateResponse(req.EventID, userID, req.Response)
+	// Update response, full event puts user on waitlist
+	updated, err := handler.respondToEvent(wsServer, userID, rsvp{EventID: req.EventID, Occurrence: req.Occurrence, Response: req.Response})
 	if err != nil {
-		utils.RespondWithError(w, "Error updating response", 200)
+		utils.RespondWithError(w, err.Error(), 200)
 		return
 	}
 
-	utils.RespondWithSuccess(w, "Response updated successfully", 200)
+	utils.RespondWithEventResponses(w, *updated, 200)
 }
 
 func (handler *Handler) GetGroupEvents(w http.ResponseWriter, r *http.Request) {
@@ -265,8 +239,14 @@ func (handler *Handler) GetGroupEvents(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	from, to, err := eventWindow(r)
+	if err != nil {
+		utils.RespondWithError(w, err.Error(), 200)
+		return
+	}
+
 	// Get events with responses
-	events, err := handler.repos.EventRepo.GetGroupEventsWithResponses(groupID, userID)
+	events, err := handler.repos.EventRepo.GetGroupEventsWithResponses(groupID, userID, from, to)
 	if err != nil {
 		utils.RespondWithError(w, "Error fetching events", 200)
 		return
@@ -275,3 +255,194 @@ func (handler *Handler) GetGroupEvents(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(events)
 }
+
+// reads optional ?from=&to= (RFC3339) date window of event listing
+// missing from starts RecurrenceHorizon before now (or before to, if that is earlier),
+// so old open ended series are listed around now and not from their first occurrence
+func eventWindow(r *http.Request) (from, to time.Time, err error) {
+	query := r.URL.Query()
+	if value := query.Get("from"); value != "" {
+		if from, err = time.Parse(time.RFC3339, value); err != nil {
+			return from, to, errors.New("Invalid from date")
+		}
+	}
+	if value := query.Get("to"); value != "" {
+		if to, err = time.Parse(time.RFC3339, value); err != nil {
+			return from, to, errors.New("Invalid to date")
+		}
+	}
+	if query.Get("from") == "" {
+		from = time.Now()
+		if !to.IsZero() && to.Before(from) {
+			from = to
+		}
+		from = from.Add(-models.RecurrenceHorizon)
+	}
+	if !to.IsZero() && !from.Before(to) {
+		return from, to, errors.New("Invalid date window")
+	}
+	return from, to, nil
+}
+
+// edits title, content or date of event, only provided fields change
+// waits for POST with JSON {id, title, content, date}
+func (handler *Handler) EditEvent(wsServer *ws.Server, w http.ResponseWriter, r *http.Request) {
+	w = utils.ConfigHeader(w)
+	if r.Method != "POST" {
+		utils.RespondWithError(w, "Error on form submittion", 200)
+		return
+	}
+	var req struct {
+		ID      string  `json:"id"`
+		Title   *string `json:"title"`
+		Content *string `json:"content"`
+		Date    *string `json:"date"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, "Error on form submittion", 200)
+		return
+	}
+	userId := r.Context().Value(utils.UserKey).(string)
+	event, ok := handler.manageableEvent(w, req.ID, userId)
+	if !ok {
+		return
+	}
+	/* ------------------------- apply changed fields ------------------------- */
+	var changed []string
+	dateChanged := false
+	if req.Title != nil && *req.Title != event.Title {
+		event.Title = *req.Title
+		changed = append(changed, "title")
+	}
+	if req.Content != nil && *req.Content != event.Content {
+		event.Content = *req.Content
+		changed = append(changed, "description")
+	}
+	if req.Date != nil {
+		date, err := time.Parse(time.RFC3339, *req.Date)
+		if err != nil {
+			utils.RespondWithError(w, "Invalid date format", 200)
+			return
+		}
+		if !date.Equal(event.DateTime) {
+			// whole series moves, skipped occurrences with it
+			if event.Recurrence != nil {
+				event.Recurrence = event.Recurrence.Shift(date.Sub(event.DateTime))
+			}
+			event.DateTime = date
+			event.Date = *req.Date
+			changed = append(changed, "date")
+			dateChanged = true
+		}
+	}
+	if len(changed) == 0 {
+		utils.RespondWithEvents(w, []models.Event{event}, 200)
+		return
+	}
+	if err := handler.repos.EventRepo.Update(event); err != nil {
+		utils.RespondWithError(w, "Error on saving data", 200)
+		return
+	}
+	/* ------------------------ move pending reminders ------------------------ */
+	if dateChanged {
+		if err := handler.cancelEventReminders(event.ID); err != nil {
+			utils.RespondWithError(w, "Internal server error", 200)
+			return
+		}
+		if err := handler.scheduleEventReminders(event, time.Time{}); err != nil {
+			utils.RespondWithError(w, "Internal server error", 200)
+			return
+		}
+	}
+	if err := handler.notifyRespondents(wsServer, event, "EVENT_UPDATED", utils.EventChange(event.ID, changed), userId); err != nil {
+		utils.RespondWithError(w, "Internal server error", 200)
+		return
+	}
+	utils.RespondWithEvents(w, []models.Event{event}, 200)
+}
+
+// cancels event, it stays in group history but can't be answered
+// waits for POST with JSON {eventId}
+func (handler *Handler) CancelEvent(wsServer *ws.Server, w http.ResponseWriter, r *http.Request) {
+	w = utils.ConfigHeader(w)
+	if r.Method != "POST" {
+		utils.RespondWithError(w, "Error on form submittion", 200)
+		return
+	}
+	var req struct {
+		EventID string `json:"eventId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, "Error on form submittion", 200)
+		return
+	}
+	userId := r.Context().Value(utils.UserKey).(string)
+	event, ok := handler.manageableEvent(w, req.EventID, userId)
+	if !ok {
+		return
+	}
+	if err := handler.repos.EventRepo.Cancel(event.ID); err != nil {
+		utils.RespondWithError(w, "Error on saving data", 200)
+		return
+	}
+	if err := handler.cancelEventReminders(event.ID); err != nil {
+		utils.RespondWithError(w, "Internal server error", 200)
+		return
+	}
+	if err := handler.notifyRespondents(wsServer, event, "EVENT_CANCELLED", event.ID, userId); err != nil {
+		utils.RespondWithError(w, "Internal server error", 200)
+		return
+	}
+	utils.RespondWithSuccess(w, "Event cancelled", 200)
+}
+
+// gets event that user may edit or cancel (author or group admin),
+// responds with error and returns false otherwise
+func (handler *Handler) manageableEvent(w http.ResponseWriter, eventId, userId string) (models.Event, bool) {
+	event, err := handler.repos.EventRepo.GetData(eventId)
+	if err != nil {
+		utils.RespondWithError(w, "Event not found", 200)
+		return event, false
+	}
+	if event.CancelledAt != nil {
+		utils.RespondWithError(w, "Event is cancelled", 200)
+		return event, false
+	}
+	if event.AuthorID != userId {
+		isAdmin, err := handler.repos.GroupRepo.IsAdmin(event.GroupID, userId)
+		if err != nil {
+			utils.RespondWithError(w, "Error on reading role", 200)
+			return event, false
+		}
+		if !isAdmin {
+			utils.RespondWithError(w, "Only author or group admin can change the event", 200)
+			return event, false
+		}
+	}
+	return event, true
+}
+
+// saves and sends notification to everyone who answered event, except sender
+func (handler *Handler) notifyRespondents(wsServer *ws.Server, event models.Event, notifType, content, senderId string) error {
+	respondents, err := handler.repos.EventRepo.GetRespondents(event.ID)
+	if err != nil {
+		return err
+	}
+	for _, userId := range respondents {
+		if userId == senderId {
+			continue
+		}
+		notif := models.Notification{
+			ID:       utils.UniqueId(),
+			TargetID: userId,
+			Type:     notifType,
+			Content:  content,
+			Sender:   senderId,
+		}
+		if err = handler.repos.NotifRepo.Save(notif); err != nil {
+			return err
+		}
+		wsServer.SendNotification(userId, notif)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"social-network/pkg/models"
+)
+
+func TestEventWindow(t *testing.T) {
+	now := time.Now()
+	past := now.AddDate(-1, 0, 0).UTC().Truncate(time.Second)
+	later := now.AddDate(0, 1, 0).UTC().Truncate(time.Second)
+	tests := []struct {
+		query    string
+		wantFrom time.Time // zero if window is invalid
+		wantTo   time.Time
+	}{
+		{"", now.Add(-models.RecurrenceHorizon), time.Time{}},
+		{"to=" + later.Format(time.RFC3339), now.Add(-models.RecurrenceHorizon), later},
+		// window ending in the past keeps its length
+		{"to=" + past.Format(time.RFC3339), past.Add(-models.RecurrenceHorizon), past},
+		{"from=" + past.Format(time.RFC3339), past, time.Time{}},
+		{"from=" + past.Format(time.RFC3339) + "&to=" + later.Format(time.RFC3339), past, later},
+		{"from=" + later.Format(time.RFC3339) + "&to=" + past.Format(time.RFC3339), time.Time{}, time.Time{}},
+		{"from=yesterday", time.Time{}, time.Time{}},
+	}
+	for _, test := range tests {
+		from, to, err := eventWindow(httptest.NewRequest("GET", "/groupEvents?"+test.query, nil))
+		if (err != nil) != test.wantFrom.IsZero() {
+			t.Errorf("%q: err = %v", test.query, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		// default from is taken from the clock, allow for time the call took
+		if diff := from.Sub(test.wantFrom); diff < 0 || diff > time.Minute || !to.Equal(test.wantTo) {
+			t.Errorf("%q: got %v - %v, want %v - %v", test.query, from, to, test.wantFrom, test.wantTo)
+		}
+	}
+}
+
+// daily series started long ago is listed around now, not only its first occurrences
+func TestOldSeriesListedAroundNow(t *testing.T) {
+	handler, _, _ := newTestHandler(t)
+	addTestUsers(t, handler, "alice")
+	addTestGroup(t, handler, "group", "alice")
+
+	now := time.Now().UTC()
+	start := now.AddDate(0, 0, -2*models.MaxOccurrences).Truncate(time.Hour)
+	daily := models.Event{ID: "daily", GroupID: "group", AuthorID: "alice", Title: "daily", DateTime: start,
+		Recurrence: &models.Recurrence{Freq: "DAILY", Interval: 1}}
+	if err := handler.repos.EventRepo.Save(daily); err != nil {
+		t.Fatal(err)
+	}
+
+	from, to, err := eventWindow(httptest.NewRequest("GET", "/groupEvents", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, err := handler.repos.EventRepo.GetGroupEventsWithResponses("group", "alice", from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) == 0 || len(events) >= models.MaxOccurrences {
+		t.Fatalf("got %d occurrences", len(events))
+	}
+	// latest first
+	if latest := events[0].DateTime; !latest.After(now) {
+		t.Errorf("latest occurrence %v is not upcoming", latest)
+	}
+	if earliest := events[len(events)-1].DateTime; earliest.Before(from) {
+		t.Errorf("earliest occurrence %v is before window start %v", earliest, from)
+	}
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"social-network/pkg/models"
+	"social-network/pkg/utils"
+	ws "social-network/pkg/wsServer"
+)
+
+// member mutes or unmutes group chat for themselves
+// muted chat doesn't push new messages or count them as unread, unless member is @mentioned
+// waits for POST with JSON {groupId, muted}
+func (handler *Handler) MuteGroupChat(w http.ResponseWriter, r *http.Request) {
+	w = utils.ConfigHeader(w)
+	if r.Method != "POST" {
+		utils.RespondWithError(w, "Method not allowed", 405)
+		return
+	}
+	var req struct {
+		GroupID string `json:"groupId"`
+		Muted   bool   `json:"muted"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, "Invalid request body", 400)
+		return
+	}
+	userId := r.Context().Value(utils.UserKey).(string)
+	isMember, err := handler.isGroupMember(req.GroupID, userId)
+	if err != nil {
+		utils.RespondWithError(w, "Error on getting data", 200)
+		return
+	}
+	if !isMember {
+		utils.RespondWithError(w, "Only group members can mute group chat", 200)
+		return
+	}
+	if !req.Muted {
+		if err = handler.repos.MsgRepo.UnmuteGroupChat(req.GroupID, userId); err != nil {
+			utils.RespondWithError(w, "Error on saving data", 200)
+			return
+		}
+		utils.RespondWithSuccess(w, "Group chat unmuted", 200)
+		return
+	}
+	if err = handler.repos.MsgRepo.MuteGroupChat(req.GroupID, userId); err != nil {
+		utils.RespondWithError(w, "Error on saving data", 200)
+		return
+	}
+	utils.RespondWithSuccess(w, "Group chat muted", 200)
+}
+
+/* -------------------------------------------------------------------------- */
+/*                                   helpers                                  */
+/* -------------------------------------------------------------------------- */
+
+// loads message quoted by reply, it must belong to the same chat as msg
+func (handler *Handler) quotedMessage(msg models.ChatMessage) (*models.ChatMessage, error) {
+	quoted, err := handler.repos.MsgRepo.GetData(msg.ReplyToID)
+	if err != nil {
+		return nil, errors.New("Replied message not found")
+	}
+	sameChat := false
+	switch msg.Type {
+	case "GROUP":
+		sameChat = quoted.Type == "GROUP" && quoted.ReceiverId == msg.ReceiverId
+	case "PERSON":
+		sameChat = quoted.Type == "PERSON" &&
+			((quoted.SenderId == msg.SenderId && quoted.ReceiverId == msg.ReceiverId) ||
+				(quoted.SenderId == msg.ReceiverId && quoted.ReceiverId == msg.SenderId))
+	}
+	if !sameChat || quoted.Deleted {
+		return nil, errors.New("Replied message not found")
+	}
+	quoted.ReplyToID = ""
+	quoted.Sender, _ = handler.repos.UserRepo.GetDataMin(quoted.SenderId)
+	return &quoted, nil
+}
+
+// ids of members whose @nickname appears in content, sender can't mention themselves
+func mentionedMembers(content string, members []models.User, senderId string) map[string]bool {
+	mentioned := make(map[string]bool)
+	content = strings.ToLower(content)
+	for _, member := range members {
+		if member.ID == senderId || member.Nickname == "" {
+			continue
+		}
+		tag := "@" + strings.ToLower(member.Nickname)
+		for rest := content; ; {
+			i := strings.Index(rest, tag)
+			if i < 0 {
+				break
+			}
+			rest = rest[i+len(tag):]
+			// @ann must not match @anna
+			if next, _ := utf8.DecodeRuneInString(rest); rest == "" || !(unicode.IsLetter(next) || unicode.IsDigit(next) || next == '_') {
+				mentioned[member.ID] = true
+				break
+			}
+		}
+	}
+	return mentioned
+}
+
+// notifies mentioned member about group chat message, muted chat doesn't stop it
+func (handler *Handler) notifyMention(wsServer *ws.Server, msg models.ChatMessage, memberId string) {
+	notif := models.Notification{
+		ID:       utils.UniqueId(),
+		TargetID: memberId,
+		Type:     "MENTION",
+		Content:  msg.ID,
+		Sender:   msg.SenderId,
+	}
+	if err := handler.repos.NotifRepo.Save(notif); err != nil {
+		log.Println("Error on saving mention notification:", err)
+		return
+	}
+	wsServer.SendNotification(memberId, notif)
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"testing"
+
+	"social-network/pkg/models"
+)
+
+func TestMentionedMembers(t *testing.T) {
+	members := []models.User{
+		{ID: "1", Nickname: "ann"},
+		{ID: "2", Nickname: "anna"},
+		{ID: "3", Nickname: "Bob_K"},
+		{ID: "4"}, // without nickname
+		{ID: "5", Nickname: "zoë"},
+	}
+	tests := []struct {
+		content string
+		want    []string
+	}{
+		{"hello all", nil},
+		{"@ann look", []string{"1"}},
+		{"@anna look", []string{"2"}},
+		{"@ann and @anna", []string{"1", "2"}},
+		{"@annabel is not a member", nil},
+		{"@anna_ is not anna", nil},
+		{"@ANN, please", []string{"1"}},
+		{"hi @bob_k!", []string{"3"}},
+		{"@zoë.", []string{"5"}},
+		{"@me sender can't mention themselves", nil},
+		{"@", nil},
+	}
+	for _, test := range tests {
+		got := mentionedMembers(test.content, append(members, models.User{ID: "me", Nickname: "me"}), "me")
+		if len(got) != len(test.want) {
+			t.Errorf("%q: got %v, want %v", test.content, got, test.want)
+			continue
+		}
+		for _, id := range test.want {
+			if !got[id] {
+				t.Errorf("%q: got %v, want %v", test.content, got, test.want)
+				break
+			}
+		}
+	}
+}
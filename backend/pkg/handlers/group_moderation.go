@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"social-network/pkg/models"
+	"social-network/pkg/utils"
+)
+
+// longest mute moderators can give
+const maxMuteMinutes = 30 * 24 * 60
+
+// owner or moderator bans user from group, member is removed
+// banned user can't request to join, join public group or be invited
+// waits for POST with JSON {groupId, userId, reason}
+func (handler *Handler) BanGroupMember(w http.ResponseWriter, r *http.Request) {
+	w = utils.ConfigHeader(w)
+	req, userId, ok := handler.readModerationRequest(w, r, false)
+	if !ok {
+		return
+	}
+	if _, err := handler.repos.UserRepo.GetDataMin(req.UserID); err != nil {
+		utils.RespondWithError(w, "User not found", 200)
+		return
+	}
+	if err := handler.repos.GroupRepo.Ban(req.GroupID, req.UserID, userId); err != nil {
+		utils.RespondWithError(w, "Error on banning member", 200)
+		return
+	}
+	handler.logModeration(req, userId, models.ModerationBan, "")
+	utils.RespondWithSuccess(w, "Member banned", 200)
+}
+
+// waits for POST with JSON {groupId, userId, reason}
+func (handler *Handler) UnbanGroupMember(w http.ResponseWriter, r *http.Request) {
+	w = utils.ConfigHeader(w)
+	req, userId, ok := handler.readModerationRequest(w, r, false)
+	if !ok {
+		return
+	}
+	isBanned, err := handler.repos.GroupRepo.IsBanned(req.GroupID, req.UserID)
+	if err != nil {
+		utils.RespondWithError(w, "Error on getting data", 200)
+		return
+	}
+	if !isBanned {
+		utils.RespondWithError(w, "User is not banned", 200)
+		return
+	}
+	if err = handler.repos.GroupRepo.Unban(req.GroupID, req.UserID); err != nil {
+		utils.RespondWithError(w, "Error on saving data", 200)
+		return
+	}
+	handler.logModeration(req, userId, models.ModerationUnban, "")
+	utils.RespondWithSuccess(w, "Member unbanned", 200)
+}
+
+// owner or moderator stops member from posting, chatting and creating events for some minutes
+// waits for POST with JSON {groupId, userId, minutes, reason}
+func (handler *Handler) MuteGroupMember(w http.ResponseWriter, r *http.Request) {
+	w = utils.ConfigHeader(w)
+	req, userId, ok := handler.readModerationRequest(w, r, true)
+	if !ok {
+		return
+	}
+	if req.Minutes < 1 || req.Minutes > maxMuteMinutes {
+		utils.RespondWithError(w, "Mute must last from 1 minute to 30 days", 200)
+		return
+	}
+	until := time.Now().Add(time.Duration(req.Minutes) * time.Minute).UTC()
+	if err := handler.repos.GroupRepo.Mute(req.GroupID, req.UserID, until); err != nil {
+		utils.RespondWithError(w, "Error on saving data", 200)
+		return
+	}
+	handler.logModeration(req, userId, models.ModerationMute, until.Format(time.RFC3339))
+	utils.RespondWithSuccess(w, "Member muted until "+until.Format(time.RFC3339), 200)
+}
+
+// waits for POST with JSON {groupId, userId, reason}
+func (handler *Handler) UnmuteGroupMember(w http.ResponseWriter, r *http.Request) {
+	w = utils.ConfigHeader(w)
+	req, userId, ok := handler.readModerationRequest(w, r, true)
+	if !ok {
+		return
+	}
+	if err := handler.repos.GroupRepo.Unmute(req.GroupID, req.UserID); err != nil {
+		utils.RespondWithError(w, "Error on saving data", 200)
+		return
+	}
+	handler.logModeration(req, userId, models.ModerationUnmute, "")
+	utils.RespondWithSuccess(w, "Member unmuted", 200)
+}
+
+// audit trail of kicks, bans and mutes, newest first, only for owner and moderators
+// waits for GET with ?groupId= and optional cursor, limit
+func (handler *Handler) GroupModerationLog(w http.ResponseWriter, r *http.Request) {
+	w = utils.ConfigHeader(w)
+	userId := r.Context().Value(utils.UserKey).(string)
+	groupId := r.URL.Query().Get("groupId")
+	if groupId == "" {
+		utils.RespondWithError(w, "Error on getting data", 200)
+		return
+	}
+	isModerator, err := handler.repos.GroupRepo.IsModerator(groupId, userId)
+	if err != nil {
+		utils.RespondWithError(w, "Error on getting data", 200)
+		return
+	}
+	if !isModerator {
+		utils.RespondWithError(w, "Only group owner or moderators can see moderation log", 200)
+		return
+	}
+	page, err := utils.ParsePage(r)
+	if err != nil {
+		utils.RespondWithError(w, err.Error(), 200)
+		return
+	}
+	actions, next, err := handler.repos.GroupRepo.GetModerationLog(groupId, page)
+	if err != nil {
+		utils.RespondWithError(w, "Error on getting data", 200)
+		return
+	}
+	loader := handler.newUserLoader()
+	var userIDs []string
+	for _, action := range actions {
+		userIDs = append(userIDs, action.ActorID, action.TargetID)
+	}
+	loader.Load(userIDs...)
+	for i := range actions {
+		actions[i].Actor = loader.Get(actions[i].ActorID)
+		actions[i].Target = loader.Get(actions[i].TargetID)
+	}
+	utils.RespondWithModerationLog(w, actions, utils.EncodeCursor(next), 200)
+}
+
+/* -------------------------------------------------------------------------- */
+/*                                   helpers                                  */
+/* -------------------------------------------------------------------------- */
+
+// decodes POST body of moderation request and checks if current user may act on target
+// responds with error and returns false otherwise
+func (handler *Handler) readModerationRequest(w http.ResponseWriter, r *http.Request, mustBeMember bool) (memberRequest, string, bool) {
+	var req memberRequest
+	if r.Method != "POST" {
+		utils.RespondWithError(w, "Method not allowed", 405)
+		return req, "", false
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, "Invalid request body", 400)
+		return req, "", false
+	}
+	userId := r.Context().Value(utils.UserKey).(string)
+	return req, userId, handler.canManageMember(w, req.GroupID, userId, req.UserID, mustBeMember)
+}
+
+// saves action in group audit trail, failure doesn't undo the action
+func (handler *Handler) logModeration(req memberRequest, actorId, action, expiresAt string) {
+	err := handler.repos.GroupRepo.SaveModerationAction(models.ModerationAction{
+		ID:        utils.UniqueId(),
+		GroupID:   req.GroupID,
+		ActorID:   actorId,
+		TargetID:  req.UserID,
+		Action:    action,
+		Reason:    req.Reason,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		log.Println("Error on saving moderation action:", err)
+	}
+}
+
+// error for client if user is muted in group, nil otherwise
+func (handler *Handler) checkNotMuted(groupId, userId string) error {
+	until, err := handler.repos.GroupRepo.GetMutedUntil(groupId, userId)
+	if err != nil {
+		return errors.New("Error on checking mute")
+	}
+	if !until.IsZero() {
+		return errors.New("You are muted in this group until " + until.Format(time.RFC3339))
+	}
+	return nil
+}
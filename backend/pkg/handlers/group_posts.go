@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"social-network/pkg/models"
+	"social-network/pkg/utils"
+	ws "social-network/pkg/wsServer"
+)
+
+// most posts group can have pinned at once
+const maxPinnedPosts = 3
+
+// owner or moderator pins group post on top of group feed or unpins it
+// waits for POST with JSON {postId, pinned}
+func (handler *Handler) PinPost(w http.ResponseWriter, r *http.Request) {
+	w = utils.ConfigHeader(w)
+	if r.Method != "POST" {
+		utils.RespondWithError(w, "Method not allowed", 405)
+		return
+	}
+	var req struct {
+		PostID string `json:"postId"`
+		Pinned bool   `json:"pinned"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, "Invalid request body", 400)
+		return
+	}
+	userId := r.Context().Value(utils.UserKey).(string)
+	post, ok := handler.moderatedGroupPost(w, req.PostID, userId)
+	if !ok {
+		return
+	}
+	if !req.Pinned {
+		if err := handler.repos.PostRepo.Unpin(post.ID); err != nil {
+			utils.RespondWithError(w, "Error on saving data", 200)
+			return
+		}
+		utils.RespondWithSuccess(w, "Post unpinned", 200)
+		return
+	}
+	if post.PinnedAt != nil {
+		utils.RespondWithError(w, "Post is already pinned", 200)
+		return
+	}
+	pinned, err := handler.repos.PostRepo.Pin(post.ID, maxPinnedPosts)
+	if err != nil {
+		utils.RespondWithError(w, "Error on saving data", 200)
+		return
+	}
+	if !pinned {
+		utils.RespondWithError(w, "Group can have at most "+strconv.Itoa(maxPinnedPosts)+" pinned posts", 200)
+		return
+	}
+	utils.RespondWithSuccess(w, "Post pinned", 200)
+}
+
+// owner or moderator marks group post as announcement, all other members get notification
+// waits for POST with JSON {postId}
+func (handler *Handler) AnnouncePost(wsServer *ws.Server, w http.ResponseWriter, r *http.Request) {
+	w = utils.ConfigHeader(w)
+	if r.Method != "POST" {
+		utils.RespondWithError(w, "Method not allowed", 405)
+		return
+	}
+	var req struct {
+		PostID string `json:"postId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, "Invalid request body", 400)
+		return
+	}
+	userId := r.Context().Value(utils.UserKey).(string)
+	post, ok := handler.moderatedGroupPost(w, req.PostID, userId)
+	if !ok {
+		return
+	}
+	announced, err := handler.repos.PostRepo.Announce(post.ID)
+	if err != nil {
+		utils.RespondWithError(w, "Error on saving data", 200)
+		return
+	}
+	if !announced {
+		utils.RespondWithError(w, "Post is already an announcement", 200)
+		return
+	}
+	/* -------------------------- notify group members -------------------------- */
+	members, err := handler.repos.GroupRepo.GetMembers(post.GroupID)
+	if err != nil {
+		utils.RespondWithError(w, "Error on getting group members", 200)
+		return
+	}
+	notified := 0
+	for _, member := range members {
+		if member.ID == userId {
+			continue
+		}
+		notification := models.Notification{
+			ID:       utils.UniqueId(),
+			TargetID: member.ID,
+			Type:     "GROUP_ANNOUNCEMENT",
+			Content:  post.ID,
+			Sender:   userId,
+		}
+		if err := handler.repos.NotifRepo.Save(notification); err != nil {
+			utils.RespondWithError(w, "Error on saving notification", 200)
+			return
+		}
+		wsServer.SendNotification(member.ID, notification)
+		notified++
+	}
+	utils.RespondWithSuccess(w, "Announcement sent to "+strconv.Itoa(notified)+" members", 200)
+}
+
+// returns group post if user is owner or moderator of its group, responds with error otherwise
+func (handler *Handler) moderatedGroupPost(w http.ResponseWriter, postId, userId string) (models.Post, bool) {
+	post, err := handler.repos.PostRepo.GetData(postId)
+	if err != nil {
+		utils.RespondWithError(w, "Post not found", 200)
+		return post, false
+	}
+	if post.GroupID == "" {
+		utils.RespondWithError(w, "Only group posts can be pinned or announced", 200)
+		return post, false
+	}
+	isModerator, err := handler.repos.GroupRepo.IsModerator(post.GroupID, userId)
+	if err != nil {
+		utils.RespondWithError(w, "Error on getting data", 200)
+		return post, false
+	}
+	if !isModerator {
+		utils.RespondWithError(w, "Only group owner or moderators can pin or announce posts", 200)
+		return post, false
+	}
+	return post, true
+}
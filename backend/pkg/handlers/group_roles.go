@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"social-network/pkg/models"
+	"social-network/pkg/utils"
+)
+
+// body of requests that change membership of one user
+type memberRequest struct {
+	GroupID string `json:"groupId"`
+	UserID  string `json:"userId"`
+	Role    string `json:"role"`    // only for SetGroupRole
+	Reason  string `json:"reason"`  // kept in moderation log
+	Minutes int    `json:"minutes"` // length of mute
+}
+
+// owner makes member a moderator or moderator a member again
+// waits for POST with JSON {groupId, userId, role}
+func (handler *Handler) SetGroupRole(w http.ResponseWriter, r *http.Request) {
+	w = utils.ConfigHeader(w)
+	if r.Method != "POST" {
+		utils.RespondWithError(w, "Method not allowed", 405)
+		return
+	}
+	var req memberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, "Invalid request body", 400)
+		return
+	}
+	if req.Role != models.RoleModerator && req.Role != models.RoleMember {
+		utils.RespondWithError(w, "Role must be moderator or member", 200)
+		return
+	}
+	userId := r.Context().Value(utils.UserKey).(string)
+	/* ---------------------------- check both sides ---------------------------- */
+	if role, err := handler.repos.GroupRepo.GetRole(req.GroupID, userId); err != nil || role != models.RoleOwner {
+		utils.RespondWithError(w, "Only group owner can change roles", 200)
+		return
+	}
+	role, err := handler.repos.GroupRepo.GetRole(req.GroupID, req.UserID)
+	if err != nil || role == "" {
+		utils.RespondWithError(w, "User is not a member of this group", 200)
+		return
+	}
+	if role == models.RoleOwner {
+		utils.RespondWithError(w, "Use ownership transfer to change owner", 200)
+		return
+	}
+	if err = handler.repos.GroupRepo.SetRole(req.GroupID, req.UserID, req.Role); err != nil {
+		utils.RespondWithError(w, "Error on saving data", 200)
+		return
+	}
+	utils.RespondWithSuccess(w, "Role changed", 200)
+}
+
+// owner hands group over to another member and becomes moderator
+// waits for POST with JSON {groupId, userId}
+func (handler *Handler) TransferGroupOwnership(w http.ResponseWriter, r *http.Request) {
+	w = utils.ConfigHeader(w)
+	if r.Method != "POST" {
+		utils.RespondWithError(w, "Method not allowed", 405)
+		return
+	}
+	var req memberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, "Invalid request body", 400)
+		return
+	}
+	userId := r.Context().Value(utils.UserKey).(string)
+	if role, err := handler.repos.GroupRepo.GetRole(req.GroupID, userId); err != nil || role != models.RoleOwner {
+		utils.RespondWithError(w, "Only group owner can transfer ownership", 200)
+		return
+	}
+	if req.UserID == userId {
+		utils.RespondWithError(w, "You already own this group", 200)
+		return
+	}
+	if role, err := handler.repos.GroupRepo.GetRole(req.GroupID, req.UserID); err != nil || role == "" {
+		utils.RespondWithError(w, "User is not a member of this group", 200)
+		return
+	}
+	if err := handler.repos.GroupRepo.TransferOwnership(req.GroupID, req.UserID); err != nil {
+		utils.RespondWithError(w, "Error on saving data", 200)
+		return
+	}
+	utils.RespondWithSuccess(w, "Ownership transferred", 200)
+}
+
+// moderator removes member from group, only owner can remove moderators
+// waits for POST with JSON {groupId, userId}
+func (handler *Handler) RemoveGroupMember(w http.ResponseWriter, r *http.Request) {
+	w = utils.ConfigHeader(w)
+	if r.Method != "POST" {
+		utils.RespondWithError(w, "Method not allowed", 405)
+		return
+	}
+	var req memberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, "Invalid request body", 400)
+		return
+	}
+	userId := r.Context().Value(utils.UserKey).(string)
+	if ok := handler.canManageMember(w, req.GroupID, userId, req.UserID, true); !ok {
+		return
+	}
+	if err := handler.repos.GroupRepo.RemoveMember(req.UserID, req.GroupID); err != nil {
+		utils.RespondWithError(w, "Error on removing member", 200)
+		return
+	}
+	handler.logModeration(req, userId, models.ModerationKick, "")
+	utils.RespondWithSuccess(w, "Member removed", 200)
+}
+
+/* -------------------------------------------------------------------------- */
+/*                                   helpers                                  */
+/* -------------------------------------------------------------------------- */
+
+// true if user may act on member: moderators manage members, owner manages everyone
+// non-members can be managed only if mustBeMember is false (bans)
+// responds with error otherwise
+func (handler *Handler) canManageMember(w http.ResponseWriter, groupId, userId, memberId string, mustBeMember bool) bool {
+	role, err := handler.repos.GroupRepo.GetRole(groupId, userId)
+	if err != nil {
+		utils.RespondWithError(w, "Error on reading role", 200)
+		return false
+	}
+	if role != models.RoleOwner && role != models.RoleModerator {
+		utils.RespondWithError(w, "Only group owner or moderators can manage members", 200)
+		return false
+	}
+	memberRole, err := handler.repos.GroupRepo.GetRole(groupId, memberId)
+	if err != nil {
+		utils.RespondWithError(w, "Error on reading role", 200)
+		return false
+	}
+	if memberRole == "" && mustBeMember {
+		utils.RespondWithError(w, "User is not a member of this group", 200)
+		return false
+	}
+	if memberRole == models.RoleOwner || (memberRole == models.RoleModerator && role != models.RoleOwner) || memberId == userId {
+		utils.RespondWithError(w, "Not allowed to manage this member", 200)
+		return false
+	}
+	return true
+}
+
+// passes group of leaving owner to successor, returns false if nobody can take it
+func (handler *Handler) handOverGroup(groupId string) (bool, error) {
+	successor, err := handler.repos.GroupRepo.GetSuccessor(groupId)
+	if err != nil || successor == "" {
+		return false, err
+	}
+	return true, handler.repos.GroupRepo.TransferOwnership(groupId, successor)
+}
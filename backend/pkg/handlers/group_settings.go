@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"social-network/pkg/models"
+	"social-network/pkg/utils"
+	ws "social-network/pkg/wsServer"
+)
+
+// owner changes group name, description, privacy or image
+// waits for multipart POST with groupId and fields to change: name, description, privacy, image
+// removeImage=true drops current image, replaced image is deleted from disk
+// public group turned private keeps members, pending requests stay for moderators and nobody can join directly
+// private group turned public accepts pending requests, as anyone can join it anyway
+func (handler *Handler) EditGroup(wsServer *ws.Server, w http.ResponseWriter, r *http.Request) {
+	w = utils.ConfigHeader(w)
+	if r.Method != "POST" {
+		utils.RespondWithError(w, "Method not allowed", 405)
+		return
+	}
+	if err := r.ParseMultipartForm(10 << 20); err != nil { // 10 MB max
+		utils.RespondWithError(w, "Error parsing form data", 200)
+		return
+	}
+	userId := r.Context().Value(utils.UserKey).(string)
+	groupId := r.FormValue("groupId")
+	isAdmin, err := handler.repos.GroupRepo.IsAdmin(groupId, userId)
+	if err != nil {
+		utils.RespondWithError(w, "Error on getting data", 200)
+		return
+	}
+	if !isAdmin {
+		utils.RespondWithError(w, "Only group owner can edit the group", 200)
+		return
+	}
+	group, err := handler.repos.GroupRepo.GetData(groupId)
+	if err != nil {
+		utils.RespondWithError(w, "Group not found", 200)
+		return
+	}
+	/* ------------------------- apply submitted fields ------------------------- */
+	oldPrivacy, oldImage := group.Privacy, group.Image
+	if values, ok := r.PostForm["name"]; ok {
+		group.Name = strings.TrimSpace(values[0])
+		if group.Name == "" {
+			utils.RespondWithError(w, "Group name can't be empty", 200)
+			return
+		}
+	}
+	if values, ok := r.PostForm["description"]; ok {
+		group.Description = values[0]
+	}
+	if values, ok := r.PostForm["privacy"]; ok {
+		if values[0] != "public" && values[0] != "private" {
+			utils.RespondWithError(w, "Privacy must be public or private", 200)
+			return
+		}
+		group.Privacy = values[0]
+	}
+	if r.FormValue("removeImage") == "true" {
+		group.Image = ""
+	}
+	file, fileHeader, err := r.FormFile("image")
+	if err == nil {
+		defer file.Close()
+		group.Image, err = utils.SaveUploadedFile(file, fileHeader, "imageUpload")
+		if err != nil {
+			utils.RespondWithError(w, "Error saving image", 200)
+			return
+		}
+	}
+	/* ------------------------------- save in db ------------------------------- */
+	if err = handler.repos.GroupRepo.Update(group); err != nil {
+		if group.Image != oldImage {
+			utils.DeleteImage(group.Image)
+		}
+		utils.RespondWithError(w, "Error on saving group", 200)
+		return
+	}
+	if group.Image != oldImage {
+		utils.DeleteImage(oldImage)
+	}
+	if oldPrivacy == "private" && group.Privacy == "public" {
+		if err = handler.acceptPendingRequests(wsServer, groupId); err != nil {
+			utils.RespondWithError(w, "Error on accepting pending requests", 200)
+			return
+		}
+		if group, err = handler.repos.GroupRepo.GetData(groupId); err != nil {
+			utils.RespondWithError(w, "Error on getting data", 200)
+			return
+		}
+	}
+	group.Administrator, group.Member, group.Role = true, true, models.RoleOwner
+	utils.RespondWithGroups(w, []models.Group{group}, 200)
+}
+
+// owner deletes group with its members, posts, events, chat and notifications
+// waits for DELETE with JSON {groupId}
+func (handler *Handler) DeleteGroup(w http.ResponseWriter, r *http.Request) {
+	w = utils.ConfigHeader(w)
+	if r.Method != "DELETE" {
+		utils.RespondWithError(w, "Method not allowed", 405)
+		return
+	}
+	var req struct {
+		GroupID string `json:"groupId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, "Invalid request body", 400)
+		return
+	}
+	userId := r.Context().Value(utils.UserKey).(string)
+	isAdmin, err := handler.repos.GroupRepo.IsAdmin(req.GroupID, userId)
+	if err != nil {
+		utils.RespondWithError(w, "Error on getting data", 200)
+		return
+	}
+	if !isAdmin {
+		utils.RespondWithError(w, "Only group owner can delete the group", 200)
+		return
+	}
+	if err = handler.deleteGroup(req.GroupID); err != nil {
+		utils.RespondWithError(w, "Error on deleting group", 200)
+		return
+	}
+	utils.RespondWithSuccess(w, "Group deleted", 200)
+}
+
+// questions user must answer when requesting to join, auto approval rule only for moderators
+// waits for GET with ?groupId=
+func (handler *Handler) GroupJoinRules(w http.ResponseWriter, r *http.Request) {
+	w = utils.ConfigHeader(w)
+	userId := r.Context().Value(utils.UserKey).(string)
+	groupId := r.URL.Query().Get("groupId")
+	rules, err := handler.repos.GroupRepo.GetJoinRules(groupId)
+	if err != nil {
+		utils.RespondWithError(w, "Error on getting data", 200)
+		return
+	}
+	isModerator, err := handler.repos.GroupRepo.IsModerator(groupId, userId)
+	if err != nil {
+		utils.RespondWithError(w, "Error on getting data", 200)
+		return
+	}
+	if !isModerator {
+		rules.AutoApprove = models.AutoApproveNone
+	}
+	utils.RespondWithJoinRules(w, rules, 200)
+}
+
+// owner sets questions of private group and rule to accept requests automatically
+// waits for POST with JSON {groupId, questions, autoApprove}, questions replace previous ones
+func (handler *Handler) SetGroupJoinRules(w http.ResponseWriter, r *http.Request) {
+	w = utils.ConfigHeader(w)
+	if r.Method != "POST" {
+		utils.RespondWithError(w, "Method not allowed", 405)
+		return
+	}
+	var req struct {
+		GroupID string `json:"groupId"`
+		models.JoinRules
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, "Invalid request body", 400)
+		return
+	}
+	userId := r.Context().Value(utils.UserKey).(string)
+	isAdmin, err := handler.repos.GroupRepo.IsAdmin(req.GroupID, userId)
+	if err != nil {
+		utils.RespondWithError(w, "Error on getting data", 200)
+		return
+	}
+	if !isAdmin {
+		utils.RespondWithError(w, "Only group owner can change join rules", 200)
+		return
+	}
+	group, err := handler.repos.GroupRepo.GetData(req.GroupID)
+	if err != nil {
+		utils.RespondWithError(w, "Group not found", 200)
+		return
+	}
+	if group.Privacy != "private" {
+		utils.RespondWithError(w, "Join rules can be set only on private groups", 200)
+		return
+	}
+	/* -------------------------------- validate -------------------------------- */
+	switch req.AutoApprove {
+	case models.AutoApproveNone, models.AutoApproveFollowedByOwner, models.AutoApproveFollowedByModerator:
+	default:
+		utils.RespondWithError(w, "Unknown auto approval rule", 200)
+		return
+	}
+	if len(req.Questions) > models.MaxJoinQuestions {
+		utils.RespondWithError(w, "Group can ask at most "+strconv.Itoa(models.MaxJoinQuestions)+" questions", 200)
+		return
+	}
+	questions := []string{}
+	for _, question := range req.Questions {
+		question = strings.TrimSpace(question)
+		if question == "" || len(question) > maxQuestionLength {
+			utils.RespondWithError(w, "Questions must have 1 to "+strconv.Itoa(maxQuestionLength)+" characters", 200)
+			return
+		}
+		questions = append(questions, question)
+	}
+	rules := models.JoinRules{Questions: questions, AutoApprove: req.AutoApprove}
+	if err = handler.repos.GroupRepo.SetJoinRules(req.GroupID, rules); err != nil {
+		utils.RespondWithError(w, "Error on saving data", 200)
+		return
+	}
+	utils.RespondWithJoinRules(w, rules, 200)
+}
+
+/* -------------------------------------------------------------------------- */
+/*                                   helpers                                  */
+/* -------------------------------------------------------------------------- */
+
+// limits of join questions and answers
+const (
+	maxQuestionLength = 200
+	maxAnswerLength   = 500
+)
+
+// reads optional JSON body {answers} of join request, one answer for each question in order
+func readJoinAnswers(r *http.Request, questions []string) ([]models.JoinAnswer, error) {
+	var body struct {
+		Answers []string `json:"answers"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && err != io.EOF {
+		return nil, errors.New("Invalid request body")
+	}
+	if len(body.Answers) != len(questions) {
+		return nil, errors.New("Answer all group questions")
+	}
+	answers := make([]models.JoinAnswer, len(questions))
+	for i, question := range questions {
+		answer := strings.TrimSpace(body.Answers[i])
+		if answer == "" || len(answer) > maxAnswerLength {
+			return nil, errors.New("Answers must have 1 to " + strconv.Itoa(maxAnswerLength) + " characters")
+		}
+		answers[i] = models.JoinAnswer{Question: question, Answer: answer}
+	}
+	return answers, nil
+}
+
+// true if auto approval rule of group accepts user without moderator
+func (handler *Handler) autoApproves(groupId, userId, rule string) (bool, error) {
+	var approvers []string
+	switch rule {
+	case models.AutoApproveFollowedByOwner:
+		owner, err := handler.repos.GroupRepo.GetAdmin(groupId)
+		if err != nil {
+			return false, err
+		}
+		approvers = []string{owner}
+	case models.AutoApproveFollowedByModerator:
+		moderators, err := handler.repos.GroupRepo.GetModerators(groupId)
+		if err != nil {
+			return false, err
+		}
+		approvers = moderators
+	}
+	for _, approver := range approvers {
+		if follows, err := handler.repos.UserRepo.IsFollowing(userId, approver); err != nil || follows {
+			return follows, err
+		}
+	}
+	return false, nil
+}
+
+// removes group from db, its event reminders and images from disk
+func (handler *Handler) deleteGroup(groupId string) error {
+	events, err := handler.repos.EventRepo.GetAll(groupId)
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		if err := handler.cancelEventReminders(event.ID); err != nil {
+			return err
+		}
+	}
+	images, err := handler.repos.GroupRepo.Delete(groupId)
+	if err != nil {
+		return err
+	}
+	for _, image := range images {
+		utils.DeleteImage(image)
+	}
+	return nil
+}
+
+// makes every user waiting for approval a member and lets them know if online
+func (handler *Handler) acceptPendingRequests(wsServer *ws.Server, groupId string) error {
+	requests, err := handler.repos.NotifRepo.GetGroupRequests(groupId)
+	if err != nil {
+		return err
+	}
+	for _, request := range requests {
+		joinerId := request.Content
+		isMember, err := handler.repos.GroupRepo.IsMember(groupId, joinerId)
+		if err != nil {
+			return err
+		}
+		if !isMember {
+			if err = handler.repos.GroupRepo.SaveMember(joinerId, groupId); err != nil {
+				return err
+			}
+		}
+		if err = handler.repos.NotifRepo.Delete(request.ID); err != nil {
+			return err
+		}
+		if err = handler.repos.GroupRepo.DeleteJoinAnswers(groupId, joinerId); err != nil {
+			return err
+		}
+		wsServer.SendGroupRequestAccept(joinerId, groupId)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"social-network/pkg/models"
+	"social-network/pkg/utils"
+)
+
+// only owner edits, missing fields stay, private group turned public accepts pending requests
+func TestEditGroup(t *testing.T) {
+	handler, wsServer, _ := newTestHandler(t)
+	addTestUsers(t, handler, "alice", "bob", "carol")
+	addTestGroup(t, handler, "group", "alice", "bob")
+	group, err := handler.repos.GroupRepo.GetData("group")
+	if err != nil {
+		t.Fatal(err)
+	}
+	group.Description, group.Privacy = "about", "private"
+	if err := handler.repos.GroupRepo.Update(group); err != nil {
+		t.Fatal(err)
+	}
+	request := models.Notification{ID: "request", TargetID: "group", Type: "GROUP_REQUEST", Content: "carol", Sender: "carol"}
+	if err := handler.repos.NotifRepo.Save(request); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		user        string
+		fields      map[string]string
+		wantErr     bool
+		wantName    string
+		wantPrivacy string
+	}{
+		{"bob", map[string]string{"name": "taken"}, true, "group", "private"},
+		{"alice", map[string]string{"name": "  "}, true, "group", "private"},
+		{"alice", map[string]string{"privacy": "secret"}, true, "group", "private"},
+		{"alice", map[string]string{"name": "renamed"}, false, "renamed", "private"},
+		{"alice", map[string]string{"privacy": "public"}, false, "renamed", "public"},
+	}
+	for _, test := range tests {
+		test.fields["groupId"] = "group"
+		w := httptest.NewRecorder()
+		handler.EditGroup(wsServer, w, formRequest("/editGroup", test.user, test.fields))
+		if gotErr := strings.Contains(w.Body.String(), `"type":"Error"`); gotErr != test.wantErr {
+			t.Errorf("%s editing with %v: %s", test.user, test.fields, w.Body.String())
+		}
+		group, err := handler.repos.GroupRepo.GetData("group")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if group.Name != test.wantName || group.Privacy != test.wantPrivacy || group.Description != "about" {
+			t.Errorf("%s editing with %v: got %q %q %q", test.user, test.fields, group.Name, group.Privacy, group.Description)
+		}
+	}
+
+	if isMember, err := handler.repos.GroupRepo.IsMember("group", "carol"); err != nil || !isMember {
+		t.Errorf("pending request wasn't accepted, member %v, err %v", isMember, err)
+	}
+	if exists, err := handler.repos.NotifRepo.CheckIfExists(request); err != nil || exists {
+		t.Errorf("request notification left, exists %v, err %v", exists, err)
+	}
+}
+
+// deleted group leaves nothing behind, other group stays untouched
+func TestDeleteGroup(t *testing.T) {
+	handler, _, conn := newTestHandler(t)
+	addTestUsers(t, handler, "alice", "bob")
+	repos := handler.repos
+	for _, groupId := range []string{"group", "other"} {
+		addTestGroup(t, handler, groupId, "alice", "bob")
+		postId, commentId, eventId, messageId := groupId+"-post", groupId+"-comment", groupId+"-event", groupId+"-msg"
+		steps := []error{
+			repos.PostRepo.New(models.Post{ID: postId, AuthorID: "bob", GroupID: groupId, Content: "hi"}),
+			repos.CommentRepo.New(models.Comment{ID: commentId, PostID: postId, AuthorID: "alice", Content: "hi"}),
+			repos.ReactionRepo.Set(models.Reaction{TargetID: commentId, TargetType: "COMMENT", UserID: "bob", Type: "LIKE"}),
+			repos.EventRepo.Save(models.Event{ID: eventId, GroupID: groupId, AuthorID: "alice", Title: "event", DateTime: time.Now().Add(time.Hour)}),
+			repos.MsgRepo.Save(models.ChatMessage{ID: messageId, SenderId: "bob", ReceiverId: groupId, Type: "GROUP", Content: "hi"}),
+			repos.NotifRepo.Save(models.Notification{ID: groupId + "-reaction", TargetID: "alice", Type: "REACTION", Content: commentId, Sender: "bob"}),
+			repos.NotifRepo.Save(models.Notification{ID: groupId + "-mention", TargetID: "alice", Type: "MENTION", Content: messageId, Sender: "bob"}),
+			repos.NotifRepo.Save(models.Notification{ID: groupId + "-event", TargetID: "bob", Type: "EVENT", Content: eventId, Sender: "alice"}),
+		}
+		for _, err := range steps {
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := repos.EventRepo.UpdateResponse(eventId, "", "bob", "going"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := httptest.NewRequest("DELETE", "/deleteGroup", strings.NewReader(`{"groupId":"group"}`))
+	w := httptest.NewRecorder()
+	handler.DeleteGroup(w, r.WithContext(context.WithValue(r.Context(), utils.UserKey, "bob")))
+	if !strings.Contains(w.Body.String(), `"type":"Error"`) {
+		t.Fatal("member deleted group")
+	}
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest("DELETE", "/deleteGroup", strings.NewReader(`{"groupId":"group"}`))
+	handler.DeleteGroup(w, r.WithContext(context.WithValue(r.Context(), utils.UserKey, "alice")))
+	if strings.Contains(w.Body.String(), `"type":"Error"`) {
+		t.Fatal(w.Body.String())
+	}
+
+	counts := map[string]string{
+		"groups":        "SELECT COUNT(*) FROM groups WHERE group_id = ?",
+		"group_users":   "SELECT COUNT(*) FROM group_users WHERE group_id = ?",
+		"posts":         "SELECT COUNT(*) FROM posts WHERE group_id = ?",
+		"comments":      "SELECT COUNT(*) FROM comments WHERE comment_id = ? || '-comment'",
+		"reactions":     "SELECT COUNT(*) FROM reactions WHERE target_id = ? || '-comment'",
+		"event":         "SELECT COUNT(*) FROM event WHERE group_id = ?",
+		"event_users":   "SELECT COUNT(*) FROM event_users WHERE event_id = ? || '-event'",
+		"messages":      "SELECT COUNT(*) FROM messages WHERE receiver_id = ?",
+		"notifications": "SELECT COUNT(*) FROM notifications WHERE notif_id LIKE ? || '-%'",
+	}
+	for table, query := range counts {
+		if got := countRows(t, conn, query, "group"); got != 0 {
+			t.Errorf("%d rows of deleted group left in %s", got, table)
+		}
+		if got := countRows(t, conn, query, "other"); got == 0 {
+			t.Errorf("rows of other group deleted from %s", table)
+		}
+	}
+}
+
+func countRows(t *testing.T, conn *sql.DB, query string, args ...interface{}) int {
+	t.Helper()
+	var count int
+	if err := conn.QueryRow(query, args...).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
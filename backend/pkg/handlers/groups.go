@@ -153,6 +153,16 @@ func (handler *Handler) GroupInfo(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 	}
+	group.Role, err = handler.repos.GroupRepo.GetRole(group.ID, userId)
+	if err != nil {
+		utils.RespondWithError(w, "Error on getting data", 200)
+		return
+	}
+	group.ChatMuted, err = handler.repos.MsgRepo.IsGroupChatMuted(group.ID, userId)
+	if err != nil {
+		utils.RespondWithError(w, "Error on getting data", 200)
+		return
+	}
 	utils.RespondWithGroups(w, []models.Group{group}, 200)
 }
 
@@ -221,8 +231,13 @@ func (handler *Handler) GroupEvents(w http.ResponseWriter, r *http.Request) {
 		utils.RespondWithError(w, "Not a member", 200)
 		return
 	}
+	from, to, err := eventWindow(r)
+	if err != nil {
+		utils.RespondWithError(w, err.Error(), 200)
+		return
+	}
 	// current user is a member or admin -> get events with responses
-	events, err := handler.repos.EventRepo.GetGroupEventsWithResponses(groupId, userId)
+	events, err := handler.repos.EventRepo.GetGroupEventsWithResponses(groupId, userId, from, to)
 	if err != nil {
 		fmt.Println(err)
 		utils.RespondWithError(w, "Error on getting event data", 200)
@@ -257,26 +272,27 @@ func (handler *Handler) GroupPosts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	/* ------------- current user can access group -> get posts ------------- */
-	posts, err := handler.repos.PostRepo.GetGroupPosts(groupId)
+	page, err := utils.ParsePage(r)
 	if err != nil {
-		utils.RespondWithError(w, "Error on getting data", 200)
+		utils.RespondWithError(w, err.Error(), 200)
 		return
 	}
-	// Get post author info attached
-	if err = AttachAuthors(handler, &posts); err != nil {
+
+	/* ------------- current user can access group -> get posts ------------- */
+	posts, next, err := handler.repos.PostRepo.GetGroupPosts(groupId, page)
+	if err != nil {
 		utils.RespondWithError(w, "Error on getting data", 200)
 		return
 	}
-	// Get comment info for each post
-	if err = AttachComments(handler, &posts); err != nil {
+	// Get post author and comment info attached
+	if err = handler.attachPostDetails(posts, userId); err != nil {
 		utils.RespondWithError(w, "Error on getting data", 200)
 		return
 	}
-	utils.RespondWithPosts(w, posts, 200)
+	utils.RespondWithPosts(w, posts, utils.EncodeCursor(next), 200)
 }
 
-// returns pending requests to join to group, only for admin
+// returns pending requests to join to group, only for owner and moderators
 // for others respond with error
 func (handler *Handler) GroupRequests(w http.ResponseWriter, r *http.Request) {
 	w = utils.ConfigHeader(w)
@@ -289,13 +305,13 @@ func (handler *Handler) GroupRequests(w http.ResponseWriter, r *http.Request) {
 		utils.RespondWithError(w, "Error on getting data", 200)
 		return
 	}
-	/* ------------------------- check if user is admin ------------------------- */
-	isAdmin, err := handler.repos.GroupRepo.IsAdmin(groupId, userId)
+	/* --------------------- check if user is admin or moderator -------------------- */
+	isModerator, err := handler.repos.GroupRepo.IsModerator(groupId, userId)
 	if err != nil {
 		utils.RespondWithError(w, "Error on getting data", 200)
 		return
 	}
-	if !isAdmin {
+	if !isModerator {
 		utils.RespondWithError(w, "Unauthorized", 200)
 		return
 	}
@@ -305,14 +321,20 @@ func (handler *Handler) GroupRequests(w http.ResponseWriter, r *http.Request) {
 		utils.RespondWithError(w, "Error on getting data", 200)
 		return
 	}
+	answers, err := handler.repos.GroupRepo.GetJoinAnswers(groupId)
+	if err != nil {
+		utils.RespondWithError(w, "Error on getting data", 200)
+		return
+	}
 	for i := 0; i < len(notifications); i++ {
 		notifications[i].User, err = handler.repos.UserRepo.GetDataMin(notifications[i].Sender)
 		if err != nil {
 			utils.RespondWithError(w, "Error on getting data", 200)
 			return
 		}
+		notifications[i].Answers = answers[notifications[i].Content]
 	}
-	utils.RespondWithNotifications(w, notifications, 200)
+	utils.RespondWithNotifications(w, notifications, "", 200)
 }
 
 func (handler *Handler) CancelGroupRequests(w http.ResponseWriter, r *http.Request) {
@@ -336,6 +358,10 @@ func (handler *Handler) CancelGroupRequests(w http.ResponseWriter, r *http.Reque
 		utils.RespondWithError(w, "Error on canceling request", 200)
 		return
 	}
+	if err := handler.repos.GroupRepo.DeleteJoinAnswers(groupId, currentUserId); err != nil {
+		utils.RespondWithError(w, "Error on canceling request", 200)
+		return
+	}
 	utils.RespondWithSuccess(w, "gROUP request canceled successfuly", 200)
 }
 
@@ -414,11 +440,7 @@ func (handler *Handler) NewGroup(wsServer *ws.Server, w http.ResponseWriter, r *
 			return
 		}
 
-		for client := range wsServer.Clients {
-			if client.ID == newNotif.TargetID {
-				client.SendNotification(newNotif)
-			}
-		}
+		wsServer.SendNotification(newNotif.TargetID, newNotif)
 
 		// save as a new member of group
 		// if err = handler.repos.GroupRepo.SaveMember(newGroup.Invitations[i], newGroup.ID); err != nil {
@@ -467,6 +489,10 @@ func (handler *Handler) NewGroupPost(w http.ResponseWriter, r *http.Request) {
 		utils.RespondWithError(w, "Not a member", 200)
 		return
 	}
+	if err = handler.checkNotMuted(newPost.GroupID, userId); err != nil {
+		utils.RespondWithError(w, err.Error(), 200)
+		return
+	}
 	/* ------------------------ save image in filesystem ------------------------ */
 	newPost.ImagePath = utils.SaveImage(r)
 	/* -------------------------- save post in database ------------------------- */
@@ -501,6 +527,15 @@ func (handler *Handler) NewGroupRequest(wsServer *ws.Server, w http.ResponseWrit
 		utils.RespondWithError(w, "Invalid request", 200)
 		return
 	}
+	isBanned, err := handler.repos.GroupRepo.IsBanned(groupId, userId)
+	if err != nil {
+		utils.RespondWithError(w, "Error on getting data", 200)
+		return
+	}
+	if isBanned {
+		utils.RespondWithError(w, "You are banned from this group", 200)
+		return
+	}
 	/* -------------------- create new notification instance -------------------- */
 	notification := models.Notification{
 		ID:       utils.UniqueId(),
@@ -520,30 +555,56 @@ func (handler *Handler) NewGroupRequest(wsServer *ws.Server, w http.ResponseWrit
 		utils.RespondWithSuccess(w, "Request already saved", 200)
 		return
 	}
+	/* --------------- answers to group questions and auto approval -------------- */
+	rules, err := handler.repos.GroupRepo.GetJoinRules(groupId)
+	if err != nil {
+		utils.RespondWithError(w, "Error on getting data", 200)
+		return
+	}
+	answers, err := readJoinAnswers(r, rules.Questions)
+	if err != nil {
+		utils.RespondWithError(w, err.Error(), 200)
+		return
+	}
+	approved, err := handler.autoApproves(groupId, userId, rules.AutoApprove)
+	if err != nil {
+		utils.RespondWithError(w, "Error on getting data", 200)
+		return
+	}
+	if approved {
+		if err = handler.repos.GroupRepo.SaveMember(userId, groupId); err != nil {
+			utils.RespondWithError(w, "Error joining group", 200)
+			return
+		}
+		wsServer.SendGroupRequestAccept(userId, groupId)
+		utils.RespondWithSuccess(w, "Request approved automatically", 200)
+		return
+	}
 	/* ------------------------- save notification in db ------------------------ */
 	err = handler.repos.NotifRepo.Save(notification)
 	if err != nil {
 		utils.RespondWithError(w, "Error on saving request", 200)
 		return
 	}
+	if err = handler.repos.GroupRepo.SaveJoinAnswers(groupId, userId, answers); err != nil {
+		utils.RespondWithError(w, "Error on saving request", 200)
+		return
+	}
 
-	//SEND MESSAGE TO TO GROUP ADMIN IF IS ONLINE
-	admin, err := handler.repos.GroupRepo.GetAdmin(groupId)
+	//SEND MESSAGE TO GROUP ADMIN AND MODERATORS IF ONLINE
+	moderators, err := handler.repos.GroupRepo.GetModerators(groupId)
 	if err != nil {
 		utils.RespondWithError(w, "Error on finding group admin", 200)
 		return
 	}
-	
-	for client := range wsServer.Clients {
-		if client.ID == admin {
-			client.SendNotification(notification)
-		}
+	for _, moderator := range moderators {
+		wsServer.SendNotification(moderator, notification)
 	}
 	utils.RespondWithSuccess(w, "Request saved successfuly", 200)
 }
 
 // NOT TESTED
-// handle response from group administrator for requests to join group
+// handle response from group administrator or moderator for requests to join group
 // waits for requestId and response -accept/decline
 func (handler *Handler) ResponseGroupRequest(wsServer *ws.Server, w http.ResponseWriter, r *http.Request) {
 	w = utils.ConfigHeader(w)
@@ -591,25 +652,25 @@ func (handler *Handler) ResponseGroupRequest(wsServer *ws.Server, w http.Respons
 		return
 	}
 	
-	isAdmin, err := handler.repos.GroupRepo.IsAdmin(response.GroupID, userId)
+	isModerator, err := handler.repos.GroupRepo.IsModerator(response.GroupID, userId)
 	if err != nil {
 		utils.RespondWithError(w, "Error checking admin status", 200)
 		return
 	}
-	if !isAdmin {
+	if !isModerator {
 		utils.RespondWithError(w, "Unauthorized", 200)
 		return
 	}
 	
 	/* ----------------------------- handle response ---------------------------- */
+	// get id of member that requests to join
+	joinerId, err := handler.repos.NotifRepo.GetUserFromRequest(response.RequestID)
+	if err != nil {
+		utils.RespondWithError(w, "Error retrieving user from request", 500)
+		return
+	}
 	// if accepted -> save as new member
 	if response.Response == "accept" {
-		// get id of member that requests to join
-		joinerId, err := handler.repos.NotifRepo.GetUserFromRequest(response.RequestID)
-		if err != nil {
-			utils.RespondWithError(w, "Error retrieving user from request", 500)
-			return
-		}
 
 		// Check if user is already a member to prevent duplicates
 		isMember, err := handler.repos.GroupRepo.IsMember(response.GroupID, joinerId)
@@ -627,18 +688,18 @@ func (handler *Handler) ResponseGroupRequest(wsServer *ws.Server, w http.Respons
 		}
 
 		// if joiner online, send updated group status
-		for client := range wsServer.Clients {
-			if client.ID == joinerId {
-				client.SendGroupRequestAccept(response.GroupID)
-			}
-		}
+		wsServer.SendGroupRequestAccept(joinerId, response.GroupID)
 	}
 	
-	// delete from pending notification table
+	// delete from pending notification table with answers to group questions
 	if err = handler.repos.NotifRepo.Delete(response.RequestID); err != nil {
 		utils.RespondWithError(w, "Error removing notification", 500)
 		return
 	}
+	if err = handler.repos.GroupRepo.DeleteJoinAnswers(response.GroupID, joinerId); err != nil {
+		utils.RespondWithError(w, "Error removing notification", 500)
+		return
+	}
 	
 	utils.RespondWithSuccess(w, "Group request processed successfully", 200)
 }
@@ -676,6 +737,15 @@ func (handler *Handler) NewGroupInvite(wsServer *ws.Server, w http.ResponseWrite
 		return
 	}
 	for i := 0; i < len(group.Invitations); i++ {
+		// banned users can't be invited
+		isBanned, err := handler.repos.GroupRepo.IsBanned(group.ID, group.Invitations[i])
+		if err != nil {
+			utils.RespondWithError(w, "Internal server error", 200)
+			return
+		}
+		if isBanned {
+			continue
+		}
 		// save each invitation in db
 		newNotif := models.Notification{
 			ID:       utils.UniqueId(),
@@ -690,12 +760,8 @@ func (handler *Handler) NewGroupInvite(wsServer *ws.Server, w http.ResponseWrite
 			return
 		}
 
-		// search if user has open ws connection and send notification
-		for client := range wsServer.Clients {
-			if client.ID == group.Invitations[i] {
-				client.SendNotification(newNotif)
-			}
-		}
+		// if user has open ws connection send notification
+		wsServer.SendNotification(group.Invitations[i], newNotif)
 	}
 	utils.RespondWithSuccess(w, "Invitations saved", 200)
 }
@@ -727,6 +793,15 @@ func (handler *Handler) ResponseInviteRequest(w http.ResponseWriter, r *http.Req
 		return
 	}
 	if strings.ToUpper(resp.Response) == "ACCEPT" {
+		isBanned, err := handler.repos.GroupRepo.IsBanned(groupId, userId)
+		if err != nil {
+			utils.RespondWithError(w, "Internal server error", 200)
+			return
+		}
+		if isBanned {
+			utils.RespondWithError(w, "You are banned from this group", 200)
+			return
+		}
 		// Check if user is already a member to prevent duplicates
 		isMember, err := handler.repos.GroupRepo.IsMember(groupId, userId)
 		if err != nil {
@@ -814,6 +889,17 @@ func (handler *Handler) JoinPublicGroup(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// Banned users can't join again
+	isBanned, err := handler.repos.GroupRepo.IsBanned(joinReq.GroupID, userId)
+	if err != nil {
+		utils.RespondWithError(w, "Error checking ban status", 500)
+		return
+	}
+	if isBanned {
+		utils.RespondWithError(w, "You are banned from this group", 403)
+		return
+	}
+
 	// Add user as a member
 	err = handler.repos.GroupRepo.SaveMember(userId, joinReq.GroupID)
 	if err != nil {
@@ -824,7 +910,8 @@ func (handler *Handler) JoinPublicGroup(w http.ResponseWriter, r *http.Request)
 	utils.RespondWithSuccess(w, "Successfully joined the group", 200)
 }
 
-// LeaveGroup allows members to leave a group (but not admins)
+// LeaveGroup allows members to leave a group, owner passes group to successor
+// (oldest moderator, otherwise oldest member) and can't leave as the only member
 func (handler *Handler) LeaveGroup(w http.ResponseWriter, r *http.Request) {
 	w = utils.ConfigHeader(w)
 	if r.Method != "POST" {
@@ -858,15 +945,22 @@ func (handler *Handler) LeaveGroup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check if user is the admin - admins cannot leave their own groups
+	// Owner hands the group over before leaving
 	isAdmin, err := handler.repos.GroupRepo.IsAdmin(leaveReq.GroupID, userId)
 	if err != nil {
 		utils.RespondWithError(w, "Error checking admin status", 500)
 		return
 	}
 	if isAdmin {
-		utils.RespondWithError(w, "Admins cannot leave their own groups", 403)
-		return
+		handedOver, err := handler.handOverGroup(leaveReq.GroupID)
+		if err != nil {
+			utils.RespondWithError(w, "Error on transferring ownership", 500)
+			return
+		}
+		if !handedOver {
+			utils.RespondWithError(w, "Nobody can take over the group, you are its only member", 403)
+			return
+		}
 	}
 
 	// Check if user is a member
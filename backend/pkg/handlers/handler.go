@@ -1,10 +1,18 @@
 package handlers
 
-import "social-network/pkg/models"
+import (
+	"social-network/pkg/models"
+	"social-network/pkg/scheduler"
+	"time"
+)
 
 // handler contains all repositories
 type Handler struct {
 	repos *models.Repositories
+
+	// background jobs, set by RegisterJobs
+	scheduler       *scheduler.Scheduler
+	reminderOffsets []time.Duration
 }
 
 // initializing handler to return all repo connections
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	db "social-network/pkg/db/sqlite"
+	"social-network/pkg/models"
+	"social-network/pkg/utils"
+	ws "social-network/pkg/wsServer"
+)
+
+func TestMain(m *testing.M) {
+	// migrations are read relative to backend directory, as when server runs
+	if err := os.Chdir("../.."); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// handler on fresh migrated database in temporary directory
+func newTestHandler(t *testing.T) (*Handler, *ws.Server, *sql.DB) {
+	t.Helper()
+	conn, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db")+"?_busy_timeout=5000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	if err = db.Migrations(conn); err != nil {
+		t.Fatal(err)
+	}
+	repos := db.InitRepositories(conn)
+	return InitHandlers(repos), ws.StartServer(repos), conn
+}
+
+// saves users with provided ids, nickname is the id
+func addTestUsers(t *testing.T, handler *Handler, ids ...string) {
+	t.Helper()
+	for _, id := range ids {
+		user := models.User{ID: id, Email: id + "@test.com", FirstName: id, LastName: "Test", Nickname: id, Password: "x", DateOfBirth: "2000-01-01"}
+		if err := handler.repos.UserRepo.Add(user); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// saves group administrated by adminId, other ids join as members
+func addTestGroup(t *testing.T, handler *Handler, groupId, adminId string, members ...string) {
+	t.Helper()
+	if err := handler.repos.GroupRepo.New(models.Group{ID: groupId, Name: groupId, AdminID: adminId, Privacy: "public"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, member := range members {
+		if err := handler.repos.GroupRepo.SaveMember(member, groupId); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// multipart POST with provided fields sent by logged in user
+func formRequest(target, userId string, fields map[string]string) *http.Request {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for name, value := range fields {
+		form.WriteField(name, value)
+	}
+	form.Close()
+	r := httptest.NewRequest("POST", target, &body)
+	r.Header.Set("Content-Type", form.FormDataContentType())
+	return r.WithContext(context.WithValue(r.Context(), utils.UserKey, userId))
+}
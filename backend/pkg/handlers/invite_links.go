@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"social-network/pkg/models"
+	"social-network/pkg/utils"
+)
+
+// owner or moderator creates shareable link to join group
+// waits for POST with JSON {groupId, hours, maxUses}, zero hours or maxUses means no limit
+func (handler *Handler) NewInviteLink(w http.ResponseWriter, r *http.Request) {
+	w = utils.ConfigHeader(w)
+	if r.Method != "POST" {
+		utils.RespondWithError(w, "Method not allowed", 405)
+		return
+	}
+	var req struct {
+		GroupID string `json:"groupId"`
+		Hours   int    `json:"hours"`
+		MaxUses int    `json:"maxUses"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, "Invalid request body", 400)
+		return
+	}
+	userId := r.Context().Value(utils.UserKey).(string)
+	if ok := handler.canManageInviteLinks(w, req.GroupID, userId); !ok {
+		return
+	}
+	if req.Hours < 0 || req.MaxUses < 0 {
+		utils.RespondWithError(w, "Expiry and max uses can't be negative", 200)
+		return
+	}
+	link := models.InviteLink{
+		ID:        utils.UniqueId(),
+		GroupID:   req.GroupID,
+		Token:     utils.RandomToken(),
+		CreatedBy: userId,
+		MaxUses:   req.MaxUses,
+	}
+	if req.Hours > 0 {
+		expiresAt := time.Now().Add(time.Duration(req.Hours) * time.Hour).UTC().Truncate(time.Second)
+		link.ExpiresAt = &expiresAt
+	}
+	if err := handler.repos.InviteRepo.Save(link); err != nil {
+		utils.RespondWithError(w, "Error on saving link", 200)
+		return
+	}
+	saved, err := handler.repos.InviteRepo.GetByToken(link.Token)
+	if err != nil {
+		utils.RespondWithError(w, "Error on getting data", 200)
+		return
+	}
+	utils.RespondWithInviteLinks(w, []models.InviteLink{saved}, 200)
+}
+
+// active links of group with number of users that joined through each
+// waits for GET with ?groupId=
+func (handler *Handler) InviteLinks(w http.ResponseWriter, r *http.Request) {
+	w = utils.ConfigHeader(w)
+	groupId := r.URL.Query().Get("groupId")
+	userId := r.Context().Value(utils.UserKey).(string)
+	if ok := handler.canManageInviteLinks(w, groupId, userId); !ok {
+		return
+	}
+	links, err := handler.repos.InviteRepo.GetAll(groupId)
+	if err != nil {
+		utils.RespondWithError(w, "Error on getting data", 200)
+		return
+	}
+	now := time.Now()
+	active := []models.InviteLink{}
+	for _, link := range links {
+		if link.Active(now) {
+			active = append(active, link)
+		}
+	}
+	utils.RespondWithInviteLinks(w, active, 200)
+}
+
+// link stops working, users that already joined stay
+// waits for POST with JSON {groupId, linkId}
+func (handler *Handler) RevokeInviteLink(w http.ResponseWriter, r *http.Request) {
+	w = utils.ConfigHeader(w)
+	if r.Method != "POST" {
+		utils.RespondWithError(w, "Method not allowed", 405)
+		return
+	}
+	var req struct {
+		GroupID string `json:"groupId"`
+		LinkID  string `json:"linkId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, "Invalid request body", 400)
+		return
+	}
+	userId := r.Context().Value(utils.UserKey).(string)
+	if ok := handler.canManageInviteLinks(w, req.GroupID, userId); !ok {
+		return
+	}
+	revoked, err := handler.repos.InviteRepo.Revoke(req.GroupID, req.LinkID)
+	if err != nil {
+		utils.RespondWithError(w, "Error on saving data", 200)
+		return
+	}
+	if !revoked {
+		utils.RespondWithError(w, "Link not found", 200)
+		return
+	}
+	utils.RespondWithSuccess(w, "Link revoked", 200)
+}
+
+// current user joins group of link, works for private groups too
+// waits for POST with JSON {token}
+func (handler *Handler) RedeemInviteLink(w http.ResponseWriter, r *http.Request) {
+	w = utils.ConfigHeader(w)
+	if r.Method != "POST" {
+		utils.RespondWithError(w, "Method not allowed", 405)
+		return
+	}
+	var req struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, "Invalid request body", 400)
+		return
+	}
+	userId := r.Context().Value(utils.UserKey).(string)
+	link, err := handler.repos.InviteRepo.GetByToken(req.Token)
+	if errors.Is(err, sql.ErrNoRows) {
+		utils.RespondWithError(w, "Invite link is not valid", 200)
+		return
+	} else if err != nil {
+		utils.RespondWithError(w, "Error on getting data", 200)
+		return
+	}
+	if !link.Active(time.Now()) {
+		utils.RespondWithError(w, "Invite link is no longer valid", 200)
+		return
+	}
+	/* ----------------------- check if user can join group ---------------------- */
+	isMember, err := handler.isGroupMember(link.GroupID, userId)
+	if err != nil {
+		utils.RespondWithError(w, "Error on getting data", 200)
+		return
+	}
+	if isMember {
+		utils.RespondWithError(w, "You are already a member of this group", 200)
+		return
+	}
+	isBanned, err := handler.repos.GroupRepo.IsBanned(link.GroupID, userId)
+	if err != nil {
+		utils.RespondWithError(w, "Error on getting data", 200)
+		return
+	}
+	if isBanned {
+		utils.RespondWithError(w, "You are banned from this group", 200)
+		return
+	}
+	/* ---------------------------------- join ---------------------------------- */
+	used, err := handler.repos.InviteRepo.Use(link.ID, userId)
+	if err != nil {
+		utils.RespondWithError(w, "Error on saving data", 200)
+		return
+	}
+	if !used {
+		utils.RespondWithError(w, "Invite link is no longer valid", 200)
+		return
+	}
+	if err = handler.repos.GroupRepo.SaveMember(userId, link.GroupID); err != nil {
+		utils.RespondWithError(w, "Error joining group", 200)
+		return
+	}
+	// pending request and invitations are not needed anymore, user joined anyway if they stay
+	if err := handler.repos.NotifRepo.DeleteByType(models.Notification{TargetID: link.GroupID, Type: "GROUP_REQUEST", Content: userId}); err != nil {
+		log.Println("Error on deleting group request:", err)
+	}
+	if err := handler.repos.NotifRepo.DeleteGroupInvite(userId, link.GroupID); err != nil {
+		log.Println("Error on deleting group invite:", err)
+	}
+	if err := handler.repos.GroupRepo.DeleteJoinAnswers(link.GroupID, userId); err != nil {
+		log.Println("Error on deleting join answers:", err)
+	}
+
+	group, err := handler.repos.GroupRepo.GetData(link.GroupID)
+	if err != nil {
+		utils.RespondWithError(w, "Error on getting data", 200)
+		return
+	}
+	group.Member, group.Role = true, models.RoleMember
+	utils.RespondWithGroups(w, []models.Group{group}, 200)
+}
+
+// true if user is owner or moderator of group, responds with error otherwise
+func (handler *Handler) canManageInviteLinks(w http.ResponseWriter, groupId, userId string) bool {
+	isModerator, err := handler.repos.GroupRepo.IsModerator(groupId, userId)
+	if err != nil {
+		utils.RespondWithError(w, "Error on getting data", 200)
+		return false
+	}
+	if !isModerator {
+		utils.RespondWithError(w, "Only group owner or moderators can manage invite links", 200)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import "social-network/pkg/models"
+
+// request scoped cache of author data (id, names, nickname, image)
+// ids are loaded in batches, each user is fetched at most once per request
+type userLoader struct {
+	repo  models.UserRepository
+	users map[string]models.User
+}
+
+func (handler *Handler) newUserLoader() *userLoader {
+	return &userLoader{repo: handler.repos.UserRepo, users: make(map[string]models.User)}
+}
+
+// fetch users not loaded yet with single query
+func (loader *userLoader) Load(ids ...string) error {
+	var missing []string
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		if _, ok := loader.users[id]; ok || seen[id] || id == "" {
+			continue
+		}
+		seen[id] = true
+		missing = append(missing, id)
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	users, err := loader.repo.GetDataMinBatch(missing)
+	if err != nil {
+		return err
+	}
+	for _, id := range missing {
+		loader.users[id] = users[id] // unknown users cached as empty
+	}
+	return nil
+}
+
+// returns loaded user, empty user if it was not loaded or does not exist
+func (loader *userLoader) Get(id string) models.User {
+	return loader.users[id]
+}
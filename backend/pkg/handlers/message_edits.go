@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"social-network/pkg/models"
+	"social-network/pkg/utils"
+	ws "social-network/pkg/wsServer"
+)
+
+// how long after sending the sender can still edit message
+const messageEditWindow = 15 * time.Minute
+
+// sender changes content of own message, other party or group members get chat.edited
+// waits for POST with JSON {id, content}
+func (handler *Handler) EditMessage(wsServer *ws.Server, w http.ResponseWriter, r *http.Request) {
+	w = utils.ConfigHeader(w)
+	if r.Method != "POST" {
+		utils.RespondWithError(w, "Method not allowed", 405)
+		return
+	}
+	var msg models.ChatMessage
+	if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+		utils.RespondWithError(w, "Invalid request body", 400)
+		return
+	}
+	userId := r.Context().Value(utils.UserKey).(string)
+	result, err := handler.editChatMessage(wsServer, userId, msg.ID, msg.Content)
+	if err != nil {
+		utils.RespondWithError(w, err.Error(), 200)
+		return
+	}
+	utils.RespondWithSuccess(w, result, 200)
+}
+
+// participant hides message from own chat history, sender can delete it for everyone
+// everyone sees tombstone of message deleted for everyone through chat.deleted
+// waits for DELETE with JSON {id, forEveryone}
+func (handler *Handler) DeleteMessage(wsServer *ws.Server, w http.ResponseWriter, r *http.Request) {
+	w = utils.ConfigHeader(w)
+	if r.Method != "DELETE" {
+		utils.RespondWithError(w, "Method not allowed", 405)
+		return
+	}
+	var req struct {
+		ID          string `json:"id"`
+		ForEveryone bool   `json:"forEveryone"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, "Invalid request body", 400)
+		return
+	}
+	userId := r.Context().Value(utils.UserKey).(string)
+	result, err := handler.deleteChatMessage(wsServer, userId, req.ID, req.ForEveryone)
+	if err != nil {
+		utils.RespondWithError(w, err.Error(), 200)
+		return
+	}
+	utils.RespondWithSuccess(w, result, 200)
+}
+
+/* -------------------------------------------------------------------------- */
+/*                                   helpers                                  */
+/* -------------------------------------------------------------------------- */
+
+// saves new content of message and pushes it to all participants
+// shared by http and websocket endpoints, returns text for client on success
+func (handler *Handler) editChatMessage(wsServer *ws.Server, userId, messageId, content string) (string, error) {
+	msg, err := handler.repos.MsgRepo.GetData(messageId)
+	if err != nil {
+		return "", errors.New("Message not found")
+	}
+	if msg.SenderId != userId {
+		return "", errors.New("Only sender can edit message")
+	}
+	if msg.Deleted {
+		return "", errors.New("Message was deleted")
+	}
+	if strings.TrimSpace(content) == "" {
+		return "", errors.New("Message can't be empty")
+	}
+	// like sending, editing group message needs membership and no mute
+	if msg.Type == "GROUP" {
+		isMember, err := handler.isGroupMember(msg.ReceiverId, userId)
+		if err != nil {
+			return "", errors.New("Error on checking membership")
+		}
+		if !isMember {
+			return "", errors.New("Only group members can edit messages in group chat")
+		}
+		if err = handler.checkNotMuted(msg.ReceiverId, userId); err != nil {
+			return "", err
+		}
+	}
+	edited, err := handler.repos.MsgRepo.Edit(msg.ID, content, messageEditWindow)
+	if err != nil {
+		return "", errors.New("Error on saving message")
+	}
+	if !edited {
+		return "", errors.New("Messages can be edited only within " + strconv.Itoa(int(messageEditWindow.Minutes())) + " minutes")
+	}
+	msg.Content, msg.Edited = content, true
+	msg.Sender, _ = handler.repos.UserRepo.GetDataMin(msg.SenderId)
+	participants, err := handler.chatParticipants(msg)
+	if err != nil {
+		return "Message edited", nil
+	}
+	wsServer.SendChatEdit(participants, msg)
+	return "Message edited", nil
+}
+
+// deletes message for user or, if user is sender, for everyone and pushes the change
+// shared by http and websocket endpoints, returns text for client on success
+func (handler *Handler) deleteChatMessage(wsServer *ws.Server, userId, messageId string, forEveryone bool) (string, error) {
+	msg, err := handler.repos.MsgRepo.GetData(messageId)
+	if err != nil {
+		return "", errors.New("Message not found")
+	}
+	participants, err := handler.chatParticipants(msg)
+	if err != nil {
+		return "", errors.New("Error on getting chat participants")
+	}
+	isParticipant := false
+	for _, id := range participants {
+		isParticipant = isParticipant || id == userId
+	}
+	if !isParticipant {
+		return "", errors.New("Message not found")
+	}
+	if !forEveryone {
+		if err = handler.repos.MsgRepo.DeleteForUser(msg.ID, userId); err != nil {
+			return "", errors.New("Error on deleting message")
+		}
+		// other tabs of user hide it too
+		wsServer.SendChatDelete([]string{userId}, models.ChatMessage{ID: msg.ID, ReceiverId: msg.ReceiverId, Type: msg.Type}, "me")
+		return "Message deleted for you", nil
+	}
+	if msg.SenderId != userId {
+		return "", errors.New("Only sender can delete message for everyone")
+	}
+	if msg.Deleted {
+		return "", errors.New("Message was already deleted")
+	}
+	if err = handler.repos.MsgRepo.DeleteForEveryone(msg.ID); err != nil {
+		return "", errors.New("Error on deleting message")
+	}
+	msg.Content, msg.Deleted = "", true
+	wsServer.SendChatDelete(participants, msg, "everyone")
+	return "Message deleted for everyone", nil
+}
+
+// users who can see message: both parties of private chat or group members with sender
+func (handler *Handler) chatParticipants(msg models.ChatMessage) ([]string, error) {
+	if msg.Type == "PERSON" {
+		return []string{msg.SenderId, msg.ReceiverId}, nil
+	}
+	members, err := handler.repos.GroupRepo.GetMembers(msg.ReceiverId)
+	if err != nil {
+		return nil, err
+	}
+	ids := []string{msg.SenderId}
+	for _, member := range members {
+		if member.ID != msg.SenderId {
+			ids = append(ids, member.ID)
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"social-network/pkg/models"
+)
+
+// group message can be edited only while its sender is unmuted member
+func TestEditGroupMessage(t *testing.T) {
+	handler, wsServer, _ := newTestHandler(t)
+	addTestUsers(t, handler, "alice", "bob", "carol")
+	addTestGroup(t, handler, "group", "alice", "bob", "carol")
+	for _, sender := range []string{"bob", "carol"} {
+		if err := handler.repos.MsgRepo.Save(models.ChatMessage{ID: sender + "-msg", SenderId: sender, ReceiverId: "group", Type: "GROUP", Content: "hi"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := handler.repos.GroupRepo.Mute("group", "carol", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := handler.editChatMessage(wsServer, "alice", "bob-msg", "changed"); err == nil {
+		t.Error("other member edited message")
+	}
+	if _, err := handler.editChatMessage(wsServer, "bob", "bob-msg", "changed"); err != nil {
+		t.Errorf("member can't edit own message: %v", err)
+	}
+	if _, err := handler.editChatMessage(wsServer, "carol", "carol-msg", "changed"); err == nil {
+		t.Error("muted member edited message")
+	}
+	if err := handler.repos.GroupRepo.RemoveMember("bob", "group"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := handler.editChatMessage(wsServer, "bob", "bob-msg", "again"); err == nil {
+		t.Error("removed member edited message")
+	}
+	msg, err := handler.repos.MsgRepo.GetData("bob-msg")
+	if err != nil || msg.Content != "changed" {
+		t.Errorf("content = %q, %v", msg.Content, err)
+	}
+}
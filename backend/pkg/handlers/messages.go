@@ -2,7 +2,9 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"social-network/pkg/models"
 	"social-network/pkg/utils"
@@ -12,8 +14,10 @@ import (
 
 // get all previous messages for chat
 // waits for POST request with RECEIVER as target and TYPE
-// respondes with all messages through simple http response
-func (handler *Handler) Messages(w http.ResponseWriter, r *http.Request) {
+// respondes with page of messages (?cursor=&limit=) through simple http response
+// newest page comes first, messages inside page are oldest first
+// messages of other party become read, their senders get read receipts
+func (handler *Handler) Messages(wsServer *ws.Server, w http.ResponseWriter, r *http.Request) {
 	w = utils.ConfigHeader(w)
 	/* ------------------- // get incoming data in msg format ------------------- */
 	var msgIn models.ChatMessage
@@ -23,11 +27,17 @@ func (handler *Handler) Messages(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	msgIn.SenderId = r.Context().Value(utils.UserKey).(string)
+	page, err := utils.ParsePage(r)
+	if err != nil {
+		utils.RespondWithError(w, err.Error(), 200)
+		return
+	}
 
 	/* ----------------------- get massages form database ----------------------- */
 	var messages []models.ChatMessage
+	var next *models.Cursor
 	if msgIn.Type == "PERSON" {
-		messages, err = handler.repos.MsgRepo.GetAll(msgIn)
+		messages, next, err = handler.repos.MsgRepo.GetAll(msgIn, page)
 		if err != nil {
 			utils.RespondWithError(w, "Error on getting the messages", 200)
 			return
@@ -38,14 +48,17 @@ func (handler *Handler) Messages(w http.ResponseWriter, r *http.Request) {
 			if messages[i].SenderId == msgIn.SenderId {
 				continue
 			}
-			err = handler.repos.MsgRepo.MarkAsRead(messages[i])
+			read, err := handler.repos.MsgRepo.MarkAsRead(messages[i])
 			if err != nil {
 				utils.RespondWithError(w, "Error on marking message as read", 200)
 				return
 			}
+			if read {
+				handler.sendReceipt(wsServer, messages[i], msgIn.SenderId)
+			}
 		}
 		// if no messages so far, check if request made and add message
-		if len(messages) == 0 {
+		if len(messages) == 0 && page.After.ID == "" {
 			requetExists, err := handler.repos.NotifRepo.CheckIfChatRequestExists(msgIn.SenderId, msgIn.ReceiverId)
 			if err != nil {
 				utils.RespondWithError(w, "Error on checking chat history", 200)
@@ -58,7 +71,7 @@ func (handler *Handler) Messages(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 	} else if msgIn.Type == "GROUP" {
-		messages, err = handler.repos.MsgRepo.GetAllGroup(msgIn.SenderId, msgIn.ReceiverId)
+		messages, next, err = handler.repos.MsgRepo.GetAllGroup(msgIn.SenderId, msgIn.ReceiverId, page)
 		if err != nil {
 			utils.RespondWithError(w, "Error on getting the messages", 200)
 			return
@@ -69,19 +82,34 @@ func (handler *Handler) Messages(w http.ResponseWriter, r *http.Request) {
 			if messages[i].SenderId == msgIn.SenderId {
 				continue
 			}
-			err = handler.repos.MsgRepo.MarkAsReadGroup(models.ChatMessage{ID: messages[i].ID, ReceiverId: msgIn.SenderId})
+			read, err := handler.repos.MsgRepo.MarkAsReadGroup(models.ChatMessage{ID: messages[i].ID, ReceiverId: msgIn.SenderId})
 			if err != nil {
 				utils.RespondWithError(w, "Error on marking message as read", 200)
 				return
 			}
+			if read {
+				handler.sendReceipt(wsServer, messages[i], msgIn.SenderId)
+			}
 		}
 	}
 	/* --------------------------- attach sender data --------------------------- */
+	loader := handler.newUserLoader()
+	senderIDs := make([]string, 0, len(messages))
+	for i := 0; i < len(messages); i++ {
+		senderIDs = append(senderIDs, messages[i].SenderId)
+		if messages[i].ReplyTo != nil {
+			senderIDs = append(senderIDs, messages[i].ReplyTo.SenderId)
+		}
+	}
+	loader.Load(senderIDs...)
 	for i := 0; i < len(messages); i++ {
-		messages[i].Sender, _ = handler.repos.UserRepo.GetDataMin(messages[i].SenderId)
+		messages[i].Sender = loader.Get(messages[i].SenderId)
+		if messages[i].ReplyTo != nil {
+			messages[i].ReplyTo.Sender = loader.Get(messages[i].ReplyTo.SenderId)
+		}
 	}
 
-	utils.RespondWithMessages(w, messages, 200)
+	utils.RespondWithMessages(w, messages, utils.EncodeCursor(next), 200)
 }
 
 // function saves new message and responds
@@ -98,48 +126,54 @@ func (handler *Handler) NewMessage(wsServer *ws.Server, w http.ResponseWriter, r
 	/* -------------------- attach sender id ------------------------------------ */
 	msg.SenderId = r.Context().Value(utils.UserKey).(string)
 
-	var newChatFlag = "" 
+	// The new message will be sent via WebSocket to ensure all tabs are updated.
+	result, err := handler.sendChatMessage(wsServer, msg)
+	if err != nil {
+		utils.RespondWithError(w, err.Error(), 200)
+		return
+	}
+	utils.RespondWithSuccess(w, result, 200)
+}
+
+// saves new chat message (or chat request) and pushes it through websocket to all parties
+// shared by http and websocket endpoints, msg needs SenderId, ReceiverId, Type and Content
+// returns text for client on success, error with text for client otherwise
+func (handler *Handler) sendChatMessage(wsServer *ws.Server, msg models.ChatMessage) (string, error) {
+	var newChatFlag = ""
 
 	isFollowingBack, err := handler.repos.UserRepo.IsFollowing(msg.SenderId, msg.ReceiverId)
 	if err != nil {
-		utils.RespondWithError(w, "Error on saving checking status", 200)
-		return
+		return "", errors.New("Error on saving checking status")
 	}
 
 	isGroupMember, err := handler.repos.GroupRepo.IsMember(msg.ReceiverId, msg.SenderId)
 	if err != nil {
-		utils.RespondWithError(w, "Error on checking if user is member", 200)
-		return
+		return "", errors.New("Error on checking if user is member")
 	}
 
 	isGroupAdmin, err := handler.repos.GroupRepo.IsAdmin(msg.ReceiverId, msg.SenderId)
 	if err != nil {
-		utils.RespondWithError(w, "Error on checking if user is admin", 200)
-		return
+		return "", errors.New("Error on checking if user is admin")
 	}
 
 	// if he is private and have no chat history and not group member, create notification insted of saving msg
 	if !isFollowingBack && !isGroupMember && !isGroupAdmin {
 		status, err := handler.repos.UserRepo.GetStatus(msg.ReceiverId)
 		if err != nil {
-			utils.RespondWithError(w, "Error on saving checking status", 200)
-			return
+			return "", errors.New("Error on saving checking status")
 		}
 		hasHistory, err := handler.repos.MsgRepo.HasHistory(msg.SenderId, msg.ReceiverId)
 		if err != nil {
-			utils.RespondWithError(w, "Error on checking chat history", 200)
-			return
+			return "", errors.New("Error on checking chat history")
 		}
 		if status == "PRIVATE" && !hasHistory {
 			// check if request is already made
 			requestExists, err := handler.repos.NotifRepo.CheckIfChatRequestExists(msg.SenderId, msg.ReceiverId)
 			if err != nil {
-				utils.RespondWithError(w, "Internal server error", 200)
-				return
+				return "", errors.New("Internal server error")
 			}
 			if requestExists {
-				utils.RespondWithError(w, "Chat request already saved.\n Wait for user to respond to your request.", 200)
-				return
+				return "", errors.New("Chat request already saved.\n Wait for user to respond to your request.")
 			}
 			// save msg in notification table
 			newNotif := models.Notification{
@@ -151,72 +185,86 @@ func (handler *Handler) NewMessage(wsServer *ws.Server, w http.ResponseWriter, r
 			}
 			err = handler.repos.NotifRepo.Save(newNotif)
 			if err != nil {
-				utils.RespondWithError(w, "Internal server error", 200)
-				return
+				return "", errors.New("Internal server error")
 			}
 			// NOTIFY  RECEIVER ABOUT THE NEW CHAT REQUEST IF ONLINE
-			for client := range wsServer.Clients {
-				if client.ID == newNotif.TargetID {
-					client.SendNotification(newNotif)
-				}
-			}
-			utils.RespondWithSuccess(w, "New request saved", 200)
-			return
+			wsServer.SendNotification(newNotif.TargetID, newNotif)
+			return "New request saved", nil
 		} else if status == "PUBLIC" && !hasHistory {
 			newChatFlag = "NEW"
 		}
 	}
+	// muted members can't write to group chat
+	if msg.Type == "GROUP" && (isGroupMember || isGroupAdmin) {
+		if err := handler.checkNotMuted(msg.ReceiverId, msg.SenderId); err != nil {
+			return "", err
+		}
+	}
+	// reply must quote message of the same chat
+	msg.ReplyTo = nil
+	if msg.ReplyToID != "" {
+		if msg.ReplyTo, err = handler.quotedMessage(msg); err != nil {
+			return "", err
+		}
+	}
 	msg.ID = utils.UniqueId()
 	/* ---------------------------- save in database ---------------------------- */
 	err = handler.repos.MsgRepo.Save(msg)
 	if err != nil {
 		fmt.Println("MSG", msg)
 		fmt.Println("ERR", err)
-
-		utils.RespondWithError(w, "Error on saving message", 200)
-		return
+		return "", errors.New("Error on saving message")
 	}
 	/* --------------------------- attach sender  info -------------------------- */
 	msg.Sender, _ = handler.repos.UserRepo.GetDataMin(msg.SenderId)
-	// The new message will be sent via WebSocket to ensure all tabs are updated.
-	utils.RespondWithSuccess(w, "Message sent successfully", 200)
 
 	/* ------------------ respond through websocket to all parties ----------------- */
 	if msg.Type == "PERSON" {
-		for client := range wsServer.Clients {
-			if client.ID == msg.SenderId || client.ID == msg.ReceiverId {
-				client.SendChatMessage(msg, newChatFlag)
-			}
-		}
+		wsServer.SendChatMessage([]string{msg.SenderId, msg.ReceiverId}, msg, newChatFlag)
+		handler.markDeliveredIfOnline(wsServer, msg, msg.ReceiverId)
 	} else if msg.Type == "GROUP" { // In case of a group, find and respond to all members.
 		allMembers, err := handler.repos.GroupRepo.GetMembers(msg.ReceiverId)
 		if err != nil {
 			fmt.Println("Error on getting group members:", err)
-			return
+			return "Message sent successfully", nil
+		}
+
+		mentioned := mentionedMembers(msg.Content, allMembers, msg.SenderId)
+		muted, err := handler.repos.MsgRepo.GetGroupChatMutes(msg.ReceiverId)
+		if err != nil {
+			log.Println("Error on getting group chat mutes:", err)
 		}
 
-		memberIDs := make(map[string]bool)
+		// members who muted the chat get message live only if mentioned
+		memberIDs := make([]string, 0, len(allMembers))
 		for _, member := range allMembers {
-			memberIDs[member.ID] = true
+			if member.ID == msg.SenderId || !muted[member.ID] || mentioned[member.ID] {
+				memberIDs = append(memberIDs, member.ID)
+			}
 		}
 
 		for _, member := range allMembers {
 			if member.ID != msg.SenderId {
-				err = handler.repos.MsgRepo.SaveGroupMsg(models.ChatMessage{ID: msg.ID, ReceiverId: member.ID})
+				err = handler.repos.MsgRepo.SaveGroupMsg(models.ChatMessage{ID: msg.ID, ReceiverId: member.ID}, mentioned[member.ID])
 				if err != nil {
 					fmt.Printf("Error saving group message read status for user %s: %v\n", member.ID, err)
 				}
 			}
 		}
-		for client := range wsServer.Clients {
-			if memberIDs[client.ID] {
-				client.SendChatMessage(msg, "")
+		wsServer.SendChatMessage(memberIDs, msg, "")
+		// only members the message was pushed to got it
+		for _, memberId := range memberIDs {
+			if memberId != msg.SenderId {
+				handler.markDeliveredIfOnline(wsServer, msg, memberId)
 			}
 		}
+		for memberId := range mentioned {
+			handler.notifyMention(wsServer, msg, memberId)
+		}
 	}
+	return "Message sent successfully", nil
 }
 
-
 // respond with list of messages, that user has missed
 func (handler *Handler) UnreadMessages(w http.ResponseWriter, r *http.Request) {
 	w = utils.ConfigHeader(w)
@@ -242,7 +290,7 @@ func (handler *Handler) UnreadMessages(w http.ResponseWriter, r *http.Request) {
 
 // handler needs data about msg ->  id and type
 // and it marks it as read in database
-func (handler *Handler) MessageRead(w http.ResponseWriter, r *http.Request) {
+func (handler *Handler) MessageRead(wsServer *ws.Server, w http.ResponseWriter, r *http.Request) {
 	w = utils.ConfigHeader(w)
 	/* --------------------------- read incoming data --------------------------- */
 	var msg models.ChatMessage
@@ -253,23 +301,36 @@ func (handler *Handler) MessageRead(w http.ResponseWriter, r *http.Request) {
 	}
 	// attach current user id
 	msg.ReceiverId = r.Context().Value(utils.UserKey).(string)
+	if err = handler.markMessageRead(wsServer, msg); err != nil {
+		utils.RespondWithError(w, err.Error(), 200)
+		return
+	}
+	utils.RespondWithSuccess(w, "Message marked as read successfuly", 200)
+}
+
+// marks message as read for receiver, msg needs ID, Type and ReceiverId (current user)
+// sender gets read receipt if message wasn't read before
+// shared by http and websocket endpoints
+func (handler *Handler) markMessageRead(wsServer *ws.Server, msg models.ChatMessage) error {
+	var read bool
+	var err error
 	if msg.Type == "GROUP" {
-		err = handler.repos.MsgRepo.MarkAsReadGroup(msg)
-		if err != nil {
-			utils.RespondWithError(w, "Error on marking message as read", 200)
-			return
+		if read, err = handler.repos.MsgRepo.MarkAsReadGroup(msg); err != nil {
+			return errors.New("Error on marking message as read")
 		}
 	} else if msg.Type == "PERSON" {
-		err = handler.repos.MsgRepo.MarkAsRead(msg)
-		if err != nil {
-			utils.RespondWithError(w, "Error on marking message as read", 200)
-			return
+		if read, err = handler.repos.MsgRepo.MarkAsRead(msg); err != nil {
+			return errors.New("Error on marking message as read")
 		}
 	} else {
-		utils.RespondWithError(w, "Error. Message type not provided or not recognized", 200)
-		return
+		return errors.New("Error. Message type not provided or not recognized")
 	}
-	utils.RespondWithSuccess(w, "Message marked as read successfuly", 200)
+	if read {
+		if sent, err := handler.repos.MsgRepo.GetData(msg.ID); err == nil {
+			handler.sendReceipt(wsServer, sent, msg.ReceiverId)
+		}
+	}
+	return nil
 }
 
 func (handler *Handler) ResponseChatRequest(w http.ResponseWriter, r *http.Request) {
@@ -11,31 +11,55 @@ func (handler *Handler) Notifications(w http.ResponseWriter, r *http.Request) {
 	w = utils.ConfigHeader(w)
 
 	userId := r.Context().Value(utils.UserKey).(string)
-	notifs, err := handler.repos.NotifRepo.GetAll(userId)
+	page, err := utils.ParsePage(r)
+	if err != nil {
+		utils.RespondWithError(w, err.Error(), 200)
+		return
+	}
+	notifs, next, err := handler.repos.NotifRepo.GetAll(userId, page)
 	if err != nil {
 		utils.RespondWithError(w, "Error on getting data", 200)
 		return
 	}
 
+	// users mentioned in notifications are loaded at once
+	loader := handler.newUserLoader()
+	var userIDs []string
+	for i := 0; i < len(notifs); i++ {
+		userIDs = append(userIDs, notifs[i].Sender, notifs[i].Content)
+	}
+	loader.Load(userIDs...)
+
 	for i := 0; i < len(notifs); i++ {
 		switch notifs[i].Type {
 		case "GROUP_INVITE":
 			notifs[i].Group, _ = handler.repos.GroupRepo.GetData(notifs[i].Content)
-			notifs[i].User, _ = handler.repos.UserRepo.GetDataMin(notifs[i].Sender)
+			notifs[i].User = loader.Get(notifs[i].Sender)
 		case "FOLLOW":
-			notifs[i].User, _ = handler.repos.UserRepo.GetDataMin(notifs[i].Content)
-		case "EVENT":
-			notifs[i].Event, _ = handler.repos.EventRepo.GetData(notifs[i].Content)
-			notifs[i].User, _ = handler.repos.UserRepo.GetDataMin(notifs[i].Sender)
+			notifs[i].User = loader.Get(notifs[i].Content)
+		case "EVENT", "EVENT_REMINDER", "EVENT_PROMOTED", "EVENT_UPDATED", "EVENT_CANCELLED":
+			eventID, _ := utils.ParseEventChange(notifs[i].Content)
+			notifs[i].Event, _ = handler.repos.EventRepo.GetData(eventID)
+			notifs[i].User = loader.Get(notifs[i].Sender)
 			notifs[i].Group, _ = handler.repos.GroupRepo.GetData(notifs[i].Event.GroupID)
 		case "GROUP_REQUEST":
-			notifs[i].User, _ = handler.repos.UserRepo.GetDataMin(notifs[i].Content)
+			notifs[i].User = loader.Get(notifs[i].Content)
 			notifs[i].Group, _ = handler.repos.GroupRepo.GetData(notifs[i].TargetID)
+		case "REACTION", "COMMENT_REPLY":
+			notifs[i].User = loader.Get(notifs[i].Sender)
+		case "GROUP_ANNOUNCEMENT":
+			post, _ := handler.repos.PostRepo.GetData(notifs[i].Content)
+			notifs[i].User = loader.Get(notifs[i].Sender)
+			notifs[i].Group, _ = handler.repos.GroupRepo.GetData(post.GroupID)
+		case "MENTION":
+			msg, _ := handler.repos.MsgRepo.GetData(notifs[i].Content)
+			notifs[i].User = loader.Get(notifs[i].Sender)
+			notifs[i].Group, _ = handler.repos.GroupRepo.GetData(msg.ReceiverId)
 		}
 		utils.DefineNotificationMsg(&notifs[i])
 	}
 
-	utils.RespondWithNotifications(w, notifs, 200)
+	utils.RespondWithNotifications(w, notifs, utils.EncodeCursor(next), 200)
 }
 
 func (handler *Handler) MarkNotificationAsRead(w http.ResponseWriter, r *http.Request) {
@@ -60,11 +84,16 @@ func (handler *Handler) MarkNotificationAsRead(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	err := handler.repos.NotifRepo.MarkAsRead(request.NotificationID)
+	userId := r.Context().Value(utils.UserKey).(string)
+	marked, err := handler.repos.NotifRepo.MarkAsRead(request.NotificationID, userId)
 	if err != nil {
 		utils.RespondWithError(w, "Error marking notification as read", 500)
 		return
 	}
+	if !marked {
+		utils.RespondWithError(w, "Notification not found", 200)
+		return
+	}
 
 	utils.RespondWithSuccess(w, "Notification marked as read", 200)
 }
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 
@@ -17,23 +19,23 @@ func (handler *Handler) AllPosts(w http.ResponseWriter, r *http.Request) {
 	}
 	// access user id
 	userId := r.Context().Value(utils.UserKey).(string)
-	// request all posts
-	posts, errPosts := handler.repos.PostRepo.GetAll(userId)
-	if errPosts != nil {
-		utils.RespondWithError(w, "Error on getting data", 200)
+	page, err := utils.ParsePage(r)
+	if err != nil {
+		utils.RespondWithError(w, err.Error(), 200)
 		return
 	}
-	// Get post author info attached
-	if err := AttachAuthors(handler, &posts); err != nil {
+	// request page of posts
+	posts, next, errPosts := handler.repos.PostRepo.GetAll(userId, page)
+	if errPosts != nil {
 		utils.RespondWithError(w, "Error on getting data", 200)
 		return
 	}
-	// Get comment info for each post
-	if err := AttachComments(handler, &posts); err != nil {
+	// Get post author and comment info attached
+	if err := handler.attachPostDetails(posts, userId); err != nil {
 		utils.RespondWithError(w, "Error on getting data", 200)
 		return
 	}
-	utils.RespondWithPosts(w, posts, 200)
+	utils.RespondWithPosts(w, posts, utils.EncodeCursor(next), 200)
 }
 
 func (handler *Handler) UserPosts(w http.ResponseWriter, r *http.Request) {
@@ -48,23 +50,23 @@ func (handler *Handler) UserPosts(w http.ResponseWriter, r *http.Request) {
 		utils.RespondWithError(w, "Error user id", 200)
 		return
 	}
-	// request user posts
-	posts, errPosts := handler.repos.PostRepo.GetUserPosts(userId, currentUserId)
-	if errPosts != nil {
-		utils.RespondWithError(w, "Error on getting data", 200)
+	page, err := utils.ParsePage(r)
+	if err != nil {
+		utils.RespondWithError(w, err.Error(), 200)
 		return
 	}
-	// Get post author info attached
-	if err := AttachAuthors(handler, &posts); err != nil {
+	// request page of user posts
+	posts, next, errPosts := handler.repos.PostRepo.GetUserPosts(userId, currentUserId, page)
+	if errPosts != nil {
 		utils.RespondWithError(w, "Error on getting data", 200)
 		return
 	}
-	// Get comment info for each post
-	if err := AttachComments(handler, &posts); err != nil {
+	// Get post author and comment info attached
+	if err := handler.attachPostDetails(posts, currentUserId); err != nil {
 		utils.RespondWithError(w, "Error on getting data", 200)
 		return
 	}
-	utils.RespondWithPosts(w, posts, 200)
+	utils.RespondWithPosts(w, posts, utils.EncodeCursor(next), 200)
 }
 
 /* ----------------------------- create new post ---------------------------- */
@@ -99,73 +101,231 @@ func (handler *Handler) NewPost(w http.ResponseWriter, r *http.Request) {
 		utils.RespondWithError(w, "Error in form validation", 200)
 		return
 	}
-	// in case of "almost private post", automatically give access to all followers
-	if newPost.Visibility == "ALMOST_PRIVATE" {
+	// give access to followers based on visibility
+	if err := handler.saveAccess(newPost, r); err != nil {
+		utils.RespondWithError(w, err.Error(), 200)
+		return
+	}
+	utils.RespondWithSuccess(w, "New post created", 200)
+}
+
+/* -------------------------------- edit post ------------------------------- */
+// waits for POST form with id, body, optional privacy (with checkedfollowers),
+// optional new image or removeImage=true, content changes only when body is sent
+// only author can edit, group moderators can only delete
+func (handler *Handler) EditPost(w http.ResponseWriter, r *http.Request) {
+	w = utils.ConfigHeader(w)
+	if r.Method != "POST" {
+		utils.RespondWithError(w, "Error on form submittion", 200)
+		return
+	}
+	if err := r.ParseMultipartForm(3145728); err != nil { // 3MB
+		utils.RespondWithError(w, "Error in form validation", 200)
+		return
+	}
+	userId := r.Context().Value(utils.UserKey).(string)
+	post, err := handler.repos.PostRepo.GetData(r.PostFormValue("id"))
+	if err != nil {
+		utils.RespondWithError(w, "Post not found", 200)
+		return
+	}
+	if post.AuthorID != userId {
+		utils.RespondWithError(w, "Only author can edit the post", 200)
+		return
+	}
+	if _, ok := r.PostForm["body"]; ok {
+		post.Content = r.PostFormValue("body")
+	}
+	// visibility exists only for posts outside of groups
+	var access []string
+	if privacy := r.PostFormValue("privacy"); privacy != "" && post.GroupID == "" {
+		visibility := strings.Replace(strings.ToUpper(privacy), "-", "_", -1)
+		if visibility != "PUBLIC" && visibility != "ALMOST_PRIVATE" && visibility != "PRIVATE" {
+			utils.RespondWithError(w, "Unknown visibility", 200)
+			return
+		}
+		post.Visibility = visibility
+		if access, err = handler.accessList(post, r); err != nil {
+			utils.RespondWithError(w, err.Error(), 200)
+			return
+		}
+	}
+	// replace or remove image
+	oldImage := post.ImagePath
+	if newImage := utils.SaveImage(r); newImage != "" {
+		post.ImagePath = newImage
+	} else if r.PostFormValue("removeImage") == "true" {
+		post.ImagePath = ""
+	}
+
+	if err := handler.repos.PostRepo.Update(post, access); err != nil {
+		if post.ImagePath != oldImage {
+			utils.DeleteImage(post.ImagePath)
+		}
+		utils.RespondWithError(w, "Error on saving data", 200)
+		return
+	}
+	if post.ImagePath != oldImage {
+		utils.DeleteImage(oldImage)
+	}
+	utils.RespondWithSuccess(w, "Post updated", 200)
+}
+
+/* ------------------------------- delete post ------------------------------ */
+// waits for DELETE with postId in json body
+// author or admin of the group can delete, comments and images are removed too
+func (handler *Handler) DeletePost(w http.ResponseWriter, r *http.Request) {
+	w = utils.ConfigHeader(w)
+	if r.Method != http.MethodDelete {
+		utils.RespondWithError(w, "Method not allowed", 405)
+		return
+	}
+	var request struct {
+		PostID string `json:"postId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		utils.RespondWithError(w, "Invalid request body", 400)
+		return
+	}
+	userId := r.Context().Value(utils.UserKey).(string)
+	post, err := handler.repos.PostRepo.GetData(request.PostID)
+	if err != nil {
+		utils.RespondWithError(w, "Post not found", 200)
+		return
+	}
+	canModerate, err := handler.canModeratePost(post, userId)
+	if err != nil {
+		utils.RespondWithError(w, "Error on getting data", 200)
+		return
+	}
+	if !canModerate {
+		utils.RespondWithError(w, "Only author or group moderator can delete the post", 200)
+		return
+	}
+	// remember images before rows are gone
+	comments, err := handler.repos.CommentRepo.Get(post.ID)
+	if err != nil {
+		utils.RespondWithError(w, "Error on getting data", 200)
+		return
+	}
+	if err := handler.repos.PostRepo.Delete(post.ID); err != nil {
+		utils.RespondWithError(w, "Error on deleting post", 200)
+		return
+	}
+	utils.DeleteImage(post.ImagePath)
+	for _, comment := range comments {
+		utils.DeleteImage(comment.ImagePath)
+	}
+	utils.RespondWithSuccess(w, "Post deleted", 200)
+}
+
+/* -------------------------------------------------------------------------- */
+/*                                   helpers                                  */
+/* -------------------------------------------------------------------------- */
+
+// true if user is author of post or admin/moderator of group post belongs to
+func (handler *Handler) canModeratePost(post models.Post, userId string) (bool, error) {
+	if post.AuthorID == userId {
+		return true, nil
+	}
+	if post.GroupID == "" {
+		return false, nil
+	}
+	return handler.repos.GroupRepo.IsModerator(post.GroupID, userId)
+}
+
+// users that can see not group post with its visibility, empty for public posts
+// never nil, so Update rebuilds access with it
+func (handler *Handler) accessList(post models.Post, r *http.Request) ([]string, error) {
+	access := []string{}
+	switch post.Visibility {
+	case "ALMOST_PRIVATE":
+		followers, err := handler.repos.UserRepo.GetFollowers(post.AuthorID)
+		if err != nil {
+			return nil, errors.New("Error getting followers")
+		}
+		for _, follower := range followers {
+			access = append(access, follower.ID)
+		}
+	case "PRIVATE":
+		if accessListRaw := r.PostFormValue("checkedfollowers"); accessListRaw != "" {
+			access = strings.Split(accessListRaw, ",")
+		}
+	}
+	return access, nil
+}
+
+// fill access tables of not group post
+// "almost private" post -> automatically give access to all followers
+// "private" post -> save selected users (checkedfollowers form value)
+func (handler *Handler) saveAccess(post models.Post, r *http.Request) error {
+	if post.Visibility == "ALMOST_PRIVATE" {
 		// Get all followers of the post creator
-		followers, err := handler.repos.UserRepo.GetFollowers(userId)
+		followers, err := handler.repos.UserRepo.GetFollowers(post.AuthorID)
 		if err != nil {
-			utils.RespondWithError(w, "Error getting followers", 200)
-			return
+			return errors.New("Error getting followers")
 		}
 		// Give access to each follower
 		for _, follower := range followers {
-			err = handler.repos.PostRepo.SaveAccess(newPost.ID, follower.ID)
-			if err != nil {
-				utils.RespondWithError(w, "Internal server error", 200)
-				return
+			if err := handler.repos.PostRepo.SaveAccess(post.ID, follower.ID); err != nil {
+				return errors.New("Internal server error")
 			}
 		}
 	}
-	// in case of "private post", save selected users with access
-	if newPost.Visibility == "PRIVATE" {
+	if post.Visibility == "PRIVATE" {
 		accessListRaw := r.PostFormValue("checkedfollowers")
 		if accessListRaw != "" {
 			accessList := strings.Split(accessListRaw, ",")
 			for i := 0; i < len(accessList); i++ {
 				// save each selected follower in private access table
-				err = handler.repos.PostRepo.SavePrivateAccess(newPost.ID, accessList[i])
-				if err != nil {
-					utils.RespondWithError(w, "Internal server error", 200)
-					return
+				if err := handler.repos.PostRepo.SavePrivateAccess(post.ID, accessList[i]); err != nil {
+					return errors.New("Internal server error")
 				}
 			}
 		}
 	}
-	utils.RespondWithSuccess(w, "New post created", 200)
+	return nil
 }
 
-/* -------------------------------------------------------------------------- */
-/*                                   helpers                                  */
-/* -------------------------------------------------------------------------- */
+// attach authors, comment trees (with their authors) and reactions to posts
+// costs constant number of queries regardless of posts count
+func (handler *Handler) attachPostDetails(posts []models.Post, userId string) error {
+	postIDs := make([]string, len(posts))
+	for i := range posts {
+		postIDs[i] = posts[i].ID
+	}
+	comments, err := handler.repos.CommentRepo.GetForPosts(postIDs)
+	if err != nil {
+		return err
+	}
 
-func AttachAuthors(handler *Handler, posts *[]models.Post) error {
-	for i := 0; i < len(*posts); i++ {
-		userId := (*posts)[i].AuthorID
-		author, err := handler.repos.UserRepo.GetDataMin(userId)
-		if err != nil {
-			return err
+	// collect every author on page and load them at once
+	loader := handler.newUserLoader()
+	var authorIDs []string
+	for i := range posts {
+		authorIDs = append(authorIDs, posts[i].AuthorID)
+		for _, comment := range comments[posts[i].ID] {
+			authorIDs = append(authorIDs, comment.AuthorID)
 		}
-		(*posts)[i].Author = author
 	}
-	return nil
-}
+	if err := loader.Load(authorIDs...); err != nil {
+		return err
+	}
 
-func AttachComments(handler *Handler, posts *[]models.Post) error {
-	for i := 0; i < len(*posts); i++ {
-		postId := (*posts)[i].ID
-		comments, err := handler.repos.CommentRepo.Get(postId)
-		if err != nil {
-			return err
-		}
-		// add author
-		for i := 0; i < len(comments); i++ {
-			author, err := handler.repos.UserRepo.GetDataMin(comments[i].AuthorID)
-			if err != nil {
-				return err
-			}
-			comments[i].Author = author
+	for i := range posts {
+		posts[i].Author = loader.Get(posts[i].AuthorID)
+		postComments := comments[posts[i].ID]
+		for j := range postComments {
+			postComments[j].Author = loader.Get(postComments[j].AuthorID)
 		}
-		(*posts)[i].Comments = comments
+		posts[i].Comments = postComments
+	}
+	// reactions are attached while comments are still flat
+	if err := handler.attachReactions(posts, userId); err != nil {
+		return err
+	}
+	for i := range posts {
+		posts[i].Comments = buildCommentTree(posts[i].Comments)
 	}
 	return nil
 }
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"social-network/pkg/models"
+)
+
+// only author edits group post, missing body keeps content
+func TestEditPost(t *testing.T) {
+	handler, _, _ := newTestHandler(t)
+	addTestUsers(t, handler, "alice", "bob")
+	addTestGroup(t, handler, "group", "alice", "bob")
+	if err := handler.repos.PostRepo.New(models.Post{ID: "post", AuthorID: "bob", GroupID: "group", Content: "first"}); err != nil {
+		t.Fatal(err)
+	}
+	edit := func(userId string, fields map[string]string) string {
+		fields["id"] = "post"
+		w := httptest.NewRecorder()
+		handler.EditPost(w, formRequest("/editPost", userId, fields))
+		return w.Body.String()
+	}
+
+	tests := []struct {
+		user    string
+		fields  map[string]string
+		wantErr bool
+		want    string
+	}{
+		// group admin can delete the post, not change what author wrote
+		{"alice", map[string]string{"body": "changed by admin"}, true, "first"},
+		{"bob", map[string]string{"body": "second"}, false, "second"},
+		{"bob", map[string]string{"removeImage": "true"}, false, "second"},
+		{"bob", map[string]string{"body": ""}, false, ""},
+	}
+	for _, test := range tests {
+		response := edit(test.user, test.fields)
+		if gotErr := strings.Contains(response, `"type":"Error"`); gotErr != test.wantErr {
+			t.Errorf("%s editing with %v: %s", test.user, test.fields, response)
+		}
+		post, err := handler.repos.PostRepo.GetData("post")
+		if err != nil || post.Content != test.want {
+			t.Errorf("%s editing with %v: content %q, want %q, err %v", test.user, test.fields, post.Content, test.want, err)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"social-network/pkg/models"
+	"social-network/pkg/utils"
+	ws "social-network/pkg/wsServer"
+)
+
+/* ------------------- add, change or remove own reaction ------------------- */
+// waits for POST with targetId, targetType (POST|COMMENT) and type
+// same type as current reaction removes it, other type replaces it
+func (handler *Handler) ToggleReaction(wsServer *ws.Server, w http.ResponseWriter, r *http.Request) {
+	w = utils.ConfigHeader(w)
+	if r.Method != "POST" {
+		utils.RespondWithError(w, "Error on form submittion", 200)
+		return
+	}
+	var reaction models.Reaction
+	if err := json.NewDecoder(r.Body).Decode(&reaction); err != nil {
+		utils.RespondWithError(w, "Error on reading the incomming reaction", 200)
+		return
+	}
+	reaction.UserID = r.Context().Value(utils.UserKey).(string)
+	reaction.TargetType = strings.ToUpper(reaction.TargetType)
+	reaction.Type = strings.ToUpper(reaction.Type)
+	if !slices.Contains(models.ReactionTypes, reaction.Type) {
+		utils.RespondWithError(w, "Unknown reaction type", 200)
+		return
+	}
+
+	/* ------------------- find target and check post access ------------------- */
+	post, ownerId, err := handler.reactionTarget(reaction)
+	if err != nil {
+		utils.RespondWithError(w, err.Error(), 200)
+		return
+	}
+	canAccess, err := handler.canViewPost(post, reaction.UserID)
+	if err != nil {
+		utils.RespondWithError(w, "Error on getting data", 200)
+		return
+	}
+	if !canAccess {
+		utils.RespondWithError(w, "Access denied", 200)
+		return
+	}
+
+	/* ----------------------------- toggle reaction ---------------------------- */
+	current, err := handler.repos.ReactionRepo.Get(reaction.TargetID, reaction.UserID)
+	if err != nil {
+		utils.RespondWithError(w, "Error on getting data", 200)
+		return
+	}
+	if current == reaction.Type {
+		err = handler.repos.ReactionRepo.Delete(reaction.TargetID, reaction.UserID)
+		reaction.Type = ""
+	} else {
+		err = handler.repos.ReactionRepo.Set(reaction)
+	}
+	if err != nil {
+		utils.RespondWithError(w, "Error on saving data", 200)
+		return
+	}
+
+	// let author know about first reaction of user, removed reaction takes notification with it
+	// changed reaction type keeps the one already sent
+	notification := models.Notification{
+		ID:       utils.UniqueId(),
+		TargetID: ownerId,
+		Type:     "REACTION",
+		Content:  reaction.TargetID,
+		Sender:   reaction.UserID,
+	}
+	if ownerId != reaction.UserID {
+		if reaction.Type == "" {
+			if err := handler.repos.NotifRepo.DeleteSent(notification); err != nil {
+				log.Println("Error on deleting reaction notification:", err)
+			}
+		} else if current == "" {
+			if err := handler.repos.NotifRepo.Save(notification); err == nil {
+				wsServer.SendNotification(ownerId, notification)
+			}
+		}
+	}
+
+	/* ------------------------ push new counters to viewers ----------------------- */
+	counts, err := handler.repos.ReactionRepo.GetCounts([]string{reaction.TargetID})
+	if err != nil {
+		utils.RespondWithError(w, "Error on getting data", 200)
+		return
+	}
+	summary := models.ReactionSummary{
+		TargetID:   reaction.TargetID,
+		TargetType: reaction.TargetType,
+		PostID:     post.ID,
+		Counts:     counts[reaction.TargetID],
+	}
+	if summary.Counts == nil {
+		summary.Counts = map[string]int{}
+	}
+	wsServer.SendToWatchers(post.ID, ws.WsMessage{Action: ws.ReactionAction, Reaction: &summary})
+
+	summary.MyReaction = reaction.Type
+	utils.RespondWithReaction(w, summary, 200)
+}
+
+/* -------------------------------------------------------------------------- */
+/*                                   helpers                                  */
+/* -------------------------------------------------------------------------- */
+
+// returns post that reaction target belongs to and author of target
+func (handler *Handler) reactionTarget(reaction models.Reaction) (models.Post, string, error) {
+	switch reaction.TargetType {
+	case "POST":
+		post, err := handler.repos.PostRepo.GetData(reaction.TargetID)
+		if err != nil {
+			return post, "", errors.New("Post not found")
+		}
+		return post, post.AuthorID, nil
+	case "COMMENT":
+		comment, err := handler.repos.CommentRepo.GetData(reaction.TargetID)
+		if err != nil {
+			return models.Post{}, "", errors.New("Comment not found")
+		}
+		post, err := handler.repos.PostRepo.GetData(comment.PostID)
+		if err != nil {
+			return post, "", errors.New("Post not found")
+		}
+		return post, comment.AuthorID, nil
+	}
+	return models.Post{}, "", errors.New("Unknown target type")
+}
+
+// true if user can see post, group posts follow group access rules
+func (handler *Handler) canViewPost(post models.Post, userId string) (bool, error) {
+	if post.GroupID != "" {
+		return handler.checkGroupAccess(post.GroupID, userId)
+	}
+	return handler.repos.PostRepo.CanAccess(post.ID, userId)
+}
+
+// attach reaction counters and reaction of current user to posts and their comments
+func (handler *Handler) attachReactions(posts []models.Post, userId string) error {
+	var targetIDs []string
+	for i := range posts {
+		targetIDs = append(targetIDs, posts[i].ID)
+		for j := range posts[i].Comments {
+			targetIDs = append(targetIDs, posts[i].Comments[j].ID)
+		}
+	}
+	counts, err := handler.repos.ReactionRepo.GetCounts(targetIDs)
+	if err != nil {
+		return err
+	}
+	mine, err := handler.repos.ReactionRepo.GetUserReactions(userId, targetIDs)
+	if err != nil {
+		return err
+	}
+	for i := range posts {
+		posts[i].Reactions = reactionCounts(counts, posts[i].ID)
+		posts[i].MyReaction = mine[posts[i].ID]
+		for j := range posts[i].Comments {
+			comment := &posts[i].Comments[j]
+			comment.Reactions = reactionCounts(counts, comment.ID)
+			comment.MyReaction = mine[comment.ID]
+		}
+	}
+	return nil
+}
+
+// counts of target, empty map instead of null for targets without reactions
+func reactionCounts(counts map[string]map[string]int, targetID string) map[string]int {
+	if counts[targetID] == nil {
+		return map[string]int{}
+	}
+	return counts[targetID]
+}
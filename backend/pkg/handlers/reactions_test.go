@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"social-network/pkg/models"
+	"social-network/pkg/utils"
+)
+
+// author gets one notification per user reacting, removing reaction removes it
+// and deleting reacted comment or post removes what is left
+func TestReactionNotifications(t *testing.T) {
+	handler, wsServer, conn := newTestHandler(t)
+	addTestUsers(t, handler, "alice", "bob", "carol")
+	if err := handler.repos.PostRepo.New(models.Post{ID: "post", AuthorID: "alice", Content: "hi", Visibility: "PUBLIC"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := handler.repos.CommentRepo.New(models.Comment{ID: "comment", PostID: "post", AuthorID: "alice", Content: "hi"}); err != nil {
+		t.Fatal(err)
+	}
+	react := func(userId, targetType, targetId, reactionType string) {
+		t.Helper()
+		body := `{"targetId":"` + targetId + `","targetType":"` + targetType + `","type":"` + reactionType + `"}`
+		r := httptest.NewRequest("POST", "/react", strings.NewReader(body))
+		r = r.WithContext(context.WithValue(r.Context(), utils.UserKey, userId))
+		w := httptest.NewRecorder()
+		handler.ToggleReaction(wsServer, w, r)
+		if strings.Contains(w.Body.String(), `"type":"Error"`) {
+			t.Fatalf("%s reacting to %s: %s", userId, targetId, w.Body.String())
+		}
+	}
+	check := func(targetId string, want int) {
+		t.Helper()
+		if got := countNotifications(t, conn, "alice", "REACTION", targetId); got != want {
+			t.Fatalf("alice has %d reaction notifications for %s, want %d", got, targetId, want)
+		}
+	}
+
+	steps := []struct {
+		user, reaction string
+		want           int
+	}{
+		{"bob", "LIKE", 1},
+		{"bob", "LOVE", 1}, // changed reaction
+		{"carol", "LIKE", 2},
+		{"bob", "LOVE", 1}, // removed reaction
+		{"bob", "LOVE", 2},
+		{"alice", "LIKE", 2}, // own post
+	}
+	for _, step := range steps {
+		react(step.user, "POST", "post", step.reaction)
+		check("post", step.want)
+	}
+
+	react("bob", "COMMENT", "comment", "WOW")
+	check("comment", 1)
+	if err := handler.repos.CommentRepo.Delete("comment"); err != nil {
+		t.Fatal(err)
+	}
+	check("comment", 0)
+	check("post", 2)
+	if err := handler.repos.PostRepo.Delete("post"); err != nil {
+		t.Fatal(err)
+	}
+	check("post", 0)
+}
+
+func countNotifications(t *testing.T, conn *sql.DB, userId, notifType, content string) int {
+	t.Helper()
+	var count int
+	err := conn.QueryRow("SELECT COUNT(*) FROM notifications WHERE user_id = ? AND type = ? AND content = ?", userId, notifType, content).Scan(&count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"social-network/pkg/models"
+	"social-network/pkg/utils"
+	ws "social-network/pkg/wsServer"
+)
+
+// delivery and read time of own message for each recipient, "seen by" list of group message
+// read receipts come first, ordered by time of reading
+// waits for GET with ?id= of message
+func (handler *Handler) MessageReceipts(w http.ResponseWriter, r *http.Request) {
+	w = utils.ConfigHeader(w)
+	userId := r.Context().Value(utils.UserKey).(string)
+	msg, err := handler.repos.MsgRepo.GetData(r.URL.Query().Get("id"))
+	if err != nil {
+		utils.RespondWithError(w, "Message not found", 200)
+		return
+	}
+	if msg.SenderId != userId {
+		utils.RespondWithError(w, "Only sender can see receipts of message", 200)
+		return
+	}
+	receipts, err := handler.repos.MsgRepo.GetReceipts(msg.ID)
+	if err != nil {
+		utils.RespondWithError(w, "Error on getting data", 200)
+		return
+	}
+	loader := handler.newUserLoader()
+	var userIDs []string
+	for _, receipt := range receipts {
+		userIDs = append(userIDs, receipt.UserID)
+	}
+	if err := loader.Load(userIDs...); err != nil {
+		utils.RespondWithError(w, "Error on getting data", 200)
+		return
+	}
+	for i := range receipts {
+		user := loader.Get(receipts[i].UserID)
+		receipts[i].User = &user
+	}
+	utils.RespondWithReceipts(w, receipts, 200)
+}
+
+/* -------------------------------------------------------------------------- */
+/*                                   helpers                                  */
+/* -------------------------------------------------------------------------- */
+
+// pushes current receipt of recipient to sender of message
+func (handler *Handler) sendReceipt(wsServer *ws.Server, msg models.ChatMessage, recipientId string) {
+	if msg.SenderId == recipientId {
+		return
+	}
+	receipt, err := handler.repos.MsgRepo.GetReceipt(msg.ID, recipientId)
+	if err != nil {
+		log.Println("Error on getting message receipt:", err)
+		return
+	}
+	wsServer.SendReceipt(msg.SenderId, receipt)
+}
+
+// message reaches recipient right away if they have open connection
+func (handler *Handler) markDeliveredIfOnline(wsServer *ws.Server, msg models.ChatMessage, recipientId string) {
+	if !wsServer.IsOnline(recipientId) {
+		return
+	}
+	delivered, err := handler.repos.MsgRepo.MarkDelivered(models.ChatMessage{ID: msg.ID, Type: msg.Type, ReceiverId: recipientId})
+	if err != nil {
+		log.Println("Error on marking message as delivered:", err)
+		return
+	}
+	if delivered {
+		handler.sendReceipt(wsServer, msg, recipientId)
+	}
+}
+
+// marks messages sent to user while offline as delivered and lets their senders know
+func (handler *Handler) markAllDelivered(wsServer *ws.Server, userId string) {
+	messages, err := handler.repos.MsgRepo.MarkAllDelivered(userId)
+	if err != nil {
+		log.Println("Error on marking messages as delivered:", err)
+		return
+	}
+	for _, msg := range messages {
+		handler.sendReceipt(wsServer, msg, userId)
+	}
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"social-network/pkg/models"
+	"social-network/pkg/scheduler"
+	"social-network/pkg/utils"
+	ws "social-network/pkg/wsServer"
+)
+
+// job type and notification type of event reminders
+const EventReminderJob = "EVENT_REMINDER"
+
+// reminders are sent this long before event, if not configured
+var DefaultReminderOffsets = []time.Duration{24 * time.Hour, time.Hour}
+
+// job data of one reminder
+type reminderPayload struct {
+	EventID    string `json:"eventId"`
+	Occurrence string `json:"occurrence,omitempty"` // set for recurring events
+	Offset     int64  `json:"offset"`               // seconds before start
+}
+
+// reads comma separated durations, e.g. "24h,1h", empty value gives defaults
+func ParseReminderOffsets(value string) ([]time.Duration, error) {
+	if strings.TrimSpace(value) == "" {
+		return DefaultReminderOffsets, nil
+	}
+	var offsets []time.Duration
+	for _, part := range strings.Split(value, ",") {
+		offset, err := time.ParseDuration(strings.TrimSpace(part))
+		if err != nil || offset <= 0 {
+			return nil, errors.New("invalid reminder offset " + strconv.Quote(part))
+		}
+		offsets = append(offsets, offset)
+	}
+	return offsets, nil
+}
+
+// registers jobs run by scheduler, reminders are scheduled for new events from now on
+// and for events that exist already with ScheduleUpcomingReminders
+func (handler *Handler) RegisterJobs(wsServer *ws.Server, jobs *scheduler.Scheduler, reminderOffsets []time.Duration) {
+	handler.scheduler = jobs
+	handler.reminderOffsets = reminderOffsets
+	jobs.Handle(EventReminderJob, func(job models.Job) error {
+		return handler.sendEventReminder(wsServer, job)
+	})
+}
+
+// schedules reminders of all upcoming events, run on start so events created before
+// reminders existed or while server was down get them too
+// reminders scheduled or sent already are kept once by their job key
+func (handler *Handler) ScheduleUpcomingReminders() error {
+	if handler.scheduler == nil {
+		return nil
+	}
+	events, err := handler.repos.EventRepo.GetUpcoming(handler.scheduler.Now())
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		if err := handler.scheduleEventReminders(event, time.Time{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// schedules reminders of event, for recurring events of first occurrence after provided time
+// (zero means now) that still has a reminder ahead, reminders that should have been sent already are skipped
+func (handler *Handler) scheduleEventReminders(event models.Event, after time.Time) error {
+	if handler.scheduler == nil || len(handler.reminderOffsets) == 0 {
+		return nil
+	}
+	now := handler.scheduler.Now()
+	if after.IsZero() {
+		after = now
+	}
+	start, occurrence := event.DateTime, ""
+	if event.Recurrence != nil {
+		// occurrences whose every reminder is past are skipped, otherwise the chain
+		// of reminders would end when offset is longer than interval or server was down
+		from := after.Add(time.Nanosecond)
+		if earliest := now.Add(slices.Min(handler.reminderOffsets)); earliest.After(from) {
+			from = earliest
+		}
+		next, ok := event.Recurrence.Next(event.DateTime, from)
+		if !ok {
+			return nil
+		}
+		start, occurrence = next, models.OccurrenceKey(next)
+	}
+	for _, offset := range handler.reminderOffsets {
+		runAt := start.Add(-offset)
+		if runAt.Before(now) {
+			continue
+		}
+		payload, err := json.Marshal(reminderPayload{EventID: event.ID, Occurrence: occurrence, Offset: int64(offset.Seconds())})
+		if err != nil {
+			return err
+		}
+		err = handler.scheduler.Schedule(models.Job{
+			ID:      utils.UniqueId(),
+			Type:    EventReminderJob,
+			Key:     event.ID + "/" + models.OccurrenceKey(start) + "/" + offset.String(),
+			Payload: string(payload),
+			RunAt:   runAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// drops reminders not sent yet, e.g. when event is moved or cancelled
+func (handler *Handler) cancelEventReminders(eventID string) error {
+	if handler.scheduler == nil {
+		return nil
+	}
+	return handler.scheduler.CancelPending(EventReminderJob, eventID+"/")
+}
+
+// notifies users going or maybe going to event, each user gets reminder once
+// even if job runs again after restart
+func (handler *Handler) sendEventReminder(wsServer *ws.Server, job models.Job) error {
+	var payload reminderPayload
+	if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+		return err
+	}
+	event, err := handler.repos.EventRepo.GetData(payload.EventID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil // event was removed
+	} else if err != nil {
+		return err
+	}
+
+	if event.CancelledAt != nil {
+		return nil
+	}
+	start := event.DateTime
+	if payload.Occurrence != "" {
+		if event.Recurrence == nil {
+			return nil // no longer recurring
+		}
+		if start, err = time.Parse(time.RFC3339, payload.Occurrence); err != nil {
+			return err
+		}
+		// next occurrence gets its reminders once this one is reached
+		if err = handler.scheduleEventReminders(event, start); err != nil {
+			return err
+		}
+		if !event.Recurrence.IsOccurrence(event.DateTime, start) {
+			return nil // occurrence was cancelled
+		}
+	}
+	// reminder is pointless once event started (server was down)
+	if !handler.scheduler.Now().Before(start) {
+		return nil
+	}
+
+	attendees, err := handler.repos.EventRepo.GetAttendees(event.ID, payload.Occurrence)
+	if err != nil {
+		return err
+	}
+	for _, userID := range attendees {
+		notif := models.Notification{
+			ID:       utils.UniqueId(),
+			TargetID: userID,
+			Type:     EventReminderJob,
+			Content:  event.ID,
+			Sender:   event.AuthorID,
+		}
+		// failed save leaves user without mark, so retried job reaches them
+		first, err := handler.repos.JobRepo.NotifyOnce(job.ID, notif)
+		if err != nil {
+			return err
+		}
+		if first {
+			wsServer.SendNotification(userID, notif)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"database/sql"
+	"sync"
+	"testing"
+	"time"
+
+	"social-network/pkg/models"
+	"social-network/pkg/scheduler"
+)
+
+// clock moved by test
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
+
+func TestParseReminderOffsets(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    []time.Duration
+		wantErr bool
+	}{
+		{"", DefaultReminderOffsets, false},
+		{"  ", DefaultReminderOffsets, false},
+		{"24h,1h", []time.Duration{24 * time.Hour, time.Hour}, false},
+		{" 90m , 10m ", []time.Duration{90 * time.Minute, 10 * time.Minute}, false},
+		{"1h,", nil, true},
+		{"tomorrow", nil, true},
+		{"-1h", nil, true},
+		{"0s", nil, true},
+	}
+	for _, test := range tests {
+		got, err := ParseReminderOffsets(test.value)
+		if (err != nil) != test.wantErr {
+			t.Errorf("ParseReminderOffsets(%q) err = %v", test.value, err)
+			continue
+		}
+		if len(got) != len(test.want) {
+			t.Errorf("ParseReminderOffsets(%q) = %v, want %v", test.value, got, test.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("ParseReminderOffsets(%q) = %v, want %v", test.value, got, test.want)
+				break
+			}
+		}
+	}
+}
+
+// events existing before start get reminders, restart doesn't send them again
+// and recurring event keeps getting reminders although its first occurrence had none
+func TestRemindersAcrossRestart(t *testing.T) {
+	handler, wsServer, conn := newTestHandler(t)
+	addTestUsers(t, handler, "alice", "bob")
+	addTestGroup(t, handler, "group", "alice", "bob")
+
+	start := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start}
+	offsets := []time.Duration{24 * time.Hour, time.Hour}
+
+	single := models.Event{ID: "single", GroupID: "group", AuthorID: "alice", Title: "single", DateTime: start.Add(48 * time.Hour)}
+	// all reminders of first occurrence are past already
+	weekly := models.Event{ID: "weekly", GroupID: "group", AuthorID: "alice", Title: "weekly", DateTime: start.Add(30 * time.Minute),
+		Recurrence: &models.Recurrence{Freq: "WEEKLY", Interval: 1}}
+	for _, event := range []models.Event{single, weekly} {
+		if err := handler.repos.EventRepo.Save(event); err != nil {
+			t.Fatal(err)
+		}
+	}
+	respond := func(eventID, occurrence string) {
+		if _, err := handler.repos.EventRepo.UpdateResponse(eventID, occurrence, "bob", "going"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	respond("single", "")
+	for week := 0; week < 3; week++ {
+		respond("weekly", models.OccurrenceKey(weekly.DateTime.AddDate(0, 0, 7*week)))
+	}
+
+	// server start: new handler and scheduler on the same database
+	restart := func() {
+		t.Helper()
+		handler = InitHandlers(handler.repos)
+		jobs := scheduler.New(handler.repos.JobRepo, clock)
+		handler.RegisterJobs(wsServer, jobs, offsets)
+		if err := handler.ScheduleUpcomingReminders(); err != nil {
+			t.Fatal(err)
+		}
+		jobs.RunDue()
+	}
+	advance := func(to time.Time) {
+		t.Helper()
+		clock.Set(to)
+		handler.scheduler.RunDue()
+	}
+	check := func(eventID string, want int) {
+		t.Helper()
+		if got := countReminders(t, conn, "bob", eventID); got != want {
+			t.Fatalf("at %s bob has %d reminders of %s, want %d", clock.Now().Format(time.RFC3339), got, eventID, want)
+		}
+	}
+
+	restart()
+	check("single", 0)
+	check("weekly", 0)
+
+	advance(start.Add(24 * time.Hour))
+	check("single", 1)
+	advance(start.Add(47 * time.Hour))
+	check("single", 2)
+
+	// second occurrence starts at start+7d+30m
+	advance(start.AddDate(0, 0, 6).Add(time.Hour))
+	check("weekly", 1)
+	advance(start.AddDate(0, 0, 7))
+	check("weekly", 2)
+	restart()
+	check("single", 2)
+	check("weekly", 2)
+
+	// server down until third occurrence started, its reminders are skipped
+	// but the chain goes on with the fourth
+	clock.Set(start.AddDate(0, 0, 14).Add(time.Hour))
+	restart()
+	check("weekly", 2)
+	respond("weekly", models.OccurrenceKey(weekly.DateTime.AddDate(0, 0, 21)))
+	advance(start.AddDate(0, 0, 21))
+	check("weekly", 4)
+	restart()
+	check("weekly", 4)
+	check("single", 2)
+	if got := countReminders(t, conn, "alice", "weekly"); got != 0 {
+		t.Fatalf("alice didn't answer but has %d reminders", got)
+	}
+}
+
+func countReminders(t *testing.T, conn *sql.DB, userId, eventId string) int {
+	t.Helper()
+	var count int
+	err := conn.QueryRow("SELECT COUNT(*) FROM notifications WHERE user_id = ? AND type = ? AND content = ?", userId, EventReminderJob, eventId).Scan(&count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"social-network/pkg/models"
+	"social-network/pkg/utils"
+	ws "social-network/pkg/wsServer"
+)
+
+// answers user can give, "waitlisted" is only set when event is full
+var rsvpResponses = map[string]bool{"going": true, "not_going": true, "maybe": true}
+
+// answer of user to event, occurrence is needed for recurring events
+type rsvp struct {
+	EventID    string
+	Occurrence string
+	Response   string
+}
+
+// saves answer of user to event, every RSVP endpoint goes through here
+// returns event with updated responses, errors are meant for client
+func (handler *Handler) respondToEvent(wsServer *ws.Server, userId string, answer rsvp) (*models.EventWithResponses, error) {
+	if !rsvpResponses[answer.Response] {
+		return nil, errors.New("Invalid response type")
+	}
+	event, err := handler.repos.EventRepo.GetData(answer.EventID)
+	if err != nil {
+		return nil, errors.New("Event not found")
+	}
+	/* --------------------------- check who answers --------------------------- */
+	isMember, err := handler.isGroupMember(event.GroupID, userId)
+	if err != nil {
+		return nil, errors.New("Error checking membership")
+	}
+	if !isMember {
+		return nil, errors.New("Not a member of this group")
+	}
+	/* ---------------------- check event can be answered ---------------------- */
+	if event.CancelledAt != nil {
+		return nil, errors.New("Event is cancelled")
+	}
+	start, occurrence := event.DateTime, ""
+	if event.Recurrence != nil {
+		// responses of recurring events belong to one occurrence
+		start, err = time.Parse(time.RFC3339, answer.Occurrence)
+		if err != nil || !event.Recurrence.IsOccurrence(event.DateTime, start) {
+			return nil, errors.New("Invalid occurrence")
+		}
+		occurrence = models.OccurrenceKey(start)
+	}
+	if !start.IsZero() && !time.Now().Before(start) {
+		return nil, errors.New("Event already started")
+	}
+	/* ---------------------------- save response ----------------------------- */
+	// full event puts user on waitlist, leaving it promotes waitlisted users
+	result, err := handler.repos.EventRepo.UpdateResponse(event.ID, occurrence, userId, answer.Response)
+	if err != nil {
+		return nil, errors.New("Error updating response")
+	}
+	for _, promotedId := range result.Promoted {
+		notif := models.Notification{
+			ID:       utils.UniqueId(),
+			TargetID: promotedId,
+			Type:     "EVENT_PROMOTED",
+			Content:  event.ID,
+			Sender:   event.AuthorID,
+		}
+		if err = handler.repos.NotifRepo.Save(notif); err != nil {
+			return nil, errors.New("Internal server error")
+		}
+		wsServer.SendNotification(promotedId, notif)
+	}
+	// invitation to answered event is no longer needed
+	invitation := models.Notification{Type: "EVENT", TargetID: userId, Content: event.ID}
+	if err = handler.repos.NotifRepo.DeleteByType(invitation); err != nil {
+		return nil, errors.New("Internal server error")
+	}
+	/* ------------------------ share updated counters ------------------------ */
+	updated, err := handler.repos.EventRepo.GetEventWithResponses(event.ID, occurrence, userId)
+	if err != nil {
+		return nil, errors.New("Internal server error")
+	}
+	counters := *updated
+	counters.UserResponse, counters.WaitlistPosition = "", 0 // belong to current user
+	wsServer.SendToWatchers(event.ID, ws.WsMessage{
+		Action:         ws.EventResponsesAction,
+		EventResponses: &counters,
+	})
+	return updated, nil
+}
@@ -1,14 +1,19 @@
 package handlers
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"strings"
 
 	"social-network/pkg/models"
 	"social-network/pkg/utils"
 	ws "social-network/pkg/wsServer"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 /* -------------------------------------------------------------------------- */
@@ -232,12 +237,8 @@ func (handler *Handler) Follow(wsServer *ws.Server, w http.ResponseWriter, r *ht
 			return
 		}
 
-		// Create a follow-up request notification
-		for client := range wsServer.Clients {
-			if client.ID == reqUserId {
-				client.SendNotification(notification)
-			}
-		}
+		// Notify target user about the follow request if online
+		wsServer.SendNotification(reqUserId, notification)
 	}
 
 	utils.RespondWithSuccess(w, "Following successful", 200)
@@ -333,17 +334,58 @@ func (handler *Handler) ChatList(w http.ResponseWriter, r *http.Request) {
 	if userId == "" {
 		userId = r.Context().Value(utils.UserKey).(string)
 	}
+	users, err := handler.chatListUsers(userId)
+	if err != nil {
+		utils.RespondWithError(w, err.Error(), 200)
+		return
+	}
+	utils.RespondWithUsers(w, users, 200)
+}
+
+// returns presence (online + last seen) for every user in current user chat list
+// private profiles only expose presence to their followers
+func (handler *Handler) Presence(wsServer *ws.Server, w http.ResponseWriter, r *http.Request) {
+	w = utils.ConfigHeader(w)
+	currentUserId := r.Context().Value(utils.UserKey).(string)
+	users, err := handler.chatListUsers(currentUserId)
+	if err != nil {
+		utils.RespondWithError(w, err.Error(), 200)
+		return
+	}
+	userIDs := make([]string, len(users))
+	for i, user := range users {
+		userIDs[i] = user.ID
+	}
+	// users with hidden presence are missing
+	lastSeen, err := handler.repos.UserRepo.GetLastSeenBatch(userIDs, currentUserId)
+	if err != nil {
+		utils.RespondWithError(w, "Error on getting data", 200)
+		return
+	}
+	presence := []models.Presence{}
+	for _, user := range users {
+		userPresence := models.Presence{UserID: user.ID}
+		// hidden presence is reported as offline without last seen
+		if seen, visible := lastSeen[user.ID]; visible {
+			userPresence.Online = wsServer.IsOnline(user.ID)
+			userPresence.LastSeen = seen
+		}
+		presence = append(presence, userPresence)
+	}
+	utils.RespondWithPresence(w, presence, 200)
+}
+
+// users that are followed by user or have chat history with user
+func (handler *Handler) chatListUsers(userId string) ([]models.User, error) {
 	// request all  following users
 	followers, errUsers := handler.repos.UserRepo.GetFollowing(userId)
 	if errUsers != nil {
-		utils.RespondWithError(w, "Error on getting data", 200)
-		return
+		return nil, errors.New("Error on getting data")
 	}
 	// get users_ids that have a chat history
 	ids, errIds := handler.repos.MsgRepo.GetChatHistoryIds(userId)
 	if errIds != nil {
-		utils.RespondWithError(w, "Error on getting chat history", 200)
-		return
+		return nil, errors.New("Error on getting chat history")
 	}
 	// loop over chat history ids
 	// compare with followers
@@ -353,14 +395,12 @@ func (handler *Handler) ChatList(w http.ResponseWriter, r *http.Request) {
 		if !isPresent {
 			user, err := handler.repos.UserRepo.GetDataMin(currentId)
 			if err != nil {
-				utils.RespondWithError(w, "Error on getting chat history data", 200)
-				return
+				return nil, errors.New("Error on getting chat history data")
 			}
 			followers = append(followers, user)
 		}
 	}
-
-	utils.RespondWithUsers(w, followers, 200)
+	return followers, nil
 }
 
 /* -------------------------------------------------------------------------- */
@@ -391,6 +431,71 @@ func (handler *Handler) SearchUsers(w http.ResponseWriter, r *http.Request) {
 	utils.RespondWithUsers(w, users, 200)
 }
 
+// deletes account of current user after confirming password
+// owned groups go to successor (oldest moderator, otherwise oldest member)
+// groups without other members are deleted
+// waits for DELETE with JSON {password}
+func (handler *Handler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	w = utils.ConfigHeader(w)
+	if r.Method != "DELETE" {
+		utils.RespondWithError(w, "Method not allowed", 405)
+		return
+	}
+	var req struct {
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, "Invalid request body", 400)
+		return
+	}
+	userId := r.Context().Value(utils.UserKey).(string)
+	/* ----------------------------- confirm password ---------------------------- */
+	profile, err := handler.repos.UserRepo.GetProfileMax(userId)
+	if err != nil {
+		utils.RespondWithError(w, "Error on getting data", 200)
+		return
+	}
+	dbUser, err := handler.repos.UserRepo.FindUserByEmail(profile.Email)
+	if err != nil || bcrypt.CompareHashAndPassword([]byte(dbUser.Password), []byte(req.Password)) != nil {
+		utils.RespondWithError(w, "Wrong credentials", 401)
+		return
+	}
+	/* ------------------------------ delete account ----------------------------- */
+	// events of owned groups, their reminders go if the group is removed with account
+	groupIds, err := handler.repos.GroupRepo.GetOwnedGroups(userId)
+	if err != nil {
+		utils.RespondWithError(w, "Error on getting data", 200)
+		return
+	}
+	var events []models.Event
+	for _, groupId := range groupIds {
+		groupEvents, err := handler.repos.EventRepo.GetAll(groupId)
+		if err != nil {
+			utils.RespondWithError(w, "Error on getting data", 200)
+			return
+		}
+		events = append(events, groupEvents...)
+	}
+	// owned groups are handed over or removed together with account
+	images, err := handler.repos.UserRepo.Delete(userId)
+	if err != nil {
+		utils.RespondWithError(w, "Error on deleting account", 200)
+		return
+	}
+	for _, image := range images {
+		utils.DeleteImage(image)
+	}
+	for _, event := range events {
+		if _, err := handler.repos.EventRepo.GetData(event.ID); errors.Is(err, sql.ErrNoRows) {
+			if err = handler.cancelEventReminders(event.ID); err != nil {
+				log.Println("Error on cancelling event reminders:", err)
+			}
+		}
+	}
+	utils.DeleteCookie(w)
+	utils.RespondWithSuccess(w, "Account deleted", 200)
+}
+
 /* --------------------------------- helper --------------------------------- */
 func ContainsUser(list []models.User, id string) bool {
 	for _, value := range list {
@@ -1,18 +1,22 @@
 package handlers
 
 import (
+	"errors"
 	"log"
 	"net/http"
+	"social-network/pkg/models"
 	"social-network/pkg/utils"
 	ws "social-network/pkg/wsServer"
+	"strconv"
 
 	"github.com/gorilla/websocket"
 )
 
-var upgrader = websocket.Upgrader{} // use default options
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true }, //for CORS err
+}
 
 func (handler *Handler) SocketHandler(wsServer *ws.Server, w http.ResponseWriter, r *http.Request) {
-	upgrader.CheckOrigin = func(r *http.Request) bool { return true } //for CORS err
 
 	// access user id
 	userId := r.Context().Value(utils.UserKey).(string)
@@ -24,12 +28,149 @@ func (handler *Handler) SocketHandler(wsServer *ws.Server, w http.ResponseWriter
 		return
 	}
 
-	// crete new client
+	// reconnecting client can ask for events it missed with ?since=<last seen seq>
+	since, errSince := strconv.ParseInt(r.URL.Query().Get("since"), 10, 64)
+	resume := errSince == nil && since >= 0
+
+	// crete new client, live events wait until replay or sync is sent
 	client := ws.NewClient(conn, wsServer.Repos, userId)
+	client.BeginResume()
 	// register the clinet in wsServer
 	wsServer.RegisterNewClient(client)
 
 	// put in action infinit read and write functions
 	go client.Writer()
+	// replay missed events or just tell client where it stands
+	if resume {
+		wsServer.Resume(client, since)
+	} else {
+		wsServer.Sync(client)
+	}
 	go client.Reader(wsServer)
+	// messages sent while user was offline count as delivered now
+	handler.markAllDelivered(wsServer, userId)
+}
+
+/* -------------------------------------------------------------------------- */
+/*                   actions sent by client over websocket                    */
+/* -------------------------------------------------------------------------- */
+
+// register handlers for all actions that client can send through websocket
+// they share logic with corresponding http endpoints
+func (handler *Handler) RegisterSocketActions(wsServer *ws.Server) {
+	// new chat message, same as /newMessage
+	wsServer.Handle(ws.ChatSendAction, func(client *ws.Client, message ws.WsMessage) (string, error) {
+		msg := message.ChatMessage
+		msg.SenderId = client.ID
+		return handler.sendChatMessage(wsServer, msg)
+	})
+	// mark chat message as read, same as /messageRead
+	wsServer.Handle(ws.ChatReadAction, func(client *ws.Client, message ws.WsMessage) (string, error) {
+		msg := message.ChatMessage
+		msg.ReceiverId = client.ID
+		if err := handler.markMessageRead(wsServer, msg); err != nil {
+			return "", err
+		}
+		return "Message marked as read successfuly", nil
+	})
+	// edit own chat message, same as /editMessage
+	wsServer.Handle(ws.ChatEditAction, func(client *ws.Client, message ws.WsMessage) (string, error) {
+		return handler.editChatMessage(wsServer, client.ID, message.ChatMessage.ID, message.ChatMessage.Content)
+	})
+	// delete chat message for self or everyone, same as /deleteMessage
+	wsServer.Handle(ws.ChatDeleteAction, func(client *ws.Client, message ws.WsMessage) (string, error) {
+		return handler.deleteChatMessage(wsServer, client.ID, message.ChatMessage.ID, message.Message == "everyone")
+	})
+	// mark notification as read, same as /notifications/markAsRead
+	wsServer.Handle(ws.NotificationReadAction, func(client *ws.Client, message ws.WsMessage) (string, error) {
+		if message.Notification.ID == "" {
+			return "", errors.New("Notification ID is required")
+		}
+		marked, err := handler.repos.NotifRepo.MarkAsRead(message.Notification.ID, client.ID)
+		if err != nil {
+			return "", errors.New("Error marking notification as read")
+		}
+		if !marked {
+			return "", errors.New("Notification not found")
+		}
+		return "Notification marked as read", nil
+	})
+	// forward typing indicator to other party or other group members
+	wsServer.Handle(ws.ChatTypingAction, func(client *ws.Client, message ws.WsMessage) (string, error) {
+		return "", handler.forwardTyping(wsServer, client.ID, message.ChatMessage)
+	})
+	// receive live reaction counters of posts on screen, only posts user can see
+	wsServer.Handle(ws.PostWatchAction, func(client *ws.Client, message ws.WsMessage) (string, error) {
+		var allowed []string
+		for _, postId := range message.PostIDs {
+			post, err := handler.repos.PostRepo.GetData(postId)
+			if err != nil {
+				continue
+			}
+			if canAccess, err := handler.canViewPost(post, client.ID); err == nil && canAccess {
+				allowed = append(allowed, postId)
+			}
+		}
+		return "Watching " + strconv.Itoa(wsServer.Watch(client, allowed)) + " posts", nil
+	})
+	wsServer.Handle(ws.PostUnwatchAction, func(client *ws.Client, message ws.WsMessage) (string, error) {
+		wsServer.Unwatch(client, message.PostIDs)
+		return "Posts unwatched", nil
+	})
+	// receive live response counters of events on screen, only events of user groups
+	wsServer.Handle(ws.EventWatchAction, func(client *ws.Client, message ws.WsMessage) (string, error) {
+		var allowed []string
+		for _, eventId := range message.EventIDs {
+			event, err := handler.repos.EventRepo.GetData(eventId)
+			if err != nil {
+				continue
+			}
+			if isMember, err := handler.isGroupMember(event.GroupID, client.ID); err == nil && isMember {
+				allowed = append(allowed, eventId)
+			}
+		}
+		return "Watching " + strconv.Itoa(wsServer.Watch(client, allowed)) + " posts and events", nil
+	})
+	wsServer.Handle(ws.EventUnwatchAction, func(client *ws.Client, message ws.WsMessage) (string, error) {
+		wsServer.Unwatch(client, message.EventIDs)
+		return "Events unwatched", nil
+	})
+}
+
+// send typing indicator to receiver of the chat
+// for group chats sender must be a member, indicator goes to all other members who didn't mute the chat
+func (handler *Handler) forwardTyping(wsServer *ws.Server, senderId string, msg models.ChatMessage) error {
+	typing := ws.WsMessage{
+		Action:      ws.ChatTypingAction,
+		ChatMessage: models.ChatMessage{SenderId: senderId, ReceiverId: msg.ReceiverId, Type: msg.Type},
+	}
+	switch msg.Type {
+	case "PERSON":
+		wsServer.SendToUser(msg.ReceiverId, typing)
+	case "GROUP":
+		members, err := handler.repos.GroupRepo.GetMembers(msg.ReceiverId)
+		if err != nil {
+			return errors.New("Error on getting group members")
+		}
+		muted, err := handler.repos.MsgRepo.GetGroupChatMutes(msg.ReceiverId)
+		if err != nil {
+			return errors.New("Error on getting group chat mutes")
+		}
+		isMember := false
+		ids := make([]string, 0, len(members))
+		for _, member := range members {
+			if member.ID == senderId {
+				isMember = true
+			} else if !muted[member.ID] {
+				ids = append(ids, member.ID)
+			}
+		}
+		if !isMember {
+			return errors.New("Not a member")
+		}
+		wsServer.SendToUsers(ids, typing)
+	default:
+		return errors.New("Error. Message type not provided or not recognized")
+	}
+	return nil
 }
@@ -3,17 +3,30 @@ package models
 type Comment struct {
 	ID string `json:"id"`
 
-	PostID    string `json:"postId"`
-	Content   string `json:"content"`
-	ImagePath string `json:"image"`
-	AuthorID  string `json:"authorId"`
-	CreatedAt string `json:"createdAt"`
+	PostID    string  `json:"postId"`
+	ParentID  string  `json:"parentId"` // empty for top level comment
+	Content   string  `json:"content"`
+	ImagePath string  `json:"image"`
+	AuthorID  string  `json:"authorId"`
+	CreatedAt string  `json:"createdAt"`
+	EditedAt  *string `json:"editedAt"` // nil if never edited
 	// for sending back with author
 	Author User `json:"author"`
+	// nested replies, chronological
+	Replies    []Comment `json:"replies"`
+	ReplyCount int       `json:"replyCount"` // all replies below comment
+	// reactions summary
+	Reactions  map[string]int `json:"reactions"`  // reaction type -> count
+	MyReaction string         `json:"myReaction"` // reaction of current user, empty if none
 }
 
 type CommentRepository interface {
 	// get comment based on postID
 	Get(postID string) ([]Comment, error)
+	// get comments of many posts at once, keyed by post id
+	GetForPosts(postIDs []string) (map[string][]Comment, error)
 	New(Comment) error
+	Update(Comment) error                      // change content and image, marks comment as edited
+	Delete(commentID string) error             // delete comment with all replies and their reactions
+	GetData(commentID string) (Comment, error) // get single comment
 }
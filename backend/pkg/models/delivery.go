@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// websocket event saved for user, so it can be replayed after reconnect
+type QueuedEvent struct {
+	UserID    string
+	Seq       int64  // per user, monotonically increasing
+	Payload   []byte // encoded websocket message
+	CreatedAt time.Time
+}
+
+type DeliveryRepository interface {
+	// save event for every user in one transaction, returns sequence number of each user
+	Append(userIDs []string, payload []byte) (map[string]int64, error)
+	// get events with sequence number greater than seq in order, at most limit
+	GetSince(userID string, seq int64, limit int) ([]QueuedEvent, error)
+	// oldest retained and last assigned sequence number, 0 if none
+	GetBounds(userID string) (oldest int64, last int64, err error)
+	// delete events of all users created before provided time
+	Prune(before time.Time) error
+}
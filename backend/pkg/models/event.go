@@ -11,6 +11,17 @@ type Event struct {
 	Date        string    `json:"date"`        // Keep as string for compatibility
 	DateTime    time.Time `json:"dateTime"`    // For proper time handling
 	CreatedAt   time.Time `json:"createdAt"`
+
+	EditedAt    *string   `json:"editedAt"`
+	CancelledAt *string   `json:"cancelledAt"` // cancelled events are kept, but can't be answered
+
+	// most users going, 0 if unlimited, others are waitlisted
+	Capacity int `json:"capacity,omitempty"`
+
+	// repetition rule, nil for single events
+	Recurrence *Recurrence `json:"recurrence,omitempty"`
+	// start of expanded occurrence of recurring event (RFC3339, UTC)
+	Occurrence string `json:"occurrence,omitempty"`
 	
 	// Author info for display
 	Author User `json:"author"`
@@ -19,10 +30,16 @@ type Event struct {
 type EventResponse struct {
 	EventID  string `json:"eventId"`
 	UserID   string `json:"userId"`
-	Response string `json:"response"` // "going", "not_going", "maybe"
+	Response string `json:"response"` // "going", "not_going", "maybe", "waitlisted"
 	UserName string `json:"userName"`
 }
 
+// outcome of saving response
+type RSVPResult struct {
+	Response string   // saved response, "waitlisted" if event was full
+	Promoted []string // waitlisted users that got free spot and are going now
+}
+
 type EventWithResponses struct {
 	Event
 	Responses    []EventResponse `json:"responses"`
@@ -30,18 +47,35 @@ type EventWithResponses struct {
 	GoingCount   int            `json:"goingCount"`
 	NotGoingCount int           `json:"notGoingCount"`
 	MaybeCount   int            `json:"maybeCount"`
+	WaitlistCount int           `json:"waitlistCount"`
+	WaitlistPosition int        `json:"waitlistPosition,omitempty"` // current user's place in waitlist, from 1
 }
 
 type EventRepository interface {
 	GetAll(groupId string) ([]Event, error)
 	GetData(eventID string) (Event, error)
 	Save(Event) error
-	AddParticipant(eventID, userID string) error
-	RemoveParticipant(eventID, userID string) error
-	IsParticipating(eventID, userID string) (bool, error)
 	
 	// New methods for RSVP functionality
-	UpdateResponse(eventID, userID, response string) error
-	GetEventWithResponses(eventID, currentUserID string) (*EventWithResponses, error)
-	GetGroupEventsWithResponses(groupID, currentUserID string) ([]EventWithResponses, error)
+	// occurrence is start of occurrence for recurring events, empty otherwise
+	// going to full event is waitlisted, leaving going promotes oldest waitlisted users
+	UpdateResponse(eventID, occurrence, userID, response string) (RSVPResult, error)
+	GetEventWithResponses(eventID, occurrence, currentUserID string) (*EventWithResponses, error)
+	// recurring events are expanded to occurrences starting in [from, to), zero bound is open
+	GetGroupEventsWithResponses(groupID, currentUserID string, from, to time.Time) ([]EventWithResponses, error)
+
+	// saves new title, content and date, responses of recurring event move with its occurrences
+	Update(Event) error
+	// marks event as cancelled
+	Cancel(eventID string) error
+	// ids of users that answered event (any occurrence)
+	GetRespondents(eventID string) ([]string, error)
+	// ids of users going or maybe going to event (or its occurrence)
+	GetAttendees(eventID, occurrence string) ([]string, error)
+
+	// events not cancelled that start after t or repeat, for scheduling reminders on start
+	GetUpcoming(t time.Time) ([]Event, error)
+
+	// events user is going to or maybe going to, for calendar feed
+	GetUserCalendar(userID string) ([]Event, error)
 }
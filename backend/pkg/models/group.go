@@ -1,5 +1,33 @@
 package models
 
+import "time"
+
+// roles of group members, owner is also groups administrator
+const (
+	RoleOwner     = "owner"
+	RoleModerator = "moderator" // approves requests, removes posts and members
+	RoleMember    = "member"
+)
+
+// actions kept in group moderation audit trail
+const (
+	ModerationKick   = "kick"
+	ModerationBan    = "ban"
+	ModerationUnban  = "unban"
+	ModerationMute   = "mute"
+	ModerationUnmute = "unmute"
+)
+
+// rules that accept join request without moderator
+const (
+	AutoApproveNone                = ""
+	AutoApproveFollowedByOwner     = "followed_by_owner"     // requester is followed by owner
+	AutoApproveFollowedByModerator = "followed_by_moderator" // requester is followed by owner or any moderator
+)
+
+// most questions private group can ask
+const MaxJoinQuestions = 3
+
 type Group struct {
 	ID          string `json:"id"`
 	Name        string `json:"name"`
@@ -11,15 +39,21 @@ type Group struct {
 
 	Invitations []string `json:"invitations"`
 
-	Member         bool `json:"member"`         // true if current user is a member
-	Administrator  bool `json:"admin"`          // true if current user is admin
-	RequestPending bool `json:"requestPending"` // true if request to join is pending
+	Member         bool   `json:"member"`         // true if current user is a member
+	Administrator  bool   `json:"admin"`          // true if current user is admin
+	RequestPending bool   `json:"requestPending"` // true if request to join is pending
+	Role           string `json:"role,omitempty"` // role of current user, empty if not a member
+	ChatMuted      bool   `json:"chatMuted"`      // true if current user muted group chat
 }
 
 type GroupRepository interface {
 	GetAllAndRelations(userId string) ([]Group, error)
 	GetUserGroups(userId string) ([]Group, error)
-	New(Group) error                               //create new group
+	New(Group) error    //create new group
+	Update(Group) error // save new name, description, privacy and image
+	// removes group with members, posts, events, chat and related notifications
+	// returns images to delete from disk
+	Delete(groupId string) ([]string, error)
 	GetData(groupId string) (Group, error)         //get info- name and desc
 	GetMembers(groupId string) ([]User, error)     // get all group members and admin
 	GetAdmin(groupId string) (string, error)       //get admin id
@@ -28,4 +62,56 @@ type GroupRepository interface {
 
 	SaveMember(userId, groupId string) error
 	RemoveMember(userId, groupId string) error
+
+	GetRole(groupId, userId string) (string, error)     // role of user, empty if not a member
+	IsModerator(groupId, userId string) (bool, error)   // true for owner and moderators
+	GetModerators(groupId string) ([]string, error)     // ids of owner and moderators
+	SetRole(groupId, userId, role string) error         // change role of member, not for owner
+	TransferOwnership(groupId, newOwnerId string) error // previous owner becomes moderator
+	// member that takes over group from owner: oldest moderator, otherwise oldest member
+	// empty if owner is the only member
+	GetSuccessor(groupId string) (string, error)
+	GetOwnedGroups(userId string) ([]string, error) // ids of groups user owns
+
+	Ban(groupId, userId, bannedBy string) error // removes member with pending requests and invites
+	Unban(groupId, userId string) error
+	IsBanned(groupId, userId string) (bool, error)
+	Mute(groupId, userId string, until time.Time) error
+	Unmute(groupId, userId string) error
+	GetMutedUntil(groupId, userId string) (time.Time, error) // zero time if not muted
+
+	GetJoinRules(groupId string) (JoinRules, error)
+	SetJoinRules(groupId string, rules JoinRules) error
+	SaveJoinAnswers(groupId, userId string, answers []JoinAnswer) error // replaces previous answers of user
+	GetJoinAnswers(groupId string) (map[string][]JoinAnswer, error)     // answers keyed by user id
+	DeleteJoinAnswers(groupId, userId string) error
+
+	SaveModerationAction(ModerationAction) error
+	GetModerationLog(groupId string, page Page) ([]ModerationAction, *Cursor, error) // newest first
+}
+
+// what private group asks before user can join
+type JoinRules struct {
+	Questions   []string `json:"questions"`
+	AutoApprove string   `json:"autoApprove"` // one of AutoApprove rules, only shown to moderators
+}
+
+type JoinAnswer struct {
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+}
+
+// entry of group moderation audit trail
+type ModerationAction struct {
+	ID        string `json:"id"`
+	GroupID   string `json:"groupId"`
+	ActorID   string `json:"actorId"`  // owner or moderator who acted
+	TargetID  string `json:"targetId"` // member acted on
+	Action    string `json:"action"`   // kick, ban, unban, mute or unmute
+	Reason    string `json:"reason"`
+	ExpiresAt string `json:"expiresAt,omitempty"` // end of mute, RFC3339
+	CreatedAt string `json:"createdAt"`
+
+	Actor  User `json:"actor"`
+	Target User `json:"target"`
 }
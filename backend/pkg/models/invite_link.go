@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// shareable link to join group, token is the secret part of the link
+type InviteLink struct {
+	ID        string     `json:"id"`
+	GroupID   string     `json:"groupId"`
+	Token     string     `json:"token"`
+	CreatedBy string     `json:"createdBy"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"` // nil never expires
+	MaxUses   int        `json:"maxUses,omitempty"`   // 0 is unlimited
+	Uses      int        `json:"uses"`                // users that joined through link
+	Revoked   bool       `json:"-"`
+	CreatedAt string     `json:"createdAt"`
+}
+
+// false if link was revoked, expired or used up
+func (link InviteLink) Active(now time.Time) bool {
+	if link.Revoked || (link.ExpiresAt != nil && !now.Before(*link.ExpiresAt)) {
+		return false
+	}
+	return link.MaxUses == 0 || link.Uses < link.MaxUses
+}
+
+type InviteLinkRepository interface {
+	Save(InviteLink) error
+	GetByToken(token string) (InviteLink, error)
+	GetAll(groupId string) ([]InviteLink, error) // links of group not revoked, newest first
+	Revoke(groupId, linkId string) (bool, error) // false if group has no such active link
+	// counts use of link by user, false if link is used up or user already used it
+	Use(linkId, userId string) (bool, error)
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInviteLinkActive(t *testing.T) {
+	now := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Minute), now.Add(time.Minute)
+	tests := []struct {
+		name string
+		link InviteLink
+		want bool
+	}{
+		{"unlimited", InviteLink{}, true},
+		{"uses left", InviteLink{MaxUses: 2, Uses: 1}, true},
+		{"used up", InviteLink{MaxUses: 2, Uses: 2}, false},
+		{"revoked", InviteLink{Revoked: true}, false},
+		{"not expired", InviteLink{ExpiresAt: &future}, true},
+		{"expired", InviteLink{ExpiresAt: &past}, false},
+		{"expires now", InviteLink{ExpiresAt: &now}, false},
+	}
+	for _, test := range tests {
+		if got := test.link.Active(now); got != test.want {
+			t.Errorf("%s: Active = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// background job run by scheduler at RunAt
+type Job struct {
+	ID        string
+	Type      string // selects function that runs the job
+	Key       string // unique per type, scheduling same key again is ignored
+	Payload   string // job data, usually json
+	RunAt     time.Time
+	Attempts  int
+	LastError string
+}
+
+type JobRepository interface {
+	// save job, ignored if job with same type and key exists
+	Schedule(job Job) error
+	// jobs not done yet with RunAt not after now, oldest first, at most limit
+	GetDue(now time.Time, limit int) ([]Job, error)
+	// mark job as done, it won't run again
+	Complete(jobID string, at time.Time) error
+	// count failed attempt and move job to runAt
+	Retry(jobID string, runAt time.Time, reason string) error
+	// delete jobs of type not run yet whose key starts with prefix
+	CancelPending(jobType, keyPrefix string) error
+	// saves notification of job for its target together with the mark that job reached them
+	// false if it already did, nothing is saved then
+	NotifyOnce(jobID string, notif Notification) (bool, error)
+}
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type ChatMessage struct {
 	ID         string `json:"id"`
 	SenderId   string `json:"senderId"`
@@ -7,6 +9,24 @@ type ChatMessage struct {
 	Type       string `json:"type"` //GROUP|PERSON
 	Content    string `json:"content"`
 	Sender User `json:"sender"`
+
+	ReplyToID string       `json:"replyToId,omitempty"` // id of quoted message from same chat
+	ReplyTo   *ChatMessage `json:"replyTo,omitempty"`   // quoted message with sender, missing if it was deleted
+	Edited    bool         `json:"edited"`              // content changed by sender after sending
+	Deleted   bool         `json:"deleted"`             // deleted for everyone, content is empty
+
+	DeliveredAt *string `json:"deliveredAt,omitempty"` // private chat, nil until receiver was online
+	ReadAt      *string `json:"readAt,omitempty"`      // private chat, nil until receiver read it
+	ReadCount   int     `json:"readCount,omitempty"`   // group chat, number of members who read it
+}
+
+// delivery and read time of message for one recipient
+type MessageReceipt struct {
+	MessageID   string  `json:"messageId"`
+	UserID      string  `json:"userId"`
+	DeliveredAt *string `json:"deliveredAt"` // nil until recipient was online
+	ReadAt      *string `json:"readAt"`      // nil until recipient read it
+	User        *User   `json:"user,omitempty"`
 }
 
 type ChatStats struct {
@@ -19,15 +39,37 @@ type MsgRepository interface {
 	Save(ChatMessage) error
 	//get all for specific chat
 	// needs  RECEIVER and SENDER as input
-	GetAll(ChatMessage) ([]ChatMessage, error)
-	GetAllGroup(userId, groupId string) ([]ChatMessage, error)
+	// page goes back from newest messages, returns cursor of older page
+	GetAll(ChatMessage, Page) ([]ChatMessage, *Cursor, error)
+	GetAllGroup(userId, groupId string, page Page) ([]ChatMessage, *Cursor, error)
 	GetUnread(userId string) ([]ChatStats, error)
 	GetUnreadGroup(userId string) ([]ChatStats, error)
-	// mark as read
-	MarkAsRead(ChatMessage) error
-	MarkAsReadGroup(ChatMessage) error
+	// mark as read, true if message wasn't read before
+	MarkAsRead(ChatMessage) (bool, error)
+	MarkAsReadGroup(ChatMessage) (bool, error)
+	// msg needs ID, Type and ReceiverId of recipient, true if message wasn't delivered before
+	MarkDelivered(ChatMessage) (bool, error)
+	// marks everything sent to user as delivered, returns messages that weren't delivered before
+	MarkAllDelivered(userId string) ([]ChatMessage, error)
+	GetReceipt(messageId, userId string) (MessageReceipt, error)
+	GetReceipts(messageId string) ([]MessageReceipt, error) // one for each recipient
+
+	// mentioned receivers see message as unread even if they muted the chat
+	SaveGroupMsg(msg ChatMessage, mentioned bool) error
+	GetData(messageId string) (ChatMessage, error)
+
+	// sender changes content of message not older than window, false if window passed or message deleted
+	Edit(messageId, content string, window time.Duration) (bool, error)
+	// clears content and leaves tombstone for all participants
+	DeleteForEveryone(messageId string) error
+	// hides message from chat history of user only
+	DeleteForUser(messageId, userId string) error
 
-	SaveGroupMsg(ChatMessage) error
+	// muted group chat doesn't push messages or count them as unread, except mentions
+	MuteGroupChat(groupId, userId string) error
+	UnmuteGroupChat(groupId, userId string) error
+	IsGroupChatMuted(groupId, userId string) (bool, error)
+	GetGroupChatMutes(groupId string) (map[string]bool, error) // ids of members who muted chat
 
 	//returns list of user id's that hve chat history with provided user
 	GetChatHistoryIds(userId string)(map[string]bool, error)
@@ -12,6 +12,8 @@ type Notification struct {
 	User  User  `json:"user"`
 	Event Event `json:"event"`
 	Group Group `json:"group"`
+
+	Answers []JoinAnswer `json:"answers,omitempty"` // answers to group questions, only for GROUP_REQUEST
 }
 
 type NotifRepository interface {
@@ -19,6 +21,7 @@ type NotifRepository interface {
 	Delete(notificationId string) error
 	DeleteByType(Notification)error
 	CheckIfExists(Notification)(bool, error) // true if exists, false otherwise
+	DeleteSent(Notification) error // removes notification of type and content that sender sent to target
 	
 	//get all pending requests to join group
 	GetGroupRequests(groupId string) ([]Notification, error)
@@ -26,8 +29,8 @@ type NotifRepository interface {
 	GetUserFromRequest(notificationId string) (string, error)
 	// get group id from specific request
 	GetGroupId(notificationId string) (string, error)
-	// get all notifications for client
-	GetAll(userId string) ([]Notification, error)
+	// get page of notifications for client, newest first
+	GetAll(userId string, page Page) ([]Notification, *Cursor, error)
 	// get all pending invites for a group
 	GetGroupInvites(groupId string) ([]Notification, error)
 	// delete a specific group invite
@@ -37,8 +40,9 @@ type NotifRepository interface {
 	// get content form chat_request notification
 	GetContentFromChatRequest(senderId, receiverId string)(string, error)
 	CheckIfChatRequestExists(senderId, receiverId string)(bool, error) // true if exists, false otherwise
-	// mark notification as read
-	MarkAsRead(notificationId string) error
+	// mark notification as read, only one user sees in GetAll
+	// false if user can't see notification
+	MarkAsRead(notificationId, userId string) (bool, error)
 	// mark all notifications for a user as read
 	MarkAllAsRead(userId string) error
 }
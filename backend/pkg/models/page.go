@@ -0,0 +1,14 @@
+package models
+
+// position in list ordered by created_at and id
+// zero value points before first row
+type Cursor struct {
+	CreatedAt string `json:"c"`
+	ID        string `json:"i"`
+}
+
+// one page of list, rows strictly after cursor are returned
+type Page struct {
+	After Cursor
+	Limit int
+}
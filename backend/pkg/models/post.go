@@ -1,27 +1,50 @@
 package models
 
 type Post struct {
-	ID         string `json:"id"`
-	Content    string `json:"content"`
-	ImagePath  string `json:"image"`
-	AuthorID   string `json:"authorId"`
-	Visibility string `json:"visibility"`
-	GroupID    string `json:"groupId"`
-	CreatedAt  string `json:"createdAt"`
+	ID         string  `json:"id"`
+	Content    string  `json:"content"`
+	ImagePath  string  `json:"image"`
+	AuthorID   string  `json:"authorId"`
+	Visibility string  `json:"visibility"`
+	GroupID    string  `json:"groupId"`
+	CreatedAt  string  `json:"createdAt"`
+	EditedAt   *string `json:"editedAt"` // nil if never edited
+	// group posts only
+	PinnedAt    *string `json:"pinnedAt"`    // nil if not pinned
+	AnnouncedAt *string `json:"announcedAt"` // nil if not announcement
 	// for sending back with author
 	Author   User      `json:"author"`
 	Comments []Comment `json:"comments"`
+	// reactions summary
+	Reactions  map[string]int `json:"reactions"`  // reaction type -> count
+	MyReaction string         `json:"myReaction"` // reaction of current user, empty if none
 }
 
 type PostRepository interface {
-	// Get all posts that user have access to
-	GetAll(userID string) ([]Post, error)
-	// get user posts that current user have access to
-	GetUserPosts(userID, currentUserID string) ([]Post, error)
-	// get group psts from specific group
-	GetGroupPosts(groupId string) ([]Post, error)
+	// Get page of posts that user have access to, newest first
+	// returns cursor of next page, nil on last page
+	GetAll(userID string, page Page) ([]Post, *Cursor, error)
+	// get page of user posts that current user have access to
+	GetUserPosts(userID, currentUserID string, page Page) ([]Post, *Cursor, error)
+	// get page of group psts from specific group
+	// first page starts with all pinned posts, they are not repeated on later pages
+	GetGroupPosts(groupId string, page Page) ([]Post, *Cursor, error)
 
 	New(Post) error
+	// change content, image and visibility, marks post as edited
+	// non nil access replaces users that can see post with its visibility, in the same transaction
+	Update(post Post, access []string) error
+	// delete post with its comments, reactions and access rows
+	Delete(postID string) error
+	GetData(postID string) (Post, error) // get single post, groupId empty for user posts
+	// true if user can see non group post (visibility rules of GetAll)
+	CanAccess(postID, userID string) (bool, error)
+
+	// pin group post, false if already pinned or group has limit pinned posts
+	Pin(postID string, limit int) (bool, error)
+	Unpin(postID string) error
+	// mark group post as announcement, false if it already is one
+	Announce(postID string) (bool, error)
 
 	SaveAccess(postId, userId string) error        // save access for almost_private post
 	SavePrivateAccess(postId, userId string) error // save access for private post
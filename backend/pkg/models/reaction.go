@@ -0,0 +1,34 @@
+package models
+
+// reaction types user can leave on post or comment
+var ReactionTypes = []string{"LIKE", "LOVE", "HAHA", "WOW", "SAD", "ANGRY"}
+
+// reaction of one user on post or comment
+type Reaction struct {
+	TargetID   string `json:"targetId"`
+	TargetType string `json:"targetType"` // POST|COMMENT
+	UserID     string `json:"userId"`
+	Type       string `json:"type"` // one of ReactionTypes
+}
+
+// aggregated reactions of post or comment
+type ReactionSummary struct {
+	TargetID   string         `json:"targetId"`
+	TargetType string         `json:"targetType"` // POST|COMMENT
+	PostID     string         `json:"postId"`     // post that target belongs to
+	Counts     map[string]int `json:"counts"`     // reaction type -> count
+	MyReaction string         `json:"myReaction,omitempty"`
+}
+
+type ReactionRepository interface {
+	// reaction type left by user on target, empty if none
+	Get(targetID, userID string) (string, error)
+	// add reaction or replace previous one of the same user
+	Set(Reaction) error
+	Delete(targetID, userID string) error
+
+	// counts per reaction type for each target, targets without reactions are missing
+	GetCounts(targetIDs []string) (map[string]map[string]int, error)
+	// reaction type of user for each target, targets without reaction are missing
+	GetUserReactions(userID string, targetIDs []string) (map[string]string, error)
+}
@@ -0,0 +1,220 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// most occurrences a series can have or expand to at once
+	MaxOccurrences = 500
+	// how far ahead open ended series are expanded
+	RecurrenceHorizon = 90 * 24 * time.Hour
+)
+
+// repetition rule of event, subset of RFC 5545 RRULE
+type Recurrence struct {
+	Freq       string   `json:"freq"`                 // DAILY|WEEKLY|MONTHLY
+	Interval   int      `json:"interval"`             // every n-th day/week/month, 1 if empty
+	Count      int      `json:"count,omitempty"`      // number of occurrences, 0 if unlimited
+	Until      string   `json:"until,omitempty"`      // RFC3339, last possible start
+	Exceptions []string `json:"exceptions,omitempty"` // RFC3339 starts of skipped occurrences
+}
+
+// checks rule and fills defaults
+func (rule *Recurrence) Validate() error {
+	rule.Freq = strings.ToUpper(rule.Freq)
+	if rule.Freq != "DAILY" && rule.Freq != "WEEKLY" && rule.Freq != "MONTHLY" {
+		return errors.New("Unknown recurrence frequency")
+	}
+	if rule.Interval == 0 {
+		rule.Interval = 1
+	}
+	if rule.Interval < 0 || rule.Count < 0 || rule.Count > MaxOccurrences {
+		return errors.New("Invalid recurrence interval or count")
+	}
+	if rule.Count > 0 && rule.Until != "" {
+		return errors.New("Recurrence can't have both count and until")
+	}
+	if rule.Until != "" {
+		until, err := time.Parse(time.RFC3339, rule.Until)
+		if err != nil {
+			return errors.New("Invalid recurrence until date")
+		}
+		rule.Until = OccurrenceKey(until)
+	}
+	for i, exception := range rule.Exceptions {
+		t, err := time.Parse(time.RFC3339, exception)
+		if err != nil {
+			return errors.New("Invalid recurrence exception date")
+		}
+		rule.Exceptions[i] = OccurrenceKey(t)
+	}
+	return nil
+}
+
+// identifies occurrence of series, RFC3339 in UTC
+func OccurrenceKey(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// RRULE value, e.g. FREQ=WEEKLY;INTERVAL=2;COUNT=10
+func (rule Recurrence) RRule() string {
+	parts := []string{"FREQ=" + rule.Freq, "INTERVAL=" + strconv.Itoa(max(rule.Interval, 1))}
+	if rule.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(rule.Count))
+	}
+	if until, err := time.Parse(time.RFC3339, rule.Until); err == nil {
+		parts = append(parts, "UNTIL="+until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// reads rule saved with RRule, exceptions are stored separately
+func ParseRRule(value string, exceptions []string) (*Recurrence, error) {
+	rule := &Recurrence{Exceptions: exceptions}
+	for _, part := range strings.Split(value, ";") {
+		key, val, _ := strings.Cut(part, "=")
+		var err error
+		switch key {
+		case "FREQ":
+			rule.Freq = val
+		case "INTERVAL":
+			rule.Interval, err = strconv.Atoi(val)
+		case "COUNT":
+			rule.Count, err = strconv.Atoi(val)
+		case "UNTIL":
+			var until time.Time
+			until, err = time.Parse("20060102T150405Z", val)
+			rule.Until = until.Format(time.RFC3339)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return rule, rule.Validate()
+}
+
+// start of n-th occurrence (0 is series start), months keep day of month
+// and skip months that are too short for it
+func (rule Recurrence) nth(start time.Time, n int) (time.Time, bool) {
+	step := n * max(rule.Interval, 1)
+	switch rule.Freq {
+	case "DAILY":
+		return start.AddDate(0, 0, step), true
+	case "WEEKLY":
+		return start.AddDate(0, 0, 7*step), true
+	}
+	next := start.AddDate(0, step, 0)
+	return next, next.Day() == start.Day()
+}
+
+// index of occurrence to start expanding from, close before from so far away windows
+// cost as much as near ones; monthly series with count start at 0 as their
+// missing months don't count towards it
+func (rule Recurrence) firstIndex(start, from time.Time) int {
+	if !from.After(start) || (rule.Freq == "MONTHLY" && rule.Count > 0) {
+		return 0
+	}
+	interval := int64(max(rule.Interval, 1))
+	var steps int64
+	switch rule.Freq {
+	case "DAILY":
+		steps = (from.Unix() - start.Unix()) / (24 * 60 * 60) / interval
+	case "WEEKLY":
+		steps = (from.Unix() - start.Unix()) / (7 * 24 * 60 * 60) / interval
+	default:
+		steps = (int64(from.Year()-start.Year())*12 + int64(from.Month()-start.Month())) / interval
+	}
+	// one step back covers daylight saving shifts
+	return int(max(steps-1, 0))
+}
+
+// starts of occurrences in [from, to), exceptions excluded
+// zero to means RecurrenceHorizon from now, at most MaxOccurrences are returned
+func (rule Recurrence) Occurrences(start, from, to time.Time) []time.Time {
+	if to.IsZero() {
+		to = time.Now().Add(RecurrenceHorizon)
+	}
+	until, hasUntil := time.Time{}, rule.Until != ""
+	if hasUntil {
+		until, _ = time.Parse(time.RFC3339, rule.Until)
+	}
+	skipped := make(map[int64]bool, len(rule.Exceptions))
+	for _, exception := range rule.Exceptions {
+		if t, err := time.Parse(time.RFC3339, exception); err == nil {
+			skipped[t.Unix()] = true
+		}
+	}
+
+	var result []time.Time
+	first := rule.firstIndex(start, from)
+	generated := first // skipped daily and weekly occurrences all exist
+	for n := first; ; n++ {
+		occurrence, ok := rule.nth(start, n)
+		if !occurrence.Before(to) || (hasUntil && occurrence.After(until)) {
+			break
+		}
+		if !ok {
+			// missing day in month, still counts towards the limit of loop
+			if n-first > MaxOccurrences*12 {
+				break
+			}
+			continue
+		}
+		generated++
+		if rule.Count > 0 && generated > rule.Count {
+			break
+		}
+		if !occurrence.Before(from) && !skipped[occurrence.Unix()] {
+			result = append(result, occurrence)
+			if len(result) == MaxOccurrences {
+				break
+			}
+		}
+	}
+	return result
+}
+
+// first occurrence starting at or after from, false if series ends before it
+func (rule Recurrence) Next(start, from time.Time) (time.Time, bool) {
+	// longest gap is few missing days of month (Feb 29 recurs every 4 years) plus skipped exceptions
+	steps := max(rule.Interval, 1) * (len(rule.Exceptions) + 1)
+	var to time.Time
+	switch rule.Freq {
+	case "DAILY":
+		to = from.AddDate(0, 0, steps+1)
+	case "WEEKLY":
+		to = from.AddDate(0, 0, 7*(steps+1))
+	default:
+		to = from.AddDate(0, 48*steps+1, 0)
+	}
+	next := rule.Occurrences(start, from, to)
+	if len(next) == 0 {
+		return time.Time{}, false
+	}
+	return next[0], true
+}
+
+// true if t is start of occurrence of series (exceptions are not)
+func (rule Recurrence) IsOccurrence(start, t time.Time) bool {
+	occurrences := rule.Occurrences(start, t, t.Add(time.Second))
+	return len(occurrences) == 1 && occurrences[0].Equal(t)
+}
+
+// copy of rule for series moved by d, exceptions and until move with it
+func (rule Recurrence) Shift(d time.Duration) *Recurrence {
+	shifted := rule
+	shifted.Exceptions = make([]string, 0, len(rule.Exceptions))
+	for _, exception := range rule.Exceptions {
+		if t, err := time.Parse(time.RFC3339, exception); err == nil {
+			shifted.Exceptions = append(shifted.Exceptions, OccurrenceKey(t.Add(d)))
+		}
+	}
+	if until, err := time.Parse(time.RFC3339, rule.Until); err == nil {
+		shifted.Until = OccurrenceKey(until.Add(d))
+	}
+	return &shifted
+}
@@ -0,0 +1,178 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func date(value string) time.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func keys(times []time.Time) []string {
+	result := make([]string, len(times))
+	for i, t := range times {
+		result[i] = OccurrenceKey(t)
+	}
+	return result
+}
+
+func TestOccurrences(t *testing.T) {
+	start := date("2025-01-31T18:00:00Z")
+	tests := []struct {
+		name     string
+		rule     Recurrence
+		from, to string
+		want     []string
+	}{
+		{
+			name: "daily every second day",
+			rule: Recurrence{Freq: "DAILY", Interval: 2},
+			from: "2025-01-31T18:00:00Z", to: "2025-02-06T00:00:00Z",
+			want: []string{"2025-01-31T18:00:00Z", "2025-02-02T18:00:00Z", "2025-02-04T18:00:00Z"},
+		},
+		{
+			name: "from excludes earlier occurrences",
+			rule: Recurrence{Freq: "DAILY", Interval: 1},
+			from: "2025-02-02T18:00:00Z", to: "2025-02-04T18:00:00Z",
+			want: []string{"2025-02-02T18:00:00Z", "2025-02-03T18:00:00Z"},
+		},
+		{
+			name: "weekly with count",
+			rule: Recurrence{Freq: "WEEKLY", Interval: 1, Count: 2},
+			from: "2025-01-01T00:00:00Z", to: "2025-12-31T00:00:00Z",
+			want: []string{"2025-01-31T18:00:00Z", "2025-02-07T18:00:00Z"},
+		},
+		{
+			name: "monthly skips months without day 31",
+			rule: Recurrence{Freq: "MONTHLY", Interval: 1},
+			from: "2025-01-01T00:00:00Z", to: "2025-06-01T00:00:00Z",
+			want: []string{"2025-01-31T18:00:00Z", "2025-03-31T18:00:00Z", "2025-05-31T18:00:00Z"},
+		},
+		{
+			name: "monthly count ignores missing months",
+			rule: Recurrence{Freq: "MONTHLY", Interval: 1, Count: 3},
+			from: "2025-04-01T00:00:00Z", to: "2026-01-01T00:00:00Z",
+			want: []string{"2025-05-31T18:00:00Z"},
+		},
+		{
+			name: "until is last possible start",
+			rule: Recurrence{Freq: "DAILY", Interval: 1, Until: "2025-02-02T18:00:00Z"},
+			from: "2025-01-01T00:00:00Z", to: "2025-12-31T00:00:00Z",
+			want: []string{"2025-01-31T18:00:00Z", "2025-02-01T18:00:00Z", "2025-02-02T18:00:00Z"},
+		},
+		{
+			name: "exceptions are skipped",
+			rule: Recurrence{Freq: "DAILY", Interval: 1, Exceptions: []string{"2025-02-01T18:00:00Z"}},
+			from: "2025-01-31T00:00:00Z", to: "2025-02-03T00:00:00Z",
+			want: []string{"2025-01-31T18:00:00Z", "2025-02-02T18:00:00Z"},
+		},
+		{
+			name: "far future window",
+			rule: Recurrence{Freq: "WEEKLY", Interval: 3},
+			from: "9000-01-01T00:00:00Z", to: "9000-02-01T00:00:00Z",
+			want: []string{"9000-01-17T18:00:00Z"},
+		},
+		{
+			name: "far future monthly window",
+			rule: Recurrence{Freq: "MONTHLY", Interval: 2},
+			from: "9000-01-01T00:00:00Z", to: "9000-06-01T00:00:00Z",
+			want: []string{"9000-01-31T18:00:00Z", "9000-03-31T18:00:00Z", "9000-05-31T18:00:00Z"},
+		},
+		{
+			name: "window before start",
+			rule: Recurrence{Freq: "DAILY", Interval: 1},
+			from: "2024-01-01T00:00:00Z", to: "2025-01-01T00:00:00Z",
+			want: []string{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := keys(test.rule.Occurrences(start, date(test.from), date(test.to)))
+			if len(got) != len(test.want) {
+				t.Fatalf("got %v, want %v", got, test.want)
+			}
+			for i := range got {
+				if got[i] != test.want[i] {
+					t.Fatalf("got %v, want %v", got, test.want)
+				}
+			}
+		})
+	}
+}
+
+func TestOccurrencesLimit(t *testing.T) {
+	rule := Recurrence{Freq: "DAILY", Interval: 1}
+	start := date("2025-01-01T00:00:00Z")
+	got := rule.Occurrences(start, start, date("2030-01-01T00:00:00Z"))
+	if len(got) != MaxOccurrences {
+		t.Fatalf("got %d occurrences, want %d", len(got), MaxOccurrences)
+	}
+}
+
+func TestIsOccurrence(t *testing.T) {
+	start := date("2025-01-06T09:00:00Z")
+	rule := Recurrence{Freq: "WEEKLY", Interval: 2, Exceptions: []string{"2025-02-03T09:00:00Z"}}
+	tests := []struct {
+		at   string
+		want bool
+	}{
+		{"2025-01-06T09:00:00Z", true},
+		{"2025-01-20T09:00:00Z", true},
+		{"2025-01-13T09:00:00Z", false}, // off week
+		{"2025-01-20T09:30:00Z", false}, // wrong time
+		{"2025-02-03T09:00:00Z", false}, // exception
+		{"2024-12-23T09:00:00Z", false}, // before start
+		{"8000-12-18T09:00:00Z", true},
+		{"8000-12-25T09:00:00Z", false},
+	}
+	for _, test := range tests {
+		if got := rule.IsOccurrence(start, date(test.at)); got != test.want {
+			t.Errorf("IsOccurrence(%s) = %v, want %v", test.at, got, test.want)
+		}
+	}
+}
+
+func TestShift(t *testing.T) {
+	rule := Recurrence{
+		Freq:       "DAILY",
+		Interval:   1,
+		Until:      "2025-03-01T10:00:00Z",
+		Exceptions: []string{"2025-02-10T10:00:00Z", "not a date"},
+	}
+	shifted := rule.Shift(2 * time.Hour)
+	if shifted.Until != "2025-03-01T12:00:00Z" {
+		t.Errorf("until = %s", shifted.Until)
+	}
+	if len(shifted.Exceptions) != 1 || shifted.Exceptions[0] != "2025-02-10T12:00:00Z" {
+		t.Errorf("exceptions = %v", shifted.Exceptions)
+	}
+	if rule.Exceptions[0] != "2025-02-10T10:00:00Z" {
+		t.Errorf("original rule changed: %v", rule.Exceptions)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    Recurrence
+		wantErr bool
+	}{
+		{"defaults interval", Recurrence{Freq: "daily"}, false},
+		{"unknown freq", Recurrence{Freq: "YEARLY"}, true},
+		{"negative interval", Recurrence{Freq: "DAILY", Interval: -1}, true},
+		{"count over limit", Recurrence{Freq: "DAILY", Count: MaxOccurrences + 1}, true},
+		{"count and until", Recurrence{Freq: "DAILY", Count: 2, Until: "2025-01-01T00:00:00Z"}, true},
+		{"bad exception", Recurrence{Freq: "DAILY", Exceptions: []string{"x"}}, true},
+	}
+	for _, test := range tests {
+		err := test.rule.Validate()
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: err = %v", test.name, err)
+		}
+	}
+}
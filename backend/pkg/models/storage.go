@@ -10,4 +10,9 @@ type Repositories struct {
 	NotifRepo   NotifRepository
 	EventRepo   EventRepository
 	MsgRepo     MsgRepository
+
+	DeliveryRepo DeliveryRepository
+	ReactionRepo ReactionRepository
+	JobRepo      JobRepository
+	InviteRepo   InviteLinkRepository
 }
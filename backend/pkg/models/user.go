@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // defines  User data type
 type User struct {
 	ID          string `json:"id"`
@@ -20,6 +22,16 @@ type User struct {
 
 	FollowersCount int `json:"followersCount"`   // number of followers
 	FollowingCount int `json:"followingCount"`   // number of following
+
+	GroupRole  string `json:"groupRole,omitempty"`  // role in group, only in group member lists
+	MutedUntil string `json:"mutedUntil,omitempty"` // end of mute in group, only in group member lists
+}
+
+// online status of user
+type Presence struct {
+	UserID   string `json:"userId"`
+	Online   bool   `json:"online"`
+	LastSeen string `json:"lastSeen"` // RFC3339, empty if hidden or never seen
 }
 
 // Repository represent all possible actions availible to deal with User
@@ -37,6 +49,7 @@ type UserRepository interface {
 
 	IsFollowing(userID, currentUserID string) (bool, error) // returns true if current is following
 	GetDataMin(userID string) (User, error)                 // returns id, nickname and image (for comment or post author)
+	GetDataMinBatch(userIDs []string) (map[string]User, error) // GetDataMin for many users, keyed by id
 	ProfileStatus(userID string) (string, error)            // evaluates if profile public
 
 	GetProfileMax(userID string) (User, error) // returns all data about user
@@ -45,4 +58,18 @@ type UserRepository interface {
 	GetStatus(userID string) (string, error) // get current status
 	SetStatus(User) error                    // change status (needs id and new status)
 	SearchUsers(query, currentUserID string) ([]User, error) // search users by name or nickname
+
+	SetLastSeen(userID string, lastSeen time.Time) error // save last time user was online
+	// last time users were online keyed by id, RFC3339 or empty if never seen
+	// only users whose presence viewer can see (public profile or followed by viewer)
+	GetLastSeenBatch(userIDs []string, viewerID string) (map[string]string, error)
+
+	GetCalendarToken(userID string) (string, error)       // token of calendar feed, empty if not created
+	SetCalendarToken(userID, token string) error          // create or replace calendar feed token
+	FindUserByCalendarToken(token string) (string, error) // user id of calendar feed owner
+
+	// removes user with everything they created or received, returns images to delete from disk
+	// groups user owns are handed over to successor or removed if user is alone there,
+	// events user created in groups are taken over by group owner, all in one transaction
+	Delete(userID string) ([]string, error)
 }
@@ -0,0 +1,176 @@
+package scheduler
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"social-network/pkg/models"
+)
+
+const (
+	// jobs fetched from database at once
+	batchSize = 100
+	// failed job is retried this many times before it is given up
+	maxAttempts = 5
+	// wait before retry, multiplied by attempt number
+	retryDelay = time.Minute
+)
+
+// source of current time, replaced in tests to move time manually
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// runs job, returned error schedules retry
+type JobFunc func(job models.Job) error
+
+// runs jobs saved in database when they are due
+// jobs survive restarts, job that was due while server was down runs on start
+type Scheduler struct {
+	jobs  models.JobRepository
+	clock Clock
+
+	mu    sync.Mutex // guards funcs and stop
+	funcs map[string]JobFunc
+	stop  chan struct{}
+}
+
+// creates scheduler, nil clock uses system time
+func New(jobs models.JobRepository, clock Clock) *Scheduler {
+	if clock == nil {
+		clock = systemClock{}
+	}
+	return &Scheduler{
+		jobs:  jobs,
+		clock: clock,
+		funcs: make(map[string]JobFunc),
+	}
+}
+
+// sets function that runs jobs of provided type
+func (s *Scheduler) Handle(jobType string, fn JobFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.funcs[jobType] = fn
+}
+
+// current time of scheduler clock
+func (s *Scheduler) Now() time.Time {
+	return s.clock.Now()
+}
+
+// saves job to run at job.RunAt, job with same type and key is kept only once
+func (s *Scheduler) Schedule(job models.Job) error {
+	return s.jobs.Schedule(job)
+}
+
+// removes jobs of type not run yet whose key starts with prefix
+func (s *Scheduler) CancelPending(jobType, keyPrefix string) error {
+	return s.jobs.CancelPending(jobType, keyPrefix)
+}
+
+// runs all due jobs once, returns number of jobs that completed
+// stops when no job of a full batch could be saved, as the same batch would come again
+func (s *Scheduler) RunDue() int {
+	completed := 0
+	for {
+		now := s.clock.Now()
+		jobs, err := s.jobs.GetDue(now, batchSize)
+		if err != nil {
+			log.Println("Error on getting due jobs:", err)
+			return completed
+		}
+		saved := 0
+		for _, job := range jobs {
+			done, ok := s.run(job, now)
+			if done {
+				completed++
+			}
+			if ok {
+				saved++
+			}
+		}
+		if len(jobs) < batchSize || saved == 0 {
+			return completed
+		}
+	}
+}
+
+// runs one job and saves the result
+// done is true if job completed, saved is false if result couldn't be stored and job is still due
+func (s *Scheduler) run(job models.Job, now time.Time) (done, saved bool) {
+	s.mu.Lock()
+	fn, ok := s.funcs[job.Type]
+	s.mu.Unlock()
+	if !ok {
+		// kept for later, function may be registered after restart
+		return false, s.retry(job, now, "no function for job type "+job.Type)
+	}
+
+	if err := fn(job); err != nil {
+		log.Printf("Job %s (%s) failed: %v", job.ID, job.Type, err)
+		return false, s.retry(job, now, err.Error())
+	}
+	if err := s.jobs.Complete(job.ID, now); err != nil {
+		log.Println("Error on completing job:", err)
+		return false, false
+	}
+	return true, true
+}
+
+// moves job to later attempt or gives it up, false if that couldn't be saved
+func (s *Scheduler) retry(job models.Job, now time.Time, reason string) bool {
+	var err error
+	if job.Attempts+1 >= maxAttempts {
+		log.Printf("Job %s (%s) given up: %s", job.ID, job.Type, reason)
+		err = s.jobs.Complete(job.ID, now)
+	} else {
+		err = s.jobs.Retry(job.ID, now.Add(time.Duration(job.Attempts+1)*retryDelay), reason)
+	}
+	if err != nil {
+		log.Println("Error on rescheduling job:", err)
+		return false
+	}
+	return true
+}
+
+// runs due jobs now and then every interval until Stop
+func (s *Scheduler) Start(interval time.Duration) {
+	s.mu.Lock()
+	if s.stop != nil {
+		s.mu.Unlock()
+		return
+	}
+	stop := make(chan struct{})
+	s.stop = stop
+	s.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		s.RunDue()
+		for {
+			select {
+			case <-ticker.C:
+				s.RunDue()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// stops running jobs, job running right now is finished
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
@@ -0,0 +1,205 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"social-network/pkg/models"
+)
+
+// clock moved by test
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
+
+// jobs kept in memory, saving results fails while failSave is set
+type memoryJobs struct {
+	mu       sync.Mutex
+	jobs     map[string]*models.Job
+	done     map[string]bool
+	failSave bool
+	fetches  int
+}
+
+func newMemoryJobs() *memoryJobs {
+	return &memoryJobs{jobs: make(map[string]*models.Job), done: make(map[string]bool)}
+}
+
+func (m *memoryJobs) Schedule(job models.Job) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, existing := range m.jobs {
+		if existing.Type == job.Type && existing.Key == job.Key {
+			return nil
+		}
+	}
+	m.jobs[job.ID] = &job
+	return nil
+}
+
+func (m *memoryJobs) GetDue(now time.Time, limit int) ([]models.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fetches++
+	var due []models.Job
+	for id, job := range m.jobs {
+		if !m.done[id] && !job.RunAt.After(now) {
+			due = append(due, *job)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].RunAt.Before(due[j].RunAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (m *memoryJobs) Complete(jobID string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.failSave {
+		return errors.New("database is locked")
+	}
+	m.done[jobID] = true
+	return nil
+}
+
+func (m *memoryJobs) Retry(jobID string, runAt time.Time, reason string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.failSave {
+		return errors.New("database is locked")
+	}
+	job := m.jobs[jobID]
+	job.Attempts++
+	job.RunAt = runAt
+	job.LastError = reason
+	return nil
+}
+
+func (m *memoryJobs) CancelPending(jobType, keyPrefix string) error {
+	return nil
+}
+
+func (m *memoryJobs) NotifyOnce(jobID string, notif models.Notification) (bool, error) {
+	return false, nil
+}
+
+func TestRunDueRetriesFailedJobs(t *testing.T) {
+	start := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start}
+	jobs := newMemoryJobs()
+	s := New(jobs, clock)
+
+	runs := map[string]int{}
+	s.Handle("ok", func(job models.Job) error {
+		runs[job.ID]++
+		return nil
+	})
+	s.Handle("failing", func(job models.Job) error {
+		runs[job.ID]++
+		return errors.New("failed")
+	})
+	for _, job := range []models.Job{
+		{ID: "ok", Type: "ok", Key: "a", RunAt: start},
+		{ID: "later", Type: "ok", Key: "b", RunAt: start.Add(time.Hour)},
+		{ID: "failing", Type: "failing", Key: "a", RunAt: start},
+		{ID: "unknown", Type: "unknown", Key: "a", RunAt: start},
+	} {
+		if err := s.Schedule(job); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if completed := s.RunDue(); completed != 1 {
+		t.Fatalf("completed %d jobs, want 1", completed)
+	}
+	if runs["ok"] != 1 || runs["later"] != 0 || runs["failing"] != 1 {
+		t.Fatalf("runs %v", runs)
+	}
+	// retried after delay growing with attempts, given up after maxAttempts
+	for attempt := 1; attempt < maxAttempts; attempt++ {
+		clock.Set(clock.Now().Add(time.Duration(attempt)*retryDelay - time.Second))
+		s.RunDue()
+		if runs["failing"] != attempt {
+			t.Fatalf("attempt %d ran before its delay", attempt+1)
+		}
+		clock.Set(clock.Now().Add(time.Second))
+		s.RunDue()
+		if runs["failing"] != attempt+1 {
+			t.Fatalf("attempt %d didn't run, runs %v", attempt+1, runs)
+		}
+	}
+	clock.Set(start.Add(24 * time.Hour))
+	s.RunDue()
+	if runs["failing"] != maxAttempts || runs["ok"] != 1 || runs["later"] != 1 {
+		t.Fatalf("runs %v, want failing job run %d times", runs, maxAttempts)
+	}
+	if !jobs.done["failing"] || !jobs.done["unknown"] {
+		t.Fatal("jobs that kept failing weren't given up")
+	}
+}
+
+// jobs over batch size all run in one call
+func TestRunDueRunsAllBatches(t *testing.T) {
+	start := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	jobs := newMemoryJobs()
+	s := New(jobs, &fakeClock{now: start})
+	s.Handle("ok", func(job models.Job) error { return nil })
+	for i := 0; i < batchSize*2+1; i++ {
+		s.Schedule(models.Job{ID: fmt.Sprint(i), Type: "ok", Key: fmt.Sprint(i), RunAt: start})
+	}
+	if completed := s.RunDue(); completed != batchSize*2+1 {
+		t.Fatalf("completed %d jobs, want %d", completed, batchSize*2+1)
+	}
+}
+
+// full batch whose results can't be saved comes back unchanged, RunDue has to give up
+func TestRunDueStopsWithoutProgress(t *testing.T) {
+	start := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	jobs := newMemoryJobs()
+	s := New(jobs, &fakeClock{now: start})
+	s.Handle("ok", func(job models.Job) error { return nil })
+	s.Handle("failing", func(job models.Job) error { return errors.New("failed") })
+	for i := 0; i < batchSize; i++ {
+		jobType := "ok"
+		if i%2 == 0 {
+			jobType = "failing"
+		}
+		s.Schedule(models.Job{ID: fmt.Sprint(i), Type: jobType, Key: fmt.Sprint(i), RunAt: start})
+	}
+	jobs.failSave = true
+
+	result := make(chan int)
+	go func() { result <- s.RunDue() }()
+	select {
+	case completed := <-result:
+		if completed != 0 || jobs.fetches != 1 {
+			t.Fatalf("completed %d jobs in %d fetches, want 0 in 1", completed, jobs.fetches)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunDue doesn't return")
+	}
+
+	jobs.failSave = false
+	if completed := s.RunDue(); completed != batchSize/2 {
+		t.Fatalf("completed %d jobs, want %d", completed, batchSize/2)
+	}
+}
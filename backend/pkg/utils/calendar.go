@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"social-network/pkg/models"
+)
+
+// events have no end time, calendar apps get this length
+const eventDuration = "PT1H"
+
+// UTC date-time value format
+const icsTime = "20060102T150405Z"
+
+// max octets on one content line before folding (RFC 5545 3.1)
+const icsLineLength = 75
+
+// builds iCalendar (RFC 5545) document with one VEVENT per event
+func ICalendar(name string, events []models.Event) []byte {
+	var b strings.Builder
+	stamp := time.Now().UTC().Format(icsTime)
+	writeICSLine(&b, "BEGIN:VCALENDAR")
+	writeICSLine(&b, "VERSION:2.0")
+	writeICSLine(&b, "PRODID:-//social-network//events//EN")
+	writeICSLine(&b, "CALSCALE:GREGORIAN")
+	writeICSLine(&b, "METHOD:PUBLISH")
+	writeICSLine(&b, "X-WR-CALNAME:"+escapeICSText(name))
+	for _, event := range events {
+		writeICSLine(&b, "BEGIN:VEVENT")
+		if event.Occurrence != "" {
+			// single occurrence of series gets its own uid
+			writeICSLine(&b, "UID:"+event.ID+"-"+event.DateTime.UTC().Format(icsTime)+"@social-network")
+		} else {
+			writeICSLine(&b, "UID:"+event.ID+"@social-network")
+		}
+		writeICSLine(&b, "DTSTAMP:"+stamp)
+		writeICSLine(&b, "DTSTART:"+event.DateTime.UTC().Format(icsTime))
+		writeICSLine(&b, "DURATION:"+eventDuration)
+		if event.Recurrence != nil && event.Occurrence == "" {
+			writeICSLine(&b, "RRULE:"+event.Recurrence.RRule())
+			for _, exception := range event.Recurrence.Exceptions {
+				if t, err := time.Parse(time.RFC3339, exception); err == nil {
+					writeICSLine(&b, "EXDATE:"+t.UTC().Format(icsTime))
+				}
+			}
+		}
+		if event.CancelledAt != nil {
+			writeICSLine(&b, "STATUS:CANCELLED")
+		}
+		writeICSLine(&b, "SUMMARY:"+escapeICSText(event.Title))
+		writeICSLine(&b, "DESCRIPTION:"+escapeICSText(event.Content))
+		if event.Author.FirstName != "" {
+			writeICSLine(&b, "ORGANIZER;CN="+escapeICSParam(event.Author.FirstName+" "+event.Author.LastName)+":mailto:"+event.Author.Email)
+		}
+		writeICSLine(&b, "END:VEVENT")
+	}
+	writeICSLine(&b, "END:VCALENDAR")
+	return []byte(b.String())
+}
+
+// responds with calendar file, inline so subscriptions work
+func RespondWithCalendar(w http.ResponseWriter, filename string, data []byte) {
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="`+filename+`"`)
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// writes content line ended with CRLF, long lines are folded
+// with CRLF + space without splitting utf-8 characters
+func writeICSLine(b *strings.Builder, line string) {
+	limit := icsLineLength
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && line[cut]&0xC0 == 0x80 { // continuation byte
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		limit = icsLineLength - 1 // leading space counts
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+// escapes TEXT value (RFC 5545 3.3.11)
+func escapeICSText(value string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", `\n`,
+	).Replace(value)
+}
+
+// quotes parameter value, DQUOTE is not allowed inside
+func escapeICSParam(value string) string {
+	return `"` + strings.ReplaceAll(value, `"`, "'") + `"`
+}
@@ -70,6 +70,15 @@ func SaveImage(r *http.Request) string {
 	return strings.Replace(localFile.Name(), "\\", "/", -1)
 }
 
+// removes uploaded image of post or comment from filesystem
+// empty path and default image are left untouched
+func DeleteImage(path string) {
+	if path == "" || path == defaultImage || !strings.HasPrefix(path, "imageUpload/") || strings.Contains(path, "..") {
+		return
+	}
+	os.Remove(path)
+}
+
 // creates empty local file based on filt type
 func createTempFile(fileType string) (*os.File, error) {
 	var localFile *os.File
@@ -1,6 +1,9 @@
 package utils
 
-import "social-network/pkg/models"
+import (
+	"social-network/pkg/models"
+	"strings"
+)
 
 // replace notification message content based on type
 func DefineNotificationMsg(notif *models.Notification) {
@@ -15,5 +18,36 @@ func DefineNotificationMsg(notif *models.Notification) {
 		notif.Content = " has requested to join your group "
 	case "CHAT_REQUEST":
 		notif.Content = " wants to chat with you"
+	case "COMMENT_REPLY":
+		notif.Content = " replied to your comment "
+	case "EVENT_REMINDER":
+		notif.Content = " event you are going to starts soon "
+	case "EVENT_UPDATED":
+		_, fields := ParseEventChange(notif.Content)
+		notif.Content = " changed " + strings.Join(fields, ", ") + " of event "
+	case "EVENT_CANCELLED":
+		notif.Content = " cancelled event "
+	case "EVENT_PROMOTED":
+		notif.Content = " event had a free spot, you moved from the waitlist to going "
+	case "REACTION":
+		notif.Content = " reacted to your post or comment "
+	case "GROUP_ANNOUNCEMENT":
+		notif.Content = " posted an announcement in group "
+	case "MENTION":
+		notif.Content = " mentioned you in group chat "
 	}
 }
+
+// content of EVENT_UPDATED notification, event id and changed fields
+func EventChange(eventID string, fields []string) string {
+	return eventID + ":" + strings.Join(fields, ",")
+}
+
+// reads content made by EventChange
+func ParseEventChange(content string) (eventID string, fields []string) {
+	eventID, changed, _ := strings.Cut(content, ":")
+	if changed != "" {
+		fields = strings.Split(changed, ",")
+	}
+	return eventID, fields
+}
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"social-network/pkg/models"
+	"strconv"
+)
+
+const (
+	// page size used when request has no limit
+	DefaultPageLimit = 20
+	// biggest page client can ask for
+	MaxPageLimit = 100
+)
+
+// reads ?cursor=&limit= from request
+// empty cursor means first page, missing limit means DefaultPageLimit
+func ParsePage(r *http.Request) (models.Page, error) {
+	query := r.URL.Query()
+	page := models.Page{Limit: DefaultPageLimit}
+
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			return page, errors.New("Invalid limit")
+		}
+		if limit > MaxPageLimit {
+			limit = MaxPageLimit
+		}
+		page.Limit = limit
+	}
+
+	if raw := query.Get("cursor"); raw != "" {
+		data, err := base64.RawURLEncoding.DecodeString(raw)
+		if err != nil {
+			return page, errors.New("Invalid cursor")
+		}
+		if err := json.Unmarshal(data, &page.After); err != nil || page.After.ID == "" {
+			return page, errors.New("Invalid cursor")
+		}
+	}
+	return page, nil
+}
+
+// opaque form of cursor sent to client as nextCursor
+// nil cursor (no more pages) gives empty string
+func EncodeCursor(cursor *models.Cursor) string {
+	if cursor == nil {
+		return ""
+	}
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"net/http/httptest"
+	"social-network/pkg/models"
+	"testing"
+)
+
+func TestParsePage(t *testing.T) {
+	cursor := EncodeCursor(&models.Cursor{CreatedAt: "2025-01-01 10:00:00", ID: "b"})
+	tests := []struct {
+		query     string
+		wantLimit int
+		wantAfter models.Cursor
+		wantErr   bool
+	}{
+		{"", DefaultPageLimit, models.Cursor{}, false},
+		{"limit=5", 5, models.Cursor{}, false},
+		{"limit=1000", MaxPageLimit, models.Cursor{}, false},
+		{"cursor=" + cursor, DefaultPageLimit, models.Cursor{CreatedAt: "2025-01-01 10:00:00", ID: "b"}, false},
+		{"cursor=" + cursor + "&limit=3", 3, models.Cursor{CreatedAt: "2025-01-01 10:00:00", ID: "b"}, false},
+		{"limit=0", 0, models.Cursor{}, true},
+		{"limit=-2", 0, models.Cursor{}, true},
+		{"limit=ten", 0, models.Cursor{}, true},
+		{"cursor=@@@", 0, models.Cursor{}, true},
+		{"cursor=" + EncodeCursor(&models.Cursor{CreatedAt: "2025-01-01 10:00:00"}), 0, models.Cursor{}, true},
+	}
+	for _, test := range tests {
+		page, err := ParsePage(httptest.NewRequest("GET", "/notifications?"+test.query, nil))
+		if (err != nil) != test.wantErr {
+			t.Errorf("%q: err = %v", test.query, err)
+			continue
+		}
+		if test.wantErr {
+			continue
+		}
+		if page.Limit != test.wantLimit || page.After != test.wantAfter {
+			t.Errorf("%q: got %+v, want limit %d after %+v", test.query, page, test.wantLimit, test.wantAfter)
+		}
+	}
+}
+
+func TestEncodeCursor(t *testing.T) {
+	if got := EncodeCursor(nil); got != "" {
+		t.Errorf("nil cursor = %q", got)
+	}
+}
@@ -11,8 +11,9 @@ type ResponseMessage struct {
 	Message string `json:"message"` // message itself
 }
 type PostMessage struct {
-	Type  string        `json:"type"`
-	Posts []models.Post `json:"posts"`
+	Type       string        `json:"type"`
+	Posts      []models.Post `json:"posts"`
+	NextCursor string        `json:"nextCursor,omitempty"` // empty on last page
 }
 
 type UserMessage struct {
@@ -30,14 +31,42 @@ type EventMessage struct {
 	Events []models.Event `json:"events"`
 }
 
+type ModerationLogMessage struct {
+	Type       string                    `json:"type"`
+	Actions    []models.ModerationAction `json:"actions"`
+	NextCursor string                    `json:"nextCursor,omitempty"` // empty on last page
+}
+
+type InviteLinkMessage struct {
+	Type  string              `json:"type"`
+	Links []models.InviteLink `json:"inviteLinks"`
+}
+
+type JoinRulesMessage struct {
+	Type  string           `json:"type"`
+	Rules models.JoinRules `json:"joinRules"`
+}
+
+type ReceiptsMessage struct {
+	Type     string                  `json:"type"`
+	Receipts []models.MessageReceipt `json:"receipts"`
+}
+
+type EventResponsesMessage struct {
+	Type  string                    `json:"type"`
+	Event models.EventWithResponses `json:"event"`
+}
+
 type NotifMessage struct {
 	Type          string                `json:"type"`
 	Notifications []models.Notification `json:"notifications"`
+	NextCursor    string                `json:"nextCursor,omitempty"` // empty on last page
 }
 
 type ChatMsgMessage struct {
-	Type     string               `json:"type"`
-	Messages []models.ChatMessage `json:"chatMessage"`
+	Type       string               `json:"type"`
+	Messages   []models.ChatMessage `json:"chatMessage"`
+	NextCursor string               `json:"nextCursor,omitempty"` // empty on last page
 }
 
 type ChatStatMessage struct {
@@ -45,6 +74,16 @@ type ChatStatMessage struct {
 	ChatStats []models.ChatStats `json:"chatStats"`
 }
 
+type ReactionMessage struct {
+	Type     string                 `json:"type"`
+	Reaction models.ReactionSummary `json:"reaction"`
+}
+
+type PresenceMessage struct {
+	Type     string            `json:"type"`
+	Presence []models.Presence `json:"presence"`
+}
+
 // Error takes writer, message, status code and additional error property
 // Sets status code in header and encode resp in json
 func RespondWithError(w http.ResponseWriter, message string, code int) {
@@ -79,9 +118,9 @@ func RespondWithUsers(w http.ResponseWriter, users []models.User, code int) {
 }
 
 // responds with success group
-func RespondWithPosts(w http.ResponseWriter, posts []models.Post, code int) {
+func RespondWithPosts(w http.ResponseWriter, posts []models.Post, nextCursor string, code int) {
 	w.WriteHeader(code)
-	err := PostMessage{Posts: posts, Type: "Success"}
+	err := PostMessage{Posts: posts, NextCursor: nextCursor, Type: "Success"}
 	jsonResp, _ := json.Marshal(err)
 	w.Write(jsonResp)
 }
@@ -95,17 +134,17 @@ func RespondWithEvents(w http.ResponseWriter, events []models.Event, code int) {
 }
 
 // responds with success notifs
-func RespondWithNotifications(w http.ResponseWriter, notifs []models.Notification, code int) {
+func RespondWithNotifications(w http.ResponseWriter, notifs []models.Notification, nextCursor string, code int) {
 	w.WriteHeader(code)
-	err := NotifMessage{Notifications: notifs, Type: "Success"}
+	err := NotifMessage{Notifications: notifs, NextCursor: nextCursor, Type: "Success"}
 	jsonResp, _ := json.Marshal(err)
 	w.Write(jsonResp)
 }
 
 // responds with success chat msg
-func RespondWithMessages(w http.ResponseWriter, msgs []models.ChatMessage, code int) {
+func RespondWithMessages(w http.ResponseWriter, msgs []models.ChatMessage, nextCursor string, code int) {
 	w.WriteHeader(code)
-	err := ChatMsgMessage{Messages: msgs, Type: "Success"}
+	err := ChatMsgMessage{Messages: msgs, NextCursor: nextCursor, Type: "Success"}
 	jsonResp, _ := json.Marshal(err)
 	w.Write(jsonResp)
 }
@@ -117,3 +156,59 @@ func RespondWithChatStats(w http.ResponseWriter, msgs []models.ChatStats, code i
 	jsonResp, _ := json.Marshal(err)
 	w.Write(jsonResp)
 }
+
+// responds with success presence list
+func RespondWithPresence(w http.ResponseWriter, presence []models.Presence, code int) {
+	w.WriteHeader(code)
+	err := PresenceMessage{Presence: presence, Type: "Success"}
+	jsonResp, _ := json.Marshal(err)
+	w.Write(jsonResp)
+}
+
+// responds with reaction summary of post or comment
+func RespondWithReaction(w http.ResponseWriter, reaction models.ReactionSummary, code int) {
+	w.WriteHeader(code)
+	err := ReactionMessage{Reaction: reaction, Type: "Success"}
+	jsonResp, _ := json.Marshal(err)
+	w.Write(jsonResp)
+}
+
+// responds with event and its responses after answering it
+func RespondWithEventResponses(w http.ResponseWriter, event models.EventWithResponses, code int) {
+	w.WriteHeader(code)
+	err := EventResponsesMessage{Event: event, Type: "Success"}
+	jsonResp, _ := json.Marshal(err)
+	w.Write(jsonResp)
+}
+
+// responds with page of group moderation audit trail
+func RespondWithModerationLog(w http.ResponseWriter, actions []models.ModerationAction, nextCursor string, code int) {
+	w.WriteHeader(code)
+	err := ModerationLogMessage{Actions: actions, NextCursor: nextCursor, Type: "Success"}
+	jsonResp, _ := json.Marshal(err)
+	w.Write(jsonResp)
+}
+
+// responds with invite links of group
+func RespondWithInviteLinks(w http.ResponseWriter, links []models.InviteLink, code int) {
+	w.WriteHeader(code)
+	err := InviteLinkMessage{Links: links, Type: "Success"}
+	jsonResp, _ := json.Marshal(err)
+	w.Write(jsonResp)
+}
+
+// responds with questions and auto approval rule of group
+func RespondWithJoinRules(w http.ResponseWriter, rules models.JoinRules, code int) {
+	w.WriteHeader(code)
+	err := JoinRulesMessage{Rules: rules, Type: "Success"}
+	jsonResp, _ := json.Marshal(err)
+	w.Write(jsonResp)
+}
+
+// responds with delivery and read receipts of message
+func RespondWithReceipts(w http.ResponseWriter, receipts []models.MessageReceipt, code int) {
+	w.WriteHeader(code)
+	err := ReceiptsMessage{Receipts: receipts, Type: "Success"}
+	jsonResp, _ := json.Marshal(err)
+	w.Write(jsonResp)
+}
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"net/http"
 
 	uuid "github.com/gofrs/uuid"
@@ -12,6 +14,13 @@ func UniqueId() string {
 	return id.String()
 }
 
+// Create random secret for links that work without session
+func RandomToken() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
 func ConfigHeader(w http.ResponseWriter) http.ResponseWriter {
 	w.Header().Set("Access-Control-Allow-Origin", "http://localhost:5173")
 	w.Header().Set("Access-Control-Allow-Credentials", "true")
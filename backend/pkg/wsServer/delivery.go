@@ -0,0 +1,141 @@
+package ws
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                 durable events and resumable connections                   */
+/* -------------------------------------------------------------------------- */
+
+const (
+	// how long events are kept for replay
+	eventRetention = 7 * 24 * time.Hour
+	// if client missed more events than this, it has to resync over http
+	// kept below send buffer size, so replay never evicts the client
+	maxReplayEvents = 200
+	// how often old events are deleted
+	pruneInterval = time.Hour
+)
+
+// saves message for all users in one transaction and sends it to their online connections
+// offline users get it on reconnect with ?since=<seq>
+// message that couldn't be saved is not sent, its seq would break replay
+func (s *Server) Publish(userIDs []string, message WsMessage) {
+	s.pruneEvents()
+	message.Seq = 0
+	seqs, err := s.Repos.DeliveryRepo.Append(userIDs, message.encode())
+	if err != nil {
+		log.Println("Error on saving event", message.Action, err)
+		return
+	}
+	for userID, seq := range seqs {
+		message.Seq = seq
+		data := message.encode()
+		for _, client := range s.ClientsOf(userID) {
+			s.deliverEvent(client, seq, data)
+		}
+	}
+}
+
+// deletes events older than retention in background, at most once per pruneInterval
+func (s *Server) pruneEvents() {
+	s.pruneMu.Lock()
+	if time.Since(s.lastPrune) < pruneInterval {
+		s.pruneMu.Unlock()
+		return
+	}
+	s.lastPrune = time.Now()
+	s.pruneMu.Unlock()
+	go func() {
+		if err := s.Repos.DeliveryRepo.Prune(time.Now().Add(-eventRetention)); err != nil {
+			log.Println("Error on pruning events", err)
+		}
+	}()
+}
+
+// deliver durable event, held back while client is replaying missed events
+func (s *Server) deliverEvent(client *Client, seq int64, data []byte) {
+	client.resumeMu.Lock()
+	if client.resuming {
+		client.pending = append(client.pending, pendingEvent{seq: seq, data: data})
+		client.resumeMu.Unlock()
+		return
+	}
+	client.resumeMu.Unlock()
+	s.deliver(client, data)
+}
+
+// sends client everything it missed after seq, followed by sync message with last sequence number
+// if the gap is too big or events are already pruned, sends resync instead -> client refetches over http
+// client must be marked with BeginResume before registration, so no live event gets lost meanwhile
+func (s *Server) Resume(client *Client, since int64) {
+	oldest, last, err := s.Repos.DeliveryRepo.GetBounds(client.ID)
+	if err != nil {
+		log.Println("Error on reading event bounds", err)
+		s.endResume(client, last, ResyncAction, last)
+		return
+	}
+	// nothing missed
+	if since >= last {
+		action := SyncAction
+		if since > last { // client knows events server doesn't -> state is off
+			action = ResyncAction
+		}
+		s.endResume(client, last, action, last)
+		return
+	}
+	// missed events already deleted or too many of them
+	if oldest == 0 || since+1 < oldest || last-since > maxReplayEvents {
+		s.endResume(client, last, ResyncAction, last)
+		return
+	}
+
+	events, err := s.Repos.DeliveryRepo.GetSince(client.ID, since, maxReplayEvents)
+	if err != nil {
+		log.Println("Error on reading missed events", err)
+		s.endResume(client, last, ResyncAction, last)
+		return
+	}
+	for _, event := range events {
+		var message WsMessage
+		if err := json.Unmarshal(event.Payload, &message); err != nil {
+			continue
+		}
+		message.Seq = event.Seq
+		s.deliver(client, message.encode())
+		last = event.Seq
+	}
+	s.endResume(client, last, SyncAction, last)
+}
+
+// let freshly connected client know last sequence number, to resume from later
+// like Resume, client must be marked with BeginResume before registration, so sync goes first
+// live events published meanwhile follow it, even with lower seq, as nothing was replayed
+func (s *Server) Sync(client *Client) {
+	_, last, err := s.Repos.DeliveryRepo.GetBounds(client.ID)
+	if err != nil {
+		log.Println("Error on reading event bounds", err)
+		s.endResume(client, last, ResyncAction, 0)
+		return
+	}
+	s.endResume(client, last, SyncAction, 0)
+}
+
+// send sync/resync message, then events that arrived during resume and weren't replayed,
+// and switch client to live delivery
+func (s *Server) endResume(client *Client, last int64, action string, replayed int64) {
+	s.deliver(client, (&WsMessage{Action: action, Seq: last}).encode())
+
+	client.resumeMu.Lock()
+	defer client.resumeMu.Unlock()
+	for _, event := range client.pending {
+		if event.seq > replayed {
+			s.deliver(client, event.data)
+		}
+	}
+	client.pending = nil
+	client.resuming = false
+}
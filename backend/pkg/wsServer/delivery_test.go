@@ -0,0 +1,149 @@
+package ws
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	db "social-network/pkg/db/sqlite"
+	"social-network/pkg/models"
+)
+
+func TestMain(m *testing.M) {
+	// migrations are read relative to backend directory, as when server runs
+	if err := os.Chdir("../.."); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// server on fresh migrated database in temporary directory
+func newTestServer(t *testing.T) (*Server, *sql.DB) {
+	t.Helper()
+	conn, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db")+"?_busy_timeout=5000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	if err = db.Migrations(conn); err != nil {
+		t.Fatal(err)
+	}
+	return StartServer(db.InitRepositories(conn)), conn
+}
+
+// action and seq of messages waiting in send queue of client, e.g. "chat 2"
+// presence updates are left out
+func queued(t *testing.T, client *Client) []string {
+	t.Helper()
+	var got []string
+	for {
+		select {
+		case data := <-client.send:
+			var message WsMessage
+			if err := json.Unmarshal(data, &message); err != nil {
+				t.Fatal(err)
+			}
+			if message.Action != PresenceOnlineAction && message.Action != PresenceOfflineAction {
+				got = append(got, fmt.Sprintf("%s %d", message.Action, message.Seq))
+			}
+		default:
+			return got
+		}
+	}
+}
+
+func sendChat(server *Server, userIDs ...string) {
+	server.SendChatMessage(userIDs, models.ChatMessage{Content: "hi", Type: "PERSON"}, "")
+}
+
+func TestResumeReplaysMissedEvents(t *testing.T) {
+	tests := []struct {
+		name      string
+		published int   // events saved while user was offline
+		pruned    int64 // events up to this seq are deleted
+		since     int64 // last seq client has seen
+		want      []string
+	}{
+		{"nothing missed", 2, 0, 2, []string{"sync 2", "chat 3"}},
+		{"missed events", 3, 0, 1, []string{"chat 2", "chat 3", "sync 3", "chat 4"}},
+		{"missed events pruned", 3, 1, 0, []string{"resync 3", "chat 4"}},
+		{"ahead of server", 1, 0, 4, []string{"resync 1", "chat 2"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, conn := newTestServer(t)
+			for i := 0; i < test.published; i++ {
+				sendChat(server, "alice")
+			}
+			if _, err := conn.Exec("DELETE FROM user_events WHERE seq <= ?", test.pruned); err != nil {
+				t.Fatal(err)
+			}
+
+			client := NewClient(nil, server.Repos, "alice")
+			client.BeginResume()
+			server.RegisterNewClient(client)
+			server.Resume(client, test.since)
+			// live event after resume
+			sendChat(server, "alice")
+
+			if got := queued(t, client); fmt.Sprint(got) != fmt.Sprint(test.want) {
+				t.Fatalf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+// event published between registration and sync comes after sync frame
+func TestSyncBeforeLiveEvents(t *testing.T) {
+	server, _ := newTestServer(t)
+	sendChat(server, "alice")
+
+	client := NewClient(nil, server.Repos, "alice")
+	client.BeginResume()
+	server.RegisterNewClient(client)
+	sendChat(server, "alice")
+	server.Sync(client)
+	sendChat(server, "alice")
+
+	want := []string{"sync 2", "chat 2", "chat 3"}
+	if got := queued(t, client); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+// one event for several users, nothing is sent when it can't be saved
+func TestPublishSavesBeforeSending(t *testing.T) {
+	server, conn := newTestServer(t)
+	sendChat(server, "bob")
+	alice := NewClient(nil, server.Repos, "alice")
+	bob := NewClient(nil, server.Repos, "bob")
+	server.RegisterNewClient(alice)
+	server.RegisterNewClient(bob)
+
+	sendChat(server, "alice", "bob", "alice")
+	if got, want := queued(t, alice), []string{"chat 1"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("alice got %v, want %v", got, want)
+	}
+	if got, want := queued(t, bob), []string{"chat 2"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("bob got %v, want %v", got, want)
+	}
+
+	if _, err := conn.Exec("ALTER TABLE user_events RENAME TO user_events_old"); err != nil {
+		t.Fatal(err)
+	}
+	sendChat(server, "alice", "bob")
+	if got := append(queued(t, alice), queued(t, bob)...); len(got) != 0 {
+		t.Fatalf("unsaved event was sent: %v", got)
+	}
+	// sequence numbers of failed event are not used up
+	if _, err := conn.Exec("ALTER TABLE user_events_old RENAME TO user_events"); err != nil {
+		t.Fatal(err)
+	}
+	sendChat(server, "alice")
+	if got, want := queued(t, alice), []string{"chat 2"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("alice got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,61 @@
+package ws
+
+import (
+	"log"
+	"social-network/pkg/models"
+	"time"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                              online presence                               */
+/* -------------------------------------------------------------------------- */
+
+// returns ids of users allowed to see presence of provided user
+// followers always, chat partners only if profile is not private
+func (s *Server) presenceAudience(userID string) []string {
+	var ids []string
+	followers, err := s.Repos.UserRepo.GetFollowers(userID)
+	if err != nil {
+		log.Println("Error on getting followers for presence:", err)
+	}
+	for _, follower := range followers {
+		ids = append(ids, follower.ID)
+	}
+	status, err := s.Repos.UserRepo.GetStatus(userID)
+	if err != nil || status == "PRIVATE" {
+		return ids
+	}
+	partners, err := s.Repos.MsgRepo.GetChatHistoryIds(userID)
+	if err != nil {
+		log.Println("Error on getting chat partners for presence:", err)
+	}
+	for id := range partners {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// save last seen time and let audience know that user went online/offline
+// called when first connection of user opens or last one closes
+func (s *Server) broadcastPresence(userID string, online bool) {
+	// connection opened/closed in the meantime, that call will broadcast
+	if s.IsOnline(userID) != online {
+		return
+	}
+	now := time.Now()
+	if err := s.Repos.UserRepo.SetLastSeen(userID, now); err != nil {
+		log.Println("Error on saving last seen:", err)
+	}
+	message := WsMessage{
+		Action: PresenceOfflineAction,
+		Presence: &models.Presence{
+			UserID:   userID,
+			Online:   online,
+			LastSeen: now.Format(time.RFC3339),
+		},
+	}
+	if online {
+		message.Action = PresenceOnlineAction
+	}
+	s.SendToUsers(s.presenceAudience(userID), message)
+}
@@ -0,0 +1,68 @@
+package ws
+
+// max posts and events one connection can watch at the same time
+const maxWatched = 200
+
+// start sending live updates of posts or events to client
+// ids over maxWatched are ignored, returns number of watched ids
+func (s *Server) Watch(client *Client, ids []string) int {
+	s.watchMu.Lock()
+	defer s.watchMu.Unlock()
+	for _, id := range ids {
+		if client.watching[id] {
+			continue
+		}
+		if len(client.watching) >= maxWatched {
+			break
+		}
+		client.watching[id] = true
+		if s.watchers[id] == nil {
+			s.watchers[id] = make(map[*Client]bool)
+		}
+		s.watchers[id][client] = true
+	}
+	return len(client.watching)
+}
+
+// stop sending live updates of posts or events to client
+func (s *Server) Unwatch(client *Client, ids []string) {
+	s.watchMu.Lock()
+	defer s.watchMu.Unlock()
+	for _, id := range ids {
+		s.unwatch(client, id)
+	}
+}
+
+// drop everything client watches, called on unregister
+func (s *Server) unwatchAll(client *Client) {
+	s.watchMu.Lock()
+	defer s.watchMu.Unlock()
+	for id := range client.watching {
+		s.unwatch(client, id)
+	}
+}
+
+// caller must hold watchMu
+func (s *Server) unwatch(client *Client, id string) {
+	delete(client.watching, id)
+	delete(s.watchers[id], client)
+	if len(s.watchers[id]) == 0 {
+		delete(s.watchers, id)
+	}
+}
+
+// send message to every connection currently watching post or event
+// not saved for replay, watchers refetch data when they come back
+func (s *Server) SendToWatchers(id string, message WsMessage) {
+	s.watchMu.Lock()
+	clients := make([]*Client, 0, len(s.watchers[id]))
+	for client := range s.watchers[id] {
+		clients = append(clients, client)
+	}
+	s.watchMu.Unlock()
+
+	data := message.encode()
+	for _, client := range clients {
+		s.deliver(client, data)
+	}
+}
@@ -1,27 +1,60 @@
 package ws
 
 import (
+	"encoding/json"
 	"log"
 	"social-network/pkg/models"
-	"social-network/pkg/utils"
+	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
 
+const (
+	// time allowed to write a message to the peer
+	writeWait = 10 * time.Second
+	// time allowed to read the next pong message from the peer
+	pongWait = 60 * time.Second
+	// send pings to peer with this period, must be less than pongWait
+	pingPeriod = (pongWait * 9) / 10
+	// maximum message size allowed from peer
+	maxMessageSize = 8192
+	// size of outgoing queue, client is evicted when it fills up
+	sendBufferSize = 256
+)
+
 // represents single websocket client
 type Client struct {
-	ID    string
-	conn  *websocket.Conn      //ws connection
-	send  chan []byte          //sedn channel for outgoing messages
-	repos *models.Repositories //connection to db actions
+	ID          string
+	conn        *websocket.Conn      //ws connection
+	send        chan []byte          //sedn channel for outgoing messages
+	done        chan struct{}        //closed when client is unregistered
+	closeOnce   sync.Once            //guards done channel
+	closeCode   int                  //close frame code sent to peer on shutdown
+	closeReason string               //close frame reason sent to peer on shutdown
+	repos       *models.Repositories //connection to db actions
+
+	resumeMu sync.Mutex     //guards resuming and pending
+	resuming bool           //true while missed events are being replayed
+	pending  []pendingEvent //live events held back during replay
+
+	watching map[string]bool //posts and events client receives live updates for, guarded by Server.watchMu
+}
+
+// durable event waiting for replay to finish
+type pendingEvent struct {
+	seq  int64
+	data []byte
 }
 
 func NewClient(conn *websocket.Conn, repos *models.Repositories, ID string) *Client {
 	return &Client{
-		ID:    ID,
-		conn:  conn,
-		send:  make(chan []byte, 256),
-		repos: repos,
+		ID:       ID,
+		conn:     conn,
+		send:     make(chan []byte, sendBufferSize),
+		done:     make(chan struct{}),
+		repos:    repos,
+		watching: make(map[string]bool),
 	}
 }
 
@@ -29,55 +62,38 @@ func NewClient(conn *websocket.Conn, repos *models.Repositories, ID string) *Cli
 /*                           client action functions                          */
 /* -------------------------------------------------------------------------- */
 
-// Configure the notification with additional data about sender || group
-// Change the content to reusable sentence
-// send notification to client
-func (client *Client) SendNotification(notif models.Notification) {
-	switch notif.Type {
-	case "GROUP_INVITE":
-		notif.Group, _ = client.repos.GroupRepo.GetData(notif.Content)
-		notif.User, _ = client.repos.UserRepo.GetDataMin(notif.Sender)
-	case "FOLLOW":
-		notif.User, _ = client.repos.UserRepo.GetDataMin(notif.Content)
-	case "EVENT":
-		notif.Event, _ = client.repos.EventRepo.GetData(notif.Content)
-		notif.User, _ = client.repos.UserRepo.GetDataMin(notif.Sender)
-		notif.Group,_ = client.repos.GroupRepo.GetData(notif.Event.GroupID)
-	case "GROUP_REQUEST":
-		notif.User, _ = client.repos.UserRepo.GetDataMin(notif.Content)
-		notif.Group, _ = client.repos.GroupRepo.GetData(notif.TargetID)
-	case "CHAT_REQUEST":
-		notif.User, _ = client.repos.UserRepo.GetDataMin(notif.Sender)
-	}
-	/* ---------------------------- add message text ---------------------------- */
-	utils.DefineNotificationMsg(&notif)
-
-	/* ---------------------------- construct message --------------------------- */
-	message := WsMessage{
-		Action:       NotificationAction,
-		Notification: notif,
-	}
-	/* ---------------------------------- send ---------------------------------- */
-	client.send <- message.encode()
+// mark client as replaying missed events, live events are held back
+// until Server.Resume finishes, must be called before registration
+func (client *Client) BeginResume() {
+	client.resumeMu.Lock()
+	defer client.resumeMu.Unlock()
+	client.resuming = true
 }
 
-func (client *Client) SendChatMessage(msg models.ChatMessage, flag string) {
-	message := WsMessage{
-		Action:      ChatAction,
-		ChatMessage: msg,
-		Message: flag,
+// put encoded message in send queue without blocking
+// returns false if queue is full -> client is too slow and should be evicted
+func (client *Client) queue(data []byte) bool {
+	select {
+	case <-client.done:
+		return true // already shutting down, nothing to do
+	default:
 	}
-
-	client.send <- message.encode()
-}
-
-func (client *Client) SendGroupRequestAccept(groupId string) {
-	message := WsMessage{
-		Action:  GroupAcceptAction,
-		Message: groupId,
+	select {
+	case client.send <- data:
+		return true
+	default:
+		return false
 	}
+}
 
-	client.send <- message.encode()
+// signal writer to send close frame and stop
+// only first call has effect, so code and reason of first caller win
+func (client *Client) close(code int, reason string) {
+	client.closeOnce.Do(func() {
+		client.closeCode = code
+		client.closeReason = reason
+		close(client.done)
+	})
 }
 
 /* -------------------------------------------------------------------------- */
@@ -85,26 +101,30 @@ func (client *Client) SendGroupRequestAccept(groupId string) {
 /* -------------------------------------------------------------------------- */
 // define a writer which will send
 // new messages to our WebSocket endpoint
+// also keeps connection alive with periodic pings
+// on shutdown sends close frame and closes the connection
 func (client *Client) Writer() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		client.conn.Close()
+	}()
 	for {
-
-		message, ok := <-client.send
-		if !ok {
-			log.Println("err on writing message")
-			return
-		}
-		w, err := client.conn.NextWriter(websocket.TextMessage)
-		if err != nil {
-			return
-		}
-		_, err = w.Write(message)
-		if err != nil {
-			log.Println("Line 91", err)
-			return
-		}
-
-		if err := w.Close(); err != nil {
-			log.Println("Line 95", err)
+		select {
+		case message := <-client.send:
+			client.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := client.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				log.Println("Error on writing message", err)
+				return
+			}
+		case <-ticker.C:
+			client.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := client.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		case <-client.done:
+			client.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			client.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(client.closeCode, client.closeReason))
 			return
 		}
 	}
@@ -112,16 +132,32 @@ func (client *Client) Writer() {
 
 // define a reader which will listen for
 // new messages being sent to our WebSocketendpoint
+// and dispatch them to registered action handlers
+// connection is considered dead if no pong arrives within pongWait
 // Unregister client when client disconnect
 func (client *Client) Reader(wsServer *Server) {
 	defer wsServer.UnregisterClient(client)
+	client.conn.SetReadLimit(maxMessageSize)
+	client.conn.SetReadDeadline(time.Now().Add(pongWait))
+	client.conn.SetPongHandler(func(string) error {
+		client.conn.SetReadDeadline(time.Now().Add(pongWait))
+		return nil
+	})
 	for {
 		// read in a message
-		_, _, err := client.conn.ReadMessage()
+		_, data, err := client.conn.ReadMessage()
 		if err != nil {
-			log.Println(err)
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure, websocket.CloseNoStatusReceived) {
+				log.Println(err)
+			}
 			return
 		}
-		// log.Println(msg)
+		var message WsMessage
+		if err := json.Unmarshal(data, &message); err != nil {
+			wsServer.deliver(client, (&WsMessage{Action: ErrorAction, Message: "Error on reading the incomming message"}).encode())
+			continue
+		}
+		// messages from one connection are handled in order they arrive
+		wsServer.dispatch(client, message)
 	}
 }
@@ -10,13 +10,49 @@ import (
 const NotificationAction = "notification"
 const ChatAction = "chat"
 const GroupAcceptAction = "groupAccept"
+const PresenceOnlineAction = "presence.online"   // first connection of user opened
+const PresenceOfflineAction = "presence.offline" // last connection of user closed
+const SyncAction = "sync"                        // missed events replayed, seq is last event sequence number
+const ResyncAction = "resync"                    // missed events can't be replayed, refetch data over http
+const ReactionAction = "reaction"                // reaction counters of watched post or its comment changed
+const EventResponsesAction = "event.responses"   // response counters of watched event changed
+const ChatEditedAction = "chat.edited"           // sender changed content of chat message
+const ChatDeletedAction = "chat.deleted"         // chat message deleted, message is "everyone" or "me"
+const ChatReceiptAction = "chat.receipt"         // own message delivered to or read by recipient
+
+/* ------------------ actions sent by client over websocket ----------------- */
+const ChatSendAction = "chat.send"                 // new chat message, needs chatMessage
+const ChatTypingAction = "chat.typing"             // typing indicator, needs chatMessage receiver and type
+const ChatReadAction = "chat.read"                 // mark message as read, needs chatMessage id and type
+const ChatEditAction = "chat.edit"                 // edit own message, needs chatMessage id and content
+const ChatDeleteAction = "chat.delete"             // delete message, needs chatMessage id, message "everyone" deletes for all
+const NotificationReadAction = "notification.read" // mark notification as read, needs notification id
+const PingAction = "ping"                          // application level ping, answered with pong
+const PostWatchAction = "post.watch"               // start receiving live updates of posts, needs postIds
+const PostUnwatchAction = "post.unwatch"           // stop receiving live updates of posts, needs postIds
+const EventWatchAction = "event.watch"             // start receiving live updates of events, needs eventIds
+const EventUnwatchAction = "event.unwatch"         // stop receiving live updates of events, needs eventIds
+
+/* ------------------- replies to actions sent by client -------------------- */
+const AckAction = "ack"     // action succeeded, message contains result text
+const ErrorAction = "error" // action failed, message contains error text
+const PongAction = "pong"
 
 type WsMessage struct {
-	UserID       string              `json:"uid"`
-	Action       string              `json:"action"` //msg request action
-	Notification models.Notification `json:"notification"`
-	ChatMessage  models.ChatMessage  `json:"chatMessage"`
-	Message      string              `json:"message"`
+	UserID       string                  `json:"uid"`
+	Action       string                  `json:"action"`              //msg request action
+	RequestID    string                  `json:"requestId,omitempty"` // client generated id, echoed back in ack/error
+	Seq          int64                   `json:"seq,omitempty"`       // per user sequence number of durable events
+	Notification models.Notification     `json:"notification"`
+	ChatMessage  models.ChatMessage      `json:"chatMessage"`
+	Presence     *models.Presence        `json:"presence,omitempty"`
+	Reaction     *models.ReactionSummary `json:"reaction,omitempty"`
+	Receipt      *models.MessageReceipt  `json:"receipt,omitempty"`
+	PostIDs      []string                `json:"postIds,omitempty"`  // posts to (un)watch
+	EventIDs     []string                `json:"eventIds,omitempty"` // events to (un)watch
+
+	EventResponses *models.EventWithResponses `json:"eventResponses,omitempty"`
+	Message        string                     `json:"message"`
 }
 
 // encode method that can be called to create a json []byte object
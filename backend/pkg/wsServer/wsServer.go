@@ -1,29 +1,283 @@
 package ws
 
-import "social-network/pkg/models"
+import (
+	"log"
+	"social-network/pkg/models"
+	"social-network/pkg/utils"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// handles action sent by client
+// returns text for acknowledgement, or error that is sent back to client
+type ActionHandler func(client *Client, message WsMessage) (string, error)
 
 // represent websocket server
+// clients are indexed by user id, one user can have several
+// connections open at the same time (multiple tabs/devices)
 type Server struct {
-	Clients map[*Client]bool
-	Repos   *models.Repositories
+	mu       sync.RWMutex
+	clients  map[string][]*Client
+	handlers map[string]ActionHandler // inbound actions
+	Repos    *models.Repositories
+
+	watchMu  sync.Mutex                  //guards watchers and client.watching
+	watchers map[string]map[*Client]bool // post or event id -> connections viewing it
+
+	pruneMu   sync.Mutex // guards lastPrune
+	lastPrune time.Time  // when old durable events were deleted last
 }
 
 func StartServer(repos *models.Repositories) *Server {
 	server := &Server{
-		make(map[*Client]bool),
-		repos,
+		clients:  make(map[string][]*Client),
+		handlers: make(map[string]ActionHandler),
+		Repos:    repos,
+		watchers: make(map[string]map[*Client]bool),
 	}
 	return server
 }
 
+/* -------------------------------------------------------------------------- */
+/*                              inbound actions                               */
+/* -------------------------------------------------------------------------- */
+
+// register handler for action sent by clients
+func (s *Server) Handle(action string, handler ActionHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[action] = handler
+}
+
+// run handler registered for message action and reply to the client
+// with ack or error carrying the same request id
+func (s *Server) dispatch(client *Client, message WsMessage) {
+	reply := WsMessage{Action: AckAction, RequestID: message.RequestID}
+	if message.Action == PingAction {
+		reply.Action = PongAction
+		s.deliver(client, reply.encode())
+		return
+	}
+
+	s.mu.RLock()
+	handler, ok := s.handlers[message.Action]
+	s.mu.RUnlock()
+	if !ok {
+		reply.Action = ErrorAction
+		reply.Message = "Unknown action: " + message.Action
+		s.deliver(client, reply.encode())
+		return
+	}
+
+	result, err := handler(client, message)
+	if err != nil {
+		reply.Action = ErrorAction
+		reply.Message = err.Error()
+	} else {
+		reply.Message = result
+	}
+	s.deliver(client, reply.encode())
+}
+
+/* -------------------------------------------------------------------------- */
+/*                          client (un)registration                           */
+/* -------------------------------------------------------------------------- */
+
 // register client
+// if it is first connection of user, user goes online
 func (s *Server) RegisterNewClient(client *Client) {
-	s.Clients[client] = true //update client list
+	s.mu.Lock()
+	s.clients[client.ID] = append(s.clients[client.ID], client) //update client list
+	first := len(s.clients[client.ID]) == 1
+	s.mu.Unlock()
+
+	if first {
+		s.broadcastPresence(client.ID, true)
+	}
 }
 
-// register and unregister clients
+// unregister client and stop its writer
+// if it was last connection of user, user goes offline
+// safe to call several times for the same client
 func (s *Server) UnregisterClient(client *Client) {
-	if _, ok := s.Clients[client]; ok {
-		delete(s.Clients, client)
+	s.mu.Lock()
+	conns := s.clients[client.ID]
+	found := false
+	for i := 0; i < len(conns); i++ {
+		if conns[i] == client {
+			conns = append(conns[:i], conns[i+1:]...)
+			client.close(websocket.CloseNormalClosure, "")
+			found = true
+			break
+		}
+	}
+	if len(conns) == 0 {
+		delete(s.clients, client.ID)
+	} else {
+		s.clients[client.ID] = conns
+	}
+	last := found && len(conns) == 0
+	s.mu.Unlock()
+
+	s.unwatchAll(client)
+
+	if last {
+		s.broadcastPresence(client.ID, false)
 	}
-}
\ No newline at end of file
+}
+
+/* -------------------------------------------------------------------------- */
+/*                                   lookup                                   */
+/* -------------------------------------------------------------------------- */
+
+// returns snapshot of all open connections for user
+func (s *Server) ClientsOf(userID string) []*Client {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	conns := make([]*Client, len(s.clients[userID]))
+	copy(conns, s.clients[userID])
+	return conns
+}
+
+// returns true if user has at least one open connection
+func (s *Server) IsOnline(userID string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.clients[userID]) > 0
+}
+
+/* -------------------------------------------------------------------------- */
+/*                                   senders                                  */
+/* -------------------------------------------------------------------------- */
+
+// queue data for client without blocking the caller
+// client that can't keep up with its queue gets disconnected,
+// browser is expected to reconnect and refetch missed data
+func (s *Server) deliver(client *Client, data []byte) {
+	if client.queue(data) {
+		return
+	}
+	log.Println("Evicting slow websocket client of user", client.ID)
+	client.close(websocket.CloseTryAgainLater, "send queue full")
+	s.UnregisterClient(client)
+}
+
+// send message to every open connection of user
+func (s *Server) SendToUser(userID string, message WsMessage) {
+	data := message.encode()
+	for _, client := range s.ClientsOf(userID) {
+		s.deliver(client, data)
+	}
+}
+
+// send message to every open connection of each user in list
+// duplicated ids receive the message only once
+func (s *Server) SendToUsers(userIDs []string, message WsMessage) {
+	data := message.encode()
+	seen := make(map[string]bool)
+	for _, userID := range userIDs {
+		if seen[userID] {
+			continue
+		}
+		seen[userID] = true
+		for _, client := range s.ClientsOf(userID) {
+			s.deliver(client, data)
+		}
+	}
+}
+
+// send message to all group members (admin included)
+func (s *Server) SendToGroup(groupID string, message WsMessage) {
+	members, err := s.Repos.GroupRepo.GetMembers(groupID)
+	if err != nil {
+		log.Println("Error on getting group members:", err)
+		return
+	}
+	ids := make([]string, 0, len(members))
+	for _, member := range members {
+		ids = append(ids, member.ID)
+	}
+	s.SendToUsers(ids, message)
+}
+
+// Configure the notification with additional data about sender || group
+// Change the content to reusable sentence
+// send notification to user, saved for replay if user is offline
+func (s *Server) SendNotification(userID string, notif models.Notification) {
+	switch notif.Type {
+	case "GROUP_INVITE":
+		notif.Group, _ = s.Repos.GroupRepo.GetData(notif.Content)
+		notif.User, _ = s.Repos.UserRepo.GetDataMin(notif.Sender)
+	case "FOLLOW":
+		notif.User, _ = s.Repos.UserRepo.GetDataMin(notif.Content)
+	case "EVENT", "EVENT_REMINDER", "EVENT_PROMOTED", "EVENT_UPDATED", "EVENT_CANCELLED":
+		eventID, _ := utils.ParseEventChange(notif.Content)
+		notif.Event, _ = s.Repos.EventRepo.GetData(eventID)
+		notif.User, _ = s.Repos.UserRepo.GetDataMin(notif.Sender)
+		notif.Group, _ = s.Repos.GroupRepo.GetData(notif.Event.GroupID)
+	case "GROUP_REQUEST":
+		notif.User, _ = s.Repos.UserRepo.GetDataMin(notif.Content)
+		notif.Group, _ = s.Repos.GroupRepo.GetData(notif.TargetID)
+	case "CHAT_REQUEST", "REACTION", "COMMENT_REPLY":
+		notif.User, _ = s.Repos.UserRepo.GetDataMin(notif.Sender)
+	case "GROUP_ANNOUNCEMENT":
+		post, _ := s.Repos.PostRepo.GetData(notif.Content)
+		notif.User, _ = s.Repos.UserRepo.GetDataMin(notif.Sender)
+		notif.Group, _ = s.Repos.GroupRepo.GetData(post.GroupID)
+	case "MENTION":
+		msg, _ := s.Repos.MsgRepo.GetData(notif.Content)
+		notif.User, _ = s.Repos.UserRepo.GetDataMin(notif.Sender)
+		notif.Group, _ = s.Repos.GroupRepo.GetData(msg.ReceiverId)
+	}
+	/* ---------------------------- add message text ---------------------------- */
+	utils.DefineNotificationMsg(&notif)
+
+	s.Publish([]string{userID}, WsMessage{
+		Action:       NotificationAction,
+		Notification: notif,
+	})
+}
+
+// send chat message to all provided users, saved for replay
+func (s *Server) SendChatMessage(userIDs []string, msg models.ChatMessage, flag string) {
+	s.Publish(userIDs, WsMessage{
+		Action:      ChatAction,
+		ChatMessage: msg,
+		Message:     flag,
+	})
+}
+
+// send edited chat message to all provided users, saved for replay
+func (s *Server) SendChatEdit(userIDs []string, msg models.ChatMessage) {
+	s.Publish(userIDs, WsMessage{
+		Action:      ChatEditedAction,
+		ChatMessage: msg,
+	})
+}
+
+// send deleted chat message to all provided users, scope is "everyone" or "me"
+func (s *Server) SendChatDelete(userIDs []string, msg models.ChatMessage, scope string) {
+	s.Publish(userIDs, WsMessage{
+		Action:      ChatDeletedAction,
+		ChatMessage: msg,
+		Message:     scope,
+	})
+}
+
+// let sender know that message was delivered or read, not saved for replay
+func (s *Server) SendReceipt(userID string, receipt models.MessageReceipt) {
+	s.SendToUser(userID, WsMessage{
+		Action:  ChatReceiptAction,
+		Receipt: &receipt,
+	})
+}
+
+// let user know that request to join group was accepted
+func (s *Server) SendGroupRequestAccept(userID, groupId string) {
+	s.Publish([]string{userID}, WsMessage{
+		Action:  GroupAcceptAction,
+		Message: groupId,
+	})
+}
@@ -0,0 +1,169 @@
+package ws
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"social-network/pkg/models"
+
+	"github.com/gorilla/websocket"
+)
+
+// reads send queue of client until it is closed, counts received messages
+func drain(client *Client, received *atomic.Int64) {
+	for {
+		select {
+		case <-client.send:
+			received.Add(1)
+		case <-client.done:
+			return
+		}
+	}
+}
+
+// registration, sending and eviction of slow clients running at the same time
+// meant to be run with -race
+func TestConcurrentClients(t *testing.T) {
+	server, _ := newTestServer(t)
+	const (
+		users    = 4
+		senders  = 4
+		messages = 100 // per sender and user, more than slow client can queue
+		churn    = 50
+	)
+	if senders*messages <= sendBufferSize {
+		t.Fatal("slow clients wouldn't fill their queue")
+	}
+
+	fast := make([]*Client, users)
+	slow := make([]*Client, users)
+	counts := make([]atomic.Int64, users)
+	var drainers sync.WaitGroup
+	for i := 0; i < users; i++ {
+		userID := fmt.Sprint("user", i)
+		fast[i] = NewClient(nil, server.Repos, userID)
+		// fits every message, so busy senders can't get it evicted before drain catches up
+		fast[i].send = make(chan []byte, senders*messages)
+		slow[i] = NewClient(nil, server.Repos, userID)
+		drainers.Add(1)
+		go func(i int) {
+			defer drainers.Done()
+			drain(fast[i], &counts[i])
+		}(i)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < users; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			server.RegisterNewClient(fast[i])
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			server.RegisterNewClient(slow[i])
+		}(i)
+	}
+	wg.Wait()
+
+	// connections of other users open and close meanwhile
+	for c := 0; c < churn; c++ {
+		wg.Add(1)
+		go func(c int) {
+			defer wg.Done()
+			client := NewClient(nil, server.Repos, fmt.Sprint("churn", c%5))
+			server.RegisterNewClient(client)
+			server.SendToUser(client.ID, WsMessage{Action: ChatAction})
+			server.UnregisterClient(client)
+			server.UnregisterClient(client) // second call does nothing
+		}(c)
+	}
+	for s := 0; s < senders; s++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for m := 0; m < messages; m++ {
+				for i := 0; i < users; i++ {
+					server.SendToUser(fmt.Sprint("user", i), WsMessage{Action: ChatAction, Message: fmt.Sprint(m)})
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	want := int64(senders * messages)
+	deadline := time.Now().Add(5 * time.Second)
+	for i := 0; i < users; i++ {
+		for counts[i].Load() < want && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if got := counts[i].Load(); got != want {
+			t.Errorf("fast client of user%d got %d messages, want %d", i, got, want)
+		}
+		clients := server.ClientsOf(fast[i].ID)
+		if len(clients) != 1 || clients[0] != fast[i] {
+			t.Errorf("user%d has connections %v, want only fast one", i, clients)
+		}
+		select {
+		case <-slow[i].done:
+			if slow[i].closeCode != websocket.CloseTryAgainLater {
+				t.Errorf("slow client of user%d closed with %d", i, slow[i].closeCode)
+			}
+		default:
+			t.Errorf("slow client of user%d wasn't evicted", i)
+		}
+	}
+	for c := 0; c < 5; c++ {
+		if server.IsOnline(fmt.Sprint("churn", c)) {
+			t.Errorf("churn%d is still online", c)
+		}
+	}
+
+	for i := 0; i < users; i++ {
+		server.UnregisterClient(fast[i])
+	}
+	drainers.Wait()
+}
+
+// every inbound action is answered with request id of client
+func TestDispatchReplies(t *testing.T) {
+	server, _ := newTestServer(t)
+	server.Handle(ChatSendAction, func(client *Client, message WsMessage) (string, error) {
+		if message.ChatMessage.Content == "" {
+			return "", errors.New("Message is empty")
+		}
+		return "sent by " + client.ID, nil
+	})
+	client := NewClient(nil, server.Repos, "alice")
+
+	tests := []struct {
+		message WsMessage
+		want    WsMessage
+	}{
+		{WsMessage{Action: PingAction, RequestID: "1"}, WsMessage{Action: PongAction, RequestID: "1"}},
+		{WsMessage{Action: ChatSendAction, RequestID: "2", ChatMessage: models.ChatMessage{Content: "hi"}}, WsMessage{Action: AckAction, RequestID: "2", Message: "sent by alice"}},
+		{WsMessage{Action: ChatSendAction, RequestID: "3"}, WsMessage{Action: ErrorAction, RequestID: "3", Message: "Message is empty"}},
+		{WsMessage{Action: "chat.shout", RequestID: "4"}, WsMessage{Action: ErrorAction, RequestID: "4", Message: "Unknown action: chat.shout"}},
+	}
+	for _, test := range tests {
+		server.dispatch(client, test.message)
+		var got WsMessage
+		select {
+		case data := <-client.send:
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatal(err)
+			}
+		default:
+			t.Fatalf("%s got no reply", test.message.Action)
+		}
+		if got.Action != test.want.Action || got.RequestID != test.want.RequestID || got.Message != test.want.Message {
+			t.Errorf("%s: got %s %q %q, want %s %q %q", test.message.Action, got.Action, got.RequestID, got.Message,
+				test.want.Action, test.want.RequestID, test.want.Message)
+		}
+	}
+}
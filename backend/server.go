@@ -2,13 +2,20 @@ package main
 
 import (
 	"fmt"
+	"log"
 	"net/http"
+	"os"
 	sqlite "social-network/pkg/db/sqlite"
 	"social-network/pkg/handlers"
+	"social-network/pkg/scheduler"
 	"social-network/pkg/utils"
 	ws "social-network/pkg/wsServer"
+	"time"
 )
 
+// how often scheduler checks for due jobs
+const schedulerInterval = 30 * time.Second
+
 func main() {
 	// initialize database
 	db := sqlite.InitDB()
@@ -20,6 +27,20 @@ func main() {
 	handler := handlers.InitHandlers(repos)
 	// initialize wsServer
 	wsServer := ws.StartServer(repos)
+	// actions that clients can send through websocket
+	handler.RegisterSocketActions(wsServer)
+	// background jobs, e.g. EVENT_REMINDER_OFFSETS="24h,1h"
+	reminderOffsets, err := handlers.ParseReminderOffsets(os.Getenv("EVENT_REMINDER_OFFSETS"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	jobs := scheduler.New(repos.JobRepo, nil)
+	handler.RegisterJobs(wsServer, jobs, reminderOffsets)
+	if err := handler.ScheduleUpcomingReminders(); err != nil {
+		log.Println("Error on scheduling reminders of upcoming events:", err)
+	}
+	jobs.Start(schedulerInterval)
+	defer jobs.Stop()
 
 	// set up server address and routes
 	server := &http.Server{
@@ -43,6 +64,7 @@ func setRoutes(handler *handlers.Handler, wsServer *ws.Server) http.Handler {
 	mux.HandleFunc("/register", handler.Register)
 	mux.HandleFunc("/signin", handler.Signin)
 	mux.HandleFunc("/logout", handler.Auth(handler.Logout))
+	mux.HandleFunc("/deleteAccount", handler.Auth(handler.DeleteAccount)) // delete own account, owned groups go to successor or are deleted
 	mux.HandleFunc("/sessionActive", handler.SessionActive)
 
 	/* ---------------------------------- users --------------------------------- */
@@ -65,9 +87,24 @@ func setRoutes(handler *handlers.Handler, wsServer *ws.Server) http.Handler {
 	mux.HandleFunc("/allPosts", handler.Auth(handler.AllPosts))   // all posts- main page
 	mux.HandleFunc("/userPosts", handler.Auth(handler.UserPosts)) // all user posts - user page
 	mux.HandleFunc("/newPost", handler.Auth(handler.NewPost))     // create route
+	mux.HandleFunc("/editPost", handler.Auth(handler.EditPost))     // edit own post
+	mux.HandleFunc("/deletePost", handler.Auth(handler.DeletePost)) // delete own post (or group post as moderator)
+	mux.HandleFunc("/pinPost", handler.Auth(handler.PinPost))       // pin group post on top of group feed (moderator)
+	mux.HandleFunc("/announcePost", handler.Auth(func(w http.ResponseWriter, r *http.Request) { // notify group members about post (moderator)
+		handler.AnnouncePost(wsServer, w, r)
+	}))
 
 	/* -------------------------------- comments -------------------------------- */
-	mux.HandleFunc("/newComment", handler.Auth(handler.NewComment)) // create route
+	mux.HandleFunc("/newComment", handler.Auth(func(w http.ResponseWriter, r *http.Request) { // create route, reply with parentid
+		handler.NewComment(wsServer, w, r)
+	}))
+	mux.HandleFunc("/editComment", handler.Auth(handler.EditComment))     // edit own comment
+	mux.HandleFunc("/deleteComment", handler.Auth(handler.DeleteComment)) // delete own comment (or on group post as moderator)
+
+	/* -------------------------------- reactions ------------------------------- */
+	mux.HandleFunc("/reaction", handler.Auth(func(w http.ResponseWriter, r *http.Request) { // add, change or remove reaction
+		handler.ToggleReaction(wsServer, w, r)
+	}))
 
 	/* --------------------------------- groups --------------------------------- */
 	mux.HandleFunc("/allGroups", handler.Auth(handler.AllGroups))             // group list
@@ -84,12 +121,18 @@ func setRoutes(handler *handlers.Handler, wsServer *ws.Server) http.Handler {
 	mux.HandleFunc("/newGroup", handler.Auth(func(w http.ResponseWriter, r *http.Request) {
 		handler.NewGroup(wsServer, w, r)
 	})) // create new group
+	mux.HandleFunc("/editGroup", handler.Auth(func(w http.ResponseWriter, r *http.Request) {
+		handler.EditGroup(wsServer, w, r)
+	})) // owner changes name, description, privacy or image
+	mux.HandleFunc("/deleteGroup", handler.Auth(handler.DeleteGroup)) // owner deletes group with everything in it
+	mux.HandleFunc("/groupJoinRules", handler.Auth(handler.GroupJoinRules))       // questions to answer when requesting to join
+	mux.HandleFunc("/setGroupJoinRules", handler.Auth(handler.SetGroupJoinRules)) // owner sets questions and auto approval
 	mux.HandleFunc("/newGroupPost", handler.Auth(handler.NewGroupPost))                           // create new group post
 	mux.HandleFunc("/newGroupInvite", handler.Auth(func(w http.ResponseWriter, r *http.Request) { // invite new users to group
 		handler.NewGroupInvite(wsServer, w, r)
 	}))
 	mux.HandleFunc("/responseGroupInvite", handler.Auth(handler.ResponseInviteRequest)) // response to group invitation
-	mux.HandleFunc("/newGroupRequest", handler.Auth(func(w http.ResponseWriter, r *http.Request) { // invite new users to group
+	mux.HandleFunc("/newGroupRequest", handler.Auth(func(w http.ResponseWriter, r *http.Request) { // request to join group, JSON {answers} if group asks questions
 		handler.NewGroupRequest(wsServer, w, r)
 	}))
 	mux.HandleFunc("/responseGroupRequest", handler.Auth(func(w http.ResponseWriter, r *http.Request) {
@@ -101,15 +144,41 @@ func setRoutes(handler *handlers.Handler, wsServer *ws.Server) http.Handler {
 	// get all pending invites for a group
 	mux.HandleFunc("/checkGroupInvitations", handler.Auth(handler.CheckGroupInvitations)) // check existing invitations
 	mux.HandleFunc("/joinPublicGroup", handler.Auth(handler.JoinPublicGroup)) // join public group directly
-	mux.HandleFunc("/leaveGroup", handler.Auth(handler.LeaveGroup))           // leave group (members only, not admins)
+	mux.HandleFunc("/leaveGroup", handler.Auth(handler.LeaveGroup))           // leave group (owner passes group to successor)
+	mux.HandleFunc("/setGroupRole", handler.Auth(handler.SetGroupRole))                     // owner makes member moderator or back
+	mux.HandleFunc("/transferGroupOwnership", handler.Auth(handler.TransferGroupOwnership)) // owner hands group to member
+	mux.HandleFunc("/removeGroupMember", handler.Auth(handler.RemoveGroupMember))           // moderator removes member
+	mux.HandleFunc("/banGroupMember", handler.Auth(handler.BanGroupMember))                 // remove and block from rejoining
+	mux.HandleFunc("/unbanGroupMember", handler.Auth(handler.UnbanGroupMember))
+	mux.HandleFunc("/muteGroupMember", handler.Auth(handler.MuteGroupMember)) // block posts, chat and events for some minutes
+	mux.HandleFunc("/unmuteGroupMember", handler.Auth(handler.UnmuteGroupMember))
+	mux.HandleFunc("/groupModerationLog", handler.Auth(handler.GroupModerationLog)) // audit trail of kicks, bans and mutes
+	mux.HandleFunc("/newInviteLink", handler.Auth(handler.NewInviteLink))       // shareable join link with optional expiry and max uses
+	mux.HandleFunc("/inviteLinks", handler.Auth(handler.InviteLinks))           // active links with join counts
+	mux.HandleFunc("/revokeInviteLink", handler.Auth(handler.RevokeInviteLink))
+	mux.HandleFunc("/redeemInviteLink", handler.Auth(handler.RedeemInviteLink)) // join group through link
 
 	/* --------------------------------- events --------------------------------- */
 	mux.HandleFunc("/newEvent", handler.Auth(func(w http.ResponseWriter, r *http.Request) {
 		handler.NewEvent(wsServer, w, r)
 	})) // create new
-	mux.HandleFunc("/participate", handler.Auth(handler.Participate)) // react to participation in event
-	mux.HandleFunc("/updateEventResponse", handler.Auth(handler.UpdateEventResponse)) // update RSVP response
+	mux.HandleFunc("/participate", handler.Auth(func(w http.ResponseWriter, r *http.Request) {
+		handler.Participate(wsServer, w, r)
+	})) // react to participation in event (YES/NO)
+	mux.HandleFunc("/updateEventResponse", handler.Auth(func(w http.ResponseWriter, r *http.Request) {
+		handler.UpdateEventResponse(wsServer, w, r)
+	})) // update RSVP response, waitlist when event is full
+	mux.HandleFunc("/editEvent", handler.Auth(func(w http.ResponseWriter, r *http.Request) {
+		handler.EditEvent(wsServer, w, r)
+	})) // edit title, content or date (author or group admin)
+	mux.HandleFunc("/cancelEvent", handler.Auth(func(w http.ResponseWriter, r *http.Request) {
+		handler.CancelEvent(wsServer, w, r)
+	})) // cancel event, kept in history (author or group admin)
 	mux.HandleFunc("/getGroupEvents", handler.Auth(handler.GetGroupEvents)) // get group events with responses
+	mux.HandleFunc("/eventCalendar", handler.Auth(handler.EventCalendar)) // single event as .ics
+	mux.HandleFunc("/groupCalendar", handler.Auth(handler.GroupCalendar)) // group events as .ics
+	mux.HandleFunc("/calendarToken", handler.Auth(handler.CalendarToken)) // get (GET) or rotate (POST) feed link
+	mux.HandleFunc("/calendar/", handler.CalendarFeed)                    // tokenized subscription feed, no session
 
 	/* ------------------------------ notifications ----------------------------- */
 	mux.HandleFunc("/notifications", handler.Auth(handler.Notifications)) //get all notifs from db on login
@@ -118,14 +187,29 @@ func setRoutes(handler *handlers.Handler, wsServer *ws.Server) http.Handler {
 	mux.HandleFunc("/dismissNotification", handler.Auth(handler.DismissNotification)) //dismiss/delete specific notification
 
 	/* ------------------------------ chat messages ----------------------------- */
-	mux.HandleFunc("/messages", handler.Auth(handler.Messages))             //get all chat messages for specific chat
+	mux.HandleFunc("/messages", handler.Auth(func(w http.ResponseWriter, r *http.Request) {
+		handler.Messages(wsServer, w, r)
+	})) //get all chat messages for specific chat
 	mux.HandleFunc("/unreadMessages", handler.Auth(handler.UnreadMessages)) //get list of messages that isn't read
-	mux.HandleFunc("/messageRead", handler.Auth(handler.MessageRead))       //mark message as read
+	mux.HandleFunc("/messageRead", handler.Auth(func(w http.ResponseWriter, r *http.Request) {
+		handler.MessageRead(wsServer, w, r)
+	})) //mark message as read
+	mux.HandleFunc("/messageReceipts", handler.Auth(handler.MessageReceipts)) // delivery and read receipts of own message
 	mux.HandleFunc("/newMessage", handler.Auth(func(w http.ResponseWriter, r *http.Request) {
 		handler.NewMessage(wsServer, w, r)
 	})) // new chat message
+	mux.HandleFunc("/editMessage", handler.Auth(func(w http.ResponseWriter, r *http.Request) {
+		handler.EditMessage(wsServer, w, r)
+	})) // sender edits own chat message
+	mux.HandleFunc("/deleteMessage", handler.Auth(func(w http.ResponseWriter, r *http.Request) {
+		handler.DeleteMessage(wsServer, w, r)
+	})) // delete chat message for self or everyone
 	mux.HandleFunc("/chatList", handler.Auth(handler.ChatList))                       //get list of users to display in chatbox
+	mux.HandleFunc("/presence", handler.Auth(func(w http.ResponseWriter, r *http.Request) {
+		handler.Presence(wsServer, w, r)
+	})) // online status and last seen of chat list users
 	mux.HandleFunc("/responseChatRequest", handler.Auth(handler.ResponseChatRequest)) // response to chat request
+	mux.HandleFunc("/muteGroupChat", handler.Auth(handler.MuteGroupChat))             // (un)mute group chat for current user
 
 	/* ---------------------------- websocket server ---------------------------- */
 	mux.HandleFunc("/ws", handler.Auth(func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,24 @@
+// Cursor pagination helpers
+// Lists come newest first in pages of limited size, response carries nextCursor while older items remain
+
+// Adds cursor of the page to load to url
+export function withCursor(url, cursor) {
+  if (!cursor) return url
+  return url + (url.includes('?') ? '&' : '?') + `cursor=${encodeURIComponent(cursor)}`
+}
+
+// Follows nextCursor until the last page, for lists that must be complete (e.g. pending requests)
+export async function fetchAllPages(url, key, options = {}) {
+  const items = []
+  let cursor = ''
+  do {
+    const res = await fetch(withCursor(url, cursor), options)
+    const data = await res.json()
+    if (!res.ok || data.type === 'Error') {
+      throw new Error(data.message || `Failed to fetch ${url}`)
+    }
+    items.push(...(data[key] || []))
+    cursor = data.nextCursor || ''
+  } while (cursor)
+  return items
+}
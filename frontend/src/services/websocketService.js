@@ -8,6 +8,8 @@ let reconnectTimeout = null
 const RECONNECT_DELAY = 2000
 const listeners = new Map()
 let isConnecting = false
+// Last event sequence received, sent on reconnect to replay missed events
+let lastSeq = null
 
 // Reactive connection status
 const connectionStatus = ref(false)
@@ -26,7 +28,10 @@ const wsService = {
     try {
       console.log('Connecting to WebSocket server...')
       // Use the same origin as the current page to inherit cookies/session
-      const wsUrl = `ws://${window.location.hostname}:8081/ws`
+      let wsUrl = `ws://${window.location.hostname}:8081/ws`
+      if (lastSeq !== null) {
+        wsUrl += `?since=${lastSeq}`
+      }
       console.log('WebSocket URL:', wsUrl)
       socket = new WebSocket(wsUrl)
       
@@ -49,6 +54,14 @@ const wsService = {
           const data = JSON.parse(event.data)
           console.log('📨 Parsed WebSocket data:', data)
           
+          // Remember replay position, resync means missed events are gone
+          // live event can come with lower seq than sync, position never goes back
+          if (data.action === 'resync') {
+            lastSeq = data.seq ?? null
+          } else if (data.seq && (lastSeq === null || data.seq > lastSeq)) {
+            lastSeq = data.seq
+          }
+          
           // Debug: Show notification structure
           if (data.action === 'notification') {
             console.log('🔔 Notification details:', {
@@ -91,12 +104,14 @@ const wsService = {
           console.log('🚫 Policy violation (possibly authentication issue)')
         } else if (event.code === 1011) {
           console.log('💥 Server error')
+        } else if (event.code === 1013) {
+          console.log('🐢 Disconnected by server, client too slow')
         } else {
           console.log('❓ Unknown close code:', event.code)
         }
         
         // Only auto-reconnect for network issues
-        if (event.code === 1006 || event.code === 1011) {
+        if (event.code === 1006 || event.code === 1011 || event.code === 1013) {
           console.log('🔄 Attempting to reconnect WebSocket...')
           // Schedule reconnection
           if (!reconnectTimeout) {
@@ -134,6 +149,7 @@ const wsService = {
     }
     
     connectionStatus.value = false // Update reactive status
+    lastSeq = null
     
     // Clear all listeners
     listeners.clear()
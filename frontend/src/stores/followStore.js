@@ -1,5 +1,6 @@
 import { ref } from 'vue'
 import { defineStore } from 'pinia'
+import { fetchAllPages } from '../services/pagination'
 
 export const useFollowStore = defineStore('follow', () => {
   // Listes réactives
@@ -32,12 +33,10 @@ export const useFollowStore = defineStore('follow', () => {
   // Récupère les demandes de suivi en attente (pour profils privés)
   async function fetchFollowRequests() {
     try {
-      const res = await fetch('http://localhost:8081/notifications', { credentials: 'include' })
-      const data = await res.json()
+      // Toutes les pages, une demande en attente peut être ancienne
+      const notifications = await fetchAllPages('http://localhost:8081/notifications', 'notifications', { credentials: 'include' })
       // Filtrer les notifications de type FOLLOW
-      followRequests.value = (res.ok && data.notifications)
-        ? data.notifications.filter(n => n.type === 'FOLLOW')
-        : []
+      followRequests.value = notifications.filter(n => n.type === 'FOLLOW')
 
         
     } catch (e) {
@@ -1,5 +1,6 @@
 import { defineStore } from 'pinia'
 import { ref, computed } from 'vue'
+import { withCursor, fetchAllPages } from '../services/pagination'
 
 export const useGroupStore = defineStore('group', () => {
   // State
@@ -9,6 +10,9 @@ export const useGroupStore = defineStore('group', () => {
   const currentGroup = ref(null)
   const members = ref([])
   const posts = ref([])
+  // Cursor of older group posts, empty when all are loaded
+  const postsCursor = ref('')
+  const loadingMorePosts = ref(false)
   const events = ref([])
   const invitations = ref([])
   const requests = ref([])
@@ -88,13 +92,13 @@ export const useGroupStore = defineStore('group', () => {
 
   const fetchNotifications = async () => {
     try {
-      const response = await fetch('http://localhost:8081/notifications', {
+      // Every page is needed, pending invitations and requests can be old
+      const notifications = await fetchAllPages('http://localhost:8081/notifications', 'notifications', {
         credentials: 'include'
       })
-      if (response.ok) {
-        const data = await response.json()
+      {
         // Filter for group invitations
-        invitations.value = (data.notifications || [])
+        invitations.value = notifications
           .filter(notif => notif.type === 'GROUP_INVITE')
           .map(notif => ({
             id: notif.id,
@@ -104,7 +108,7 @@ export const useGroupStore = defineStore('group', () => {
           }))
         
         // Filter for group requests (if user is admin)
-        requests.value = (data.notifications || [])
+        requests.value = notifications
           .filter(notif => notif.type === 'GROUP_REQUEST')
           .map(notif => ({
             id: notif.id,
@@ -112,10 +116,6 @@ export const useGroupStore = defineStore('group', () => {
             group: notif.group || { name: 'Groupe' },
             createdAt: notif.createdAt
           }))
-      } else {
-        console.error('Failed to load notifications')
-        invitations.value = []
-        requests.value = []
       }
     } catch (err) {
       console.error('Error loading notifications:', err)
@@ -174,6 +174,7 @@ export const useGroupStore = defineStore('group', () => {
     }
   }
 
+  // Loads first page of group posts, pinned posts come first
   const fetchGroupPosts = async (groupId) => {
     try {
       const response = await fetch(`http://localhost:8081/groupPosts?groupId=${groupId}`, {
@@ -182,12 +183,34 @@ export const useGroupStore = defineStore('group', () => {
       if (response.ok) {
         const data = await response.json()
         posts.value = data.posts || []
+        postsCursor.value = data.nextCursor || ''
       }
     } catch (err) {
       console.error('Error fetching group posts:', err)
     }
   }
 
+  // Appends next page of group posts
+  const loadMoreGroupPosts = async (groupId) => {
+    if (!postsCursor.value || loadingMorePosts.value) return
+    loadingMorePosts.value = true
+    try {
+      const response = await fetch(withCursor(`http://localhost:8081/groupPosts?groupId=${groupId}`, postsCursor.value), {
+        credentials: 'include'
+      })
+      if (response.ok) {
+        const data = await response.json()
+        const known = new Set(posts.value.map(post => post.id))
+        posts.value = [...posts.value, ...(data.posts || []).filter(post => !known.has(post.id))]
+        postsCursor.value = data.nextCursor || ''
+      }
+    } catch (err) {
+      console.error('Error fetching more group posts:', err)
+    } finally {
+      loadingMorePosts.value = false
+    }
+  }
+
   let lastFetchTime = 0
   const FETCH_COOLDOWN = 1000 // 1 second cooldown
 
@@ -523,6 +546,8 @@ export const useGroupStore = defineStore('group', () => {
     members,
     posts,
     events,
+    postsCursor,
+    loadingMorePosts,
     invitations,
     requests,
     loading,
@@ -548,6 +573,7 @@ export const useGroupStore = defineStore('group', () => {
     fetchGroupDetails,
     fetchGroupMembers,
     fetchGroupPosts,
+    loadMoreGroupPosts,
     fetchGroupEvents,
     updateEventResponse,
     createGroupPost,
@@ -2,10 +2,13 @@ import { defineStore } from 'pinia'
 import { ref, computed, nextTick } from 'vue'
 import { useGroupStore } from './groupStore'
 import { useFollowStore } from './followStore'
+import { withCursor } from '../services/pagination'
 
 export const useNotificationStore = defineStore('notification', () => {
   const notifications = ref([])
   const loading = ref(false)
+  const notificationsCursor = ref('')
+  const loadingMore = ref(false)
   const hasNewNotifications = ref(false)
   const processingRequests = ref(new Set()) // Track which requests are being processed
   const error = ref(null)
@@ -66,6 +69,7 @@ export const useNotificationStore = defineStore('notification', () => {
       const data = await res.json()
       
       notifications.value = data.notifications || []
+      notificationsCursor.value = data.nextCursor || ''
       hasNewNotifications.value = false
       
       // Also update notifications in other stores
@@ -85,6 +89,23 @@ export const useNotificationStore = defineStore('notification', () => {
     }
   }
   
+  // Appends next page of older notifications
+  async function loadMoreNotifications() {
+    if (!notificationsCursor.value || loadingMore.value) return
+    loadingMore.value = true
+    try {
+      const res = await fetch(withCursor('http://localhost:8081/notifications', notificationsCursor.value), { credentials: 'include' })
+      const data = await res.json()
+      const known = new Set(notifications.value.map(n => n.id))
+      notifications.value.push(...(data.notifications || []).filter(n => !known.has(n.id)))
+      notificationsCursor.value = data.nextCursor || ''
+    } catch (e) {
+      console.error('Error fetching notifications:', e)
+    } finally {
+      loadingMore.value = false
+    }
+  }
+
   async function respondToFollowRequest(requestId, response) {
     processingRequests.value.add(requestId)
     error.value = null
@@ -284,6 +305,8 @@ export const useNotificationStore = defineStore('notification', () => {
   return {
     notifications,
     loading,
+    notificationsCursor,
+    loadingMore,
     hasNewNotifications,
     processingRequests,
     error,
@@ -295,6 +318,7 @@ export const useNotificationStore = defineStore('notification', () => {
     unreadEventNotifications,
     recentUnreadNotifications,
     fetchNotifications,
+    loadMoreNotifications,
     respondToFollowRequest,
     respondToGroupInvite,
     respondToGroupRequest,
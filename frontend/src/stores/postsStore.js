@@ -1,10 +1,14 @@
 import { defineStore } from 'pinia';
 import { ref, computed } from 'vue';
+import { withCursor } from '../services/pagination';
 
 export const useMainStore = defineStore('main', () => {
   // Global state
   const count = ref(0);
   const posts = ref([]);
+  // Cursor of older feed posts, empty when the whole feed is loaded
+  const postsCursor = ref('');
+  const loadingMorePosts = ref(false);
   
   // Comment UI state
   const activeCommentPostId = ref(null);
@@ -25,12 +29,14 @@ export const useMainStore = defineStore('main', () => {
     count.value++;
   };
 
+  // Loads first page of the feed
   const fetchPosts = async () => {
     try {
       const res = await fetch('http://localhost:8081/allPosts', { credentials: 'include' });
       if (res.ok) {
         const data = await res.json();
         posts.value = Array.isArray(data.posts) ? data.posts : [];
+        postsCursor.value = data.nextCursor || '';
       } else {
         console.error('Failed to fetch posts:', await res.text());
       }
@@ -39,19 +45,41 @@ export const useMainStore = defineStore('main', () => {
     }
   };
 
-  const fetchUserPosts = async (userId) => {
+  // Appends next page of the feed
+  const loadMorePosts = async () => {
+    if (!postsCursor.value || loadingMorePosts.value) return;
+    loadingMorePosts.value = true;
     try {
-      const res = await fetch(`http://localhost:8081/userPosts?id=${userId}`, { credentials: 'include' });
+      const res = await fetch(withCursor('http://localhost:8081/allPosts', postsCursor.value), { credentials: 'include' });
       if (res.ok) {
         const data = await res.json();
-        return Array.isArray(data.posts) ? data.posts : [];
+        const known = new Set(posts.value.map(post => post.id));
+        posts.value = [...posts.value, ...(data.posts || []).filter(post => !known.has(post.id))];
+        postsCursor.value = data.nextCursor || '';
+      } else {
+        console.error('Failed to fetch more posts:', await res.text());
+      }
+    } catch (error) {
+      console.error('Error fetching more posts:', error);
+    } finally {
+      loadingMorePosts.value = false;
+    }
+  };
+
+  // Returns page of user posts with cursor of the next one
+  const fetchUserPosts = async (userId, cursor = '') => {
+    try {
+      const res = await fetch(withCursor(`http://localhost:8081/userPosts?id=${userId}`, cursor), { credentials: 'include' });
+      if (res.ok) {
+        const data = await res.json();
+        return { posts: Array.isArray(data.posts) ? data.posts : [], nextCursor: data.nextCursor || '' };
       } else {
         console.error('Failed to fetch user posts:', await res.text());
-        return [];
+        return { posts: [], nextCursor: '' };
       }
     } catch (error) {
       console.error('Error fetching user posts:', error);
-      return [];
+      return { posts: [], nextCursor: '' };
     }
   };
 
@@ -328,6 +356,8 @@ export const useMainStore = defineStore('main', () => {
     // State
     count,
     posts,
+    postsCursor,
+    loadingMorePosts,
     
     // Comment UI state
     activeCommentPostId,
@@ -349,6 +379,7 @@ export const useMainStore = defineStore('main', () => {
     // Basic actions
     increment,
     fetchPosts,
+    loadMorePosts,
     fetchUserPosts,
     submitComment,
     